@@ -7,64 +7,130 @@ import (
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/service/attachment"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/service/comment"
+	"github.com/maximis3d/issue-tracking-system/service/component"
 	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/service/label"
+	"github.com/maximis3d/issue-tracking-system/service/link"
 	"github.com/maximis3d/issue-tracking-system/service/project"
 	projectassignment "github.com/maximis3d/issue-tracking-system/service/project_assignment"
 	projectscopes "github.com/maximis3d/issue-tracking-system/service/project_scopes"
+	"github.com/maximis3d/issue-tracking-system/service/search"
+	"github.com/maximis3d/issue-tracking-system/service/session"
 	"github.com/maximis3d/issue-tracking-system/service/sprints"
+	"github.com/maximis3d/issue-tracking-system/service/sso"
 	"github.com/maximis3d/issue-tracking-system/service/standups"
+	"github.com/maximis3d/issue-tracking-system/service/tokens"
+	"github.com/maximis3d/issue-tracking-system/service/twofactor"
 	"github.com/maximis3d/issue-tracking-system/service/user"
+	"github.com/maximis3d/issue-tracking-system/service/workflow"
+	"github.com/maximis3d/issue-tracking-system/types"
 )
 
 type APIServer struct {
-	addr string
-	db   *sql.DB
+	addr   string
+	db     *sql.DB
+	mailer types.Mailer
+	blobs  types.BlobStore
 }
 
-func NewAPIServer(addr string, db *sql.DB) *APIServer {
+func NewAPIServer(addr string, db *sql.DB, mailer types.Mailer, blobs types.BlobStore) *APIServer {
 	return &APIServer{
-		addr: addr,
-		db:   db,
+		addr:   addr,
+		db:     db,
+		mailer: mailer,
+		blobs:  blobs,
 	}
 }
 
 func (s *APIServer) Run() error {
 	router := mux.NewRouter()
-	subrouter := router.PathPrefix("/api/v1").Subrouter()
+	publicRouter := router.PathPrefix("/api/v1").Subrouter()
 
 	userStore := user.NewStore(s.db)
-	userHandler := user.NewHandler(userStore)
-	userHandler.RegisterRoutes(subrouter)
+	sessionStore := session.NewStore(s.db)
+	userHandler := user.NewHandler(userStore, sessionStore, userStore, userStore, userStore, s.mailer)
+	userHandler.RegisterRoutes(publicRouter)
+
+	groupRoles, err := sso.ParseGroupRoles(config.Envs.OIDCGroupRoles)
+	if err != nil {
+		return err
+	}
+	projectAssignmentStore := projectassignment.NewStore(s.db)
+	ssoHandler := sso.NewHandler(sso.NewProviderFromEnv(), groupRoles, sso.NewStore(s.db), userStore, sessionStore, projectAssignmentStore)
+	ssoHandler.RegisterRoutes(publicRouter)
+
+	// Every other route requires a valid JWT or personal access token
+	subrouter := router.PathPrefix("/api/v1").Subrouter()
+	accessTokenStore := tokens.NewStore(s.db)
+	subrouter.Use(auth.WithAuth(userStore, sessionStore, accessTokenStore))
+
+	userHandler.RegisterProtectedRoutes(subrouter)
+
+	twoFactorHandler := twofactor.NewHandler(userStore, userStore, userStore)
+	twoFactorHandler.RegisterRoutes(subrouter)
+
+	sessionHandler := session.NewHandler(sessionStore, userStore)
+	sessionHandler.RegisterRoutes(subrouter)
+
+	accessTokenHandler := tokens.NewHandler(accessTokenStore)
+	accessTokenHandler.RegisterRoutes(subrouter)
+
+	projectassignmentHandler := projectassignment.NewHandler(projectAssignmentStore, projectAssignmentStore)
+	projectassignmentHandler.RegisterRoutes(subrouter)
 
 	projectStore := project.NewStore(s.db)
-	projectHandler := project.NewHandler(projectStore)
+	projectHandler := project.NewHandler(projectStore, projectAssignmentStore)
 	projectHandler.RegisterRoutes(subrouter)
 
+	workflowHandler := workflow.NewHandler(workflow.NewStore(s.db), projectAssignmentStore)
+	workflowHandler.RegisterRoutes(subrouter)
+
+	componentStore := component.NewStore(s.db)
+	componentHandler := component.NewHandler(componentStore, projectAssignmentStore)
+	componentHandler.RegisterRoutes(subrouter)
+
+	labelHandler := label.NewHandler(label.NewStore(s.db), projectAssignmentStore)
+	labelHandler.RegisterRoutes(subrouter)
+
+	searchIndex := search.NewIndex(s.db)
 	issueStore := issue.NewStore(s.db)
-	issueHandler := issue.NewHandler(issueStore)
+	issueHandler := issue.NewHandler(issueStore, projectAssignmentStore, projectStore, userStore, componentStore, searchIndex)
 	issueHandler.RegisterRoutes(subrouter)
 
+	commentHandler := comment.NewHandler(comment.NewStore(s.db), issueStore, projectAssignmentStore, searchIndex)
+	commentHandler.RegisterRoutes(subrouter)
+
+	linkHandler := link.NewHandler(link.NewStore(s.db), issueStore, projectAssignmentStore)
+	linkHandler.RegisterRoutes(subrouter)
+
+	attachmentHandler := attachment.NewHandler(attachment.NewStore(s.db), s.blobs, issueStore, projectAssignmentStore)
+	attachmentHandler.RegisterRoutes(subrouter)
+
+	searchHandler := search.NewHandler(search.NewStore(s.db), searchIndex)
+	searchHandler.RegisterRoutes(subrouter)
+
 	standupStore := standups.NewStore(s.db)
-	standupHandler := standups.NewHandler(standupStore)
+	standupHandler := standups.NewHandler(standupStore, projectAssignmentStore)
 	standupHandler.RegisterRoutes(subrouter)
 
 	scopeStore := projectscopes.NewStore(s.db)
-	scopeHandler := projectscopes.NewHandler(scopeStore)
+	scopeHandler := projectscopes.NewHandler(scopeStore, projectAssignmentStore)
 	scopeHandler.RegisterRoutes(subrouter)
 
-	projectAssignmentStore := projectassignment.NewStore(s.db)
-	projectassignmentHandler := projectassignment.NewHandler((projectAssignmentStore))
-	projectassignmentHandler.RegisterRoutes(subrouter)
-
 	sprintsStore := sprints.NewStore(s.db)
-	sprintsHandler := sprints.NewHandler(sprintsStore)
+	sprintsHandler := sprints.NewHandler(sprintsStore, projectAssignmentStore)
 	sprintsHandler.RegisterRoutes(subrouter)
 
 	// Enable CORS
 	corsHandler := handlers.CORS(
 		handlers.AllowedOrigins([]string{"http://localhost:5173"}),
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "If-Match"}),
+		handlers.ExposedHeaders([]string{"ETag"}),
 	)
 
 	log.Println("Listening on", s.addr)
@@ -8,6 +8,8 @@ import (
 	"github.com/maximis3d/issue-tracking-system/cmd/api"
 	"github.com/maximis3d/issue-tracking-system/config"
 	"github.com/maximis3d/issue-tracking-system/db"
+	"github.com/maximis3d/issue-tracking-system/service/blob"
+	"github.com/maximis3d/issue-tracking-system/service/mailer"
 )
 
 func main() {
@@ -28,7 +30,17 @@ func main() {
 
 	initStorage(db)
 
-	server := api.NewAPIServer(":8080", db)
+	mailer, err := mailer.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	blobs, err := blob.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := api.NewAPIServer(":8080", db, mailer, blobs)
 
 	if err := server.Run(); err != nil {
 		log.Fatal(err)
@@ -21,6 +21,8 @@ func main() {
 		Net:                  "tcp",
 		AllowNativePasswords: true,
 		ParseTime:            true,
+		// Migrations may hold several statements
+		MultiStatements: true,
 	})
 
 	if err != nil {
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sessions;
+
+ALTER TABLE users DROP COLUMN `is_admin`;
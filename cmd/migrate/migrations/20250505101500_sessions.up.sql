@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN `is_admin` BOOLEAN NOT NULL DEFAULT FALSE AFTER `password`;
+
+CREATE TABLE IF NOT EXISTS sessions (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id` INT UNSIGNED NOT NULL,
+    `refresh_token_hash` CHAR(64) NOT NULL UNIQUE,
+    `previous_token_hash` CHAR(64) NULL,
+    `user_agent` VARCHAR(255) NOT NULL DEFAULT '',
+    `ip_address` VARCHAR(64) NOT NULL DEFAULT '',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `last_used_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `expires_at` TIMESTAMP NOT NULL,
+    `revoked_at` TIMESTAMP NULL DEFAULT NULL,
+    INDEX (`previous_token_hash`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN `email_verified_at` TIMESTAMP NULL DEFAULT NULL AFTER `is_admin`;
+
+-- Accounts created before verification existed are trusted as they are
+UPDATE users SET `email_verified_at` = `createdAt`;
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    `jti` CHAR(43) NOT NULL PRIMARY KEY,
+    `user_id` INT UNSIGNED NOT NULL,
+    `purpose` ENUM('verify_email', 'reset_password') NOT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    `used_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    `throttle_key` VARCHAR(320) NOT NULL PRIMARY KEY,
+    `failures` INT UNSIGNED NOT NULL DEFAULT 0,
+    `last_failed_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `locked_until` TIMESTAMP NULL DEFAULT NULL
+);
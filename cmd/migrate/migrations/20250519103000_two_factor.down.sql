@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS recovery_codes;
+
+DELETE FROM user_tokens WHERE `purpose` = 'two_factor_login';
+ALTER TABLE user_tokens MODIFY `purpose` ENUM('verify_email', 'reset_password') NOT NULL;
+
+ALTER TABLE projects DROP COLUMN `require_2fa`;
+
+ALTER TABLE users DROP COLUMN `totp_last_step`;
+ALTER TABLE users DROP COLUMN `totp_enabled`;
+ALTER TABLE users DROP COLUMN `totp_secret`;
@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN `totp_secret` VARCHAR(64) NULL DEFAULT NULL AFTER `email_verified_at`;
+ALTER TABLE users ADD COLUMN `totp_enabled` BOOLEAN NOT NULL DEFAULT FALSE AFTER `totp_secret`;
+-- Last accepted TOTP time step, a code is never accepted twice
+ALTER TABLE users ADD COLUMN `totp_last_step` BIGINT NOT NULL DEFAULT 0 AFTER `totp_enabled`;
+
+ALTER TABLE projects ADD COLUMN `require_2fa` BOOLEAN NOT NULL DEFAULT FALSE AFTER `wip_limit`;
+
+ALTER TABLE user_tokens MODIFY `purpose` ENUM('verify_email', 'reset_password', 'two_factor_login') NOT NULL;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id` INT UNSIGNED NOT NULL,
+    `code_hash` CHAR(64) NOT NULL,
+    `used_at` TIMESTAMP NULL DEFAULT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (`user_id`, `code_hash`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS sso_states (
+    `state` CHAR(43) NOT NULL PRIMARY KEY,
+    `code_verifier` CHAR(43) NOT NULL,
+    `nonce` CHAR(43) NOT NULL,
+    `expires_at` TIMESTAMP NOT NULL,
+    INDEX (`expires_at`)
+);
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id` INT UNSIGNED NOT NULL,
+    `issuer` VARCHAR(255) NOT NULL,
+    `subject` VARCHAR(255) NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (`issuer`, `subject`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN `is_active` BOOLEAN NOT NULL DEFAULT TRUE AFTER `is_admin`;
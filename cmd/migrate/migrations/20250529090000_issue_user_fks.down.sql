@@ -0,0 +1,5 @@
+ALTER TABLE issues
+    DROP FOREIGN KEY `fk_issues_reporter`,
+    DROP FOREIGN KEY `fk_issues_assignee`,
+    DROP COLUMN `reporter_id`,
+    DROP COLUMN `assignee_id`;
@@ -0,0 +1,8 @@
+ALTER TABLE issues
+    ADD COLUMN `reporter_id` INT UNSIGNED DEFAULT NULL AFTER `assignee`,
+    ADD COLUMN `assignee_id` INT UNSIGNED DEFAULT NULL AFTER `reporter_id`,
+    ADD CONSTRAINT `fk_issues_reporter` FOREIGN KEY (`reporter_id`) REFERENCES `users`(`id`) ON DELETE SET NULL,
+    ADD CONSTRAINT `fk_issues_assignee` FOREIGN KEY (`assignee_id`) REFERENCES `users`(`id`) ON DELETE SET NULL;
+
+UPDATE issues i JOIN users u ON u.email = i.reporter SET i.reporter_id = u.id;
+UPDATE issues i JOIN users u ON u.email = i.assignee SET i.assignee_id = u.id;
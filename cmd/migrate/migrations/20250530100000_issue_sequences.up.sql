@@ -0,0 +1,7 @@
+ALTER TABLE projects ADD COLUMN `next_issue_number` INT UNSIGNED NOT NULL DEFAULT 1 AFTER `issue_count`;
+
+-- Continue after the highest number handed out so far, keys are never reused
+UPDATE projects p SET p.next_issue_number = 1 + GREATEST(
+    p.issue_count,
+    (SELECT COALESCE(MAX(CAST(SUBSTRING_INDEX(i.`key`, '-', -1) AS UNSIGNED)), 0) FROM issues i WHERE i.`key` LIKE CONCAT(p.project_key, '-%'))
+);
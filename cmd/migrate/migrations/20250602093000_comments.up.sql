@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS comments (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `issue_id` INT UNSIGNED NOT NULL,
+    `parent_id` INT UNSIGNED NULL DEFAULT NULL,
+    `author_id` INT UNSIGNED NULL,
+    `body` TEXT NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `edited_at` TIMESTAMP NULL DEFAULT NULL,
+    `deleted_at` TIMESTAMP NULL DEFAULT NULL,
+    INDEX (`issue_id`, `created_at`),
+    FOREIGN KEY (`issue_id`) REFERENCES `issues`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`parent_id`) REFERENCES `comments`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`author_id`) REFERENCES `users`(`id`) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `comment_id` INT UNSIGNED NOT NULL,
+    `body` TEXT NOT NULL,
+    `edited_by` INT UNSIGNED NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (`comment_id`) REFERENCES `comments`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`edited_by`) REFERENCES `users`(`id`) ON DELETE SET NULL
+);
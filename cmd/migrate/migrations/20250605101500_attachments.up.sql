@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `issue_id` INT UNSIGNED NOT NULL,
+    `filename` VARCHAR(255) NOT NULL,
+    `content_type` VARCHAR(255) NOT NULL,
+    `size` BIGINT UNSIGNED NOT NULL,
+    `sha256` CHAR(64) NOT NULL,
+    `uploaded_by` INT UNSIGNED NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX (`sha256`),
+    FOREIGN KEY (`issue_id`) REFERENCES `issues`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`uploaded_by`) REFERENCES `users`(`id`) ON DELETE SET NULL
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS issue_history (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `issue_id` INT UNSIGNED NOT NULL,
+    `actor_id` INT UNSIGNED NULL,
+    `field` VARCHAR(64) NOT NULL,
+    `old_value` TEXT NULL,
+    `new_value` TEXT NULL,
+    `changed_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX (`issue_id`, `changed_at`),
+    INDEX (`changed_at`),
+    FOREIGN KEY (`issue_id`) REFERENCES `issues`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`actor_id`) REFERENCES `users`(`id`) ON DELETE SET NULL
+);
@@ -0,0 +1,9 @@
+-- Map custom statuses back onto the fixed ones by their category
+UPDATE issues i
+JOIN workflow_statuses s ON s.project_key = i.project_key AND s.name = i.status
+SET i.status = CASE s.category WHEN 'todo' THEN 'open' WHEN 'in_progress' THEN 'in_progress' ELSE 'resolved' END;
+
+ALTER TABLE issues MODIFY `status` ENUM('open', 'in_progress', 'resolved') NOT NULL DEFAULT 'open';
+
+DROP TABLE IF EXISTS workflow_transitions;
+DROP TABLE IF EXISTS workflow_statuses;
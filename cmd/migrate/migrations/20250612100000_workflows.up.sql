@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS workflow_statuses (
+    `project_key` VARCHAR(255) NOT NULL,
+    `name` VARCHAR(64) NOT NULL,
+    `category` ENUM('todo', 'in_progress', 'done') NOT NULL,
+    `position` INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (`project_key`, `name`),
+    FOREIGN KEY (`project_key`) REFERENCES `projects`(`project_key`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workflow_transitions (
+    `project_key` VARCHAR(255) NOT NULL,
+    `from_status` VARCHAR(64) NOT NULL,
+    `to_status` VARCHAR(64) NOT NULL,
+    PRIMARY KEY (`project_key`, `from_status`, `to_status`),
+    FOREIGN KEY (`project_key`, `from_status`) REFERENCES `workflow_statuses`(`project_key`, `name`) ON DELETE CASCADE,
+    FOREIGN KEY (`project_key`, `to_status`) REFERENCES `workflow_statuses`(`project_key`, `name`) ON DELETE CASCADE
+);
+
+-- Existing projects keep the old statuses and can move freely between them
+INSERT INTO workflow_statuses (project_key, name, category, position)
+    SELECT project_key, 'open', 'todo', 0 FROM projects
+    UNION ALL SELECT project_key, 'in_progress', 'in_progress', 1 FROM projects
+    UNION ALL SELECT project_key, 'resolved', 'done', 2 FROM projects;
+
+INSERT INTO workflow_transitions (project_key, from_status, to_status)
+    SELECT a.project_key, a.name, b.name
+    FROM workflow_statuses a
+    JOIN workflow_statuses b ON b.project_key = a.project_key AND b.name <> a.name;
+
+ALTER TABLE issues MODIFY `status` VARCHAR(64) NOT NULL DEFAULT 'open';
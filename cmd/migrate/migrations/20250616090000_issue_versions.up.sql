@@ -0,0 +1 @@
+ALTER TABLE issues ADD COLUMN `version` INT UNSIGNED NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP INDEX idx_issues_project_created ON issues;
+DROP INDEX idx_issues_project_updated ON issues;
+DROP INDEX idx_issues_sprint_created ON issues;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_issues_project_created ON issues (project_key, createdAt, id);
+CREATE INDEX idx_issues_project_updated ON issues (project_key, updatedAt, id);
+CREATE INDEX idx_issues_sprint_created ON issues (sprint_id, createdAt, id);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS search_documents (
+    `issue_id` INT UNSIGNED NOT NULL PRIMARY KEY,
+    `summary` VARCHAR(255) NOT NULL,
+    `description` TEXT NOT NULL,
+    `comments` MEDIUMTEXT NOT NULL,
+    `indexed_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (`issue_id`) REFERENCES `issues`(`id`) ON DELETE CASCADE
+);
+
+SET SESSION group_concat_max_len = 16777215;
+
+INSERT INTO search_documents (issue_id, summary, description, comments)
+SELECT i.id, i.summary, i.description,
+    COALESCE((SELECT GROUP_CONCAT(c.body ORDER BY c.id SEPARATOR '\n') FROM comments c WHERE c.issue_id = i.id AND c.deleted_at IS NULL), '')
+FROM issues i;
+
+CREATE FULLTEXT INDEX ft_search_documents ON search_documents (summary, description, comments);
+CREATE FULLTEXT INDEX ft_search_documents_summary ON search_documents (summary);
+CREATE FULLTEXT INDEX ft_search_documents_description ON search_documents (description);
+CREATE FULLTEXT INDEX ft_search_documents_comments ON search_documents (comments);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS issue_labels;
+DROP TABLE IF EXISTS issue_components;
+DROP TABLE IF EXISTS labels;
+DROP TABLE IF EXISTS components;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS components (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `project_key` VARCHAR(255) NOT NULL,
+    `name` VARCHAR(64) NOT NULL,
+    `description` VARCHAR(255) NOT NULL DEFAULT '',
+    `default_assignee_id` INT UNSIGNED NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (`project_key`, `name`),
+    FOREIGN KEY (`project_key`) REFERENCES `projects`(`project_key`) ON DELETE CASCADE,
+    FOREIGN KEY (`default_assignee_id`) REFERENCES `users`(`id`) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS labels (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `project_key` VARCHAR(255) NOT NULL,
+    `name` VARCHAR(64) NOT NULL,
+    UNIQUE (`project_key`, `name`),
+    FOREIGN KEY (`project_key`) REFERENCES `projects`(`project_key`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS issue_components (
+    `issue_id` INT UNSIGNED NOT NULL,
+    `component_id` INT UNSIGNED NOT NULL,
+    PRIMARY KEY (`issue_id`, `component_id`),
+    INDEX (`component_id`),
+    FOREIGN KEY (`issue_id`) REFERENCES `issues`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`component_id`) REFERENCES `components`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS issue_labels (
+    `issue_id` INT UNSIGNED NOT NULL,
+    `label_id` INT UNSIGNED NOT NULL,
+    PRIMARY KEY (`issue_id`, `label_id`),
+    INDEX (`label_id`),
+    FOREIGN KEY (`issue_id`) REFERENCES `issues`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`label_id`) REFERENCES `labels`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP INDEX idx_issues_project_priority ON issues;
+DROP INDEX idx_issues_project_due ON issues;
+ALTER TABLE issues DROP COLUMN `priority`, DROP COLUMN `severity`, DROP COLUMN `due_date`;
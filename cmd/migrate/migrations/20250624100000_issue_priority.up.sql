@@ -0,0 +1,7 @@
+ALTER TABLE issues
+    ADD COLUMN `priority` CHAR(2) NOT NULL DEFAULT 'P2',
+    ADD COLUMN `severity` VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN `due_date` DATE NULL;
+
+CREATE INDEX idx_issues_project_priority ON issues (project_key, priority, id);
+CREATE INDEX idx_issues_project_due ON issues (project_key, due_date);
@@ -0,0 +1,16 @@
+-- Every link is stored once from each side, the outward row reads "A blocks
+-- B" and the inward row "B is blocked by A"
+CREATE TABLE IF NOT EXISTS issue_links (
+    `id` INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `issue_id` INT UNSIGNED NOT NULL,
+    `linked_issue_id` INT UNSIGNED NOT NULL,
+    `link_type` ENUM('blocks', 'duplicates', 'relates', 'clones') NOT NULL,
+    `direction` ENUM('outward', 'inward') NOT NULL,
+    `created_by` INT UNSIGNED NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (`issue_id`, `linked_issue_id`, `link_type`, `direction`),
+    INDEX (`linked_issue_id`),
+    FOREIGN KEY (`issue_id`) REFERENCES `issues`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`linked_issue_id`) REFERENCES `issues`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`created_by`) REFERENCES `users`(`id`) ON DELETE SET NULL
+);
@@ -0,0 +1,7 @@
+UPDATE issues SET `issueType` = 'task' WHERE `issueType` IN ('epic', 'sub-task');
+
+ALTER TABLE issues
+    DROP FOREIGN KEY `fk_issues_parent`,
+    DROP COLUMN `parent_id`,
+    DROP COLUMN `derive_status`,
+    MODIFY `issueType` ENUM('bug', 'task', 'story') NOT NULL DEFAULT 'task';
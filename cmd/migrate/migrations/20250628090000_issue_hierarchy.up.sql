@@ -0,0 +1,5 @@
+ALTER TABLE issues
+    MODIFY `issueType` ENUM('bug', 'task', 'story', 'epic', 'sub-task') NOT NULL DEFAULT 'task',
+    ADD COLUMN `parent_id` INT UNSIGNED DEFAULT NULL,
+    ADD COLUMN `derive_status` BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT `fk_issues_parent` FOREIGN KEY (`parent_id`) REFERENCES `issues`(`id`) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE issues DROP COLUMN `story_points`, DROP COLUMN `original_estimate`, DROP COLUMN `remaining_estimate`;
+ALTER TABLE projects DROP COLUMN `point_scale`;
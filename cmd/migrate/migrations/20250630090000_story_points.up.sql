@@ -0,0 +1,7 @@
+ALTER TABLE projects
+    ADD COLUMN `point_scale` VARCHAR(255) NOT NULL DEFAULT '0,1,2,3,5,8,13,21';
+
+ALTER TABLE issues
+    ADD COLUMN `story_points` DOUBLE NULL,
+    ADD COLUMN `original_estimate` INT UNSIGNED NULL,
+    ADD COLUMN `remaining_estimate` INT UNSIGNED NULL;
@@ -15,6 +15,63 @@ type Config struct {
 	DBName                 string
 	JWTExpirationInSeconds int64
 	JWTSecret              string
+
+	RefreshTokenExpirationInSeconds int64
+
+	// Failed logins back off exponentially from LoginBackoffBaseSeconds and
+	// lock the account (or IP) for LoginLockoutSeconds after too many failures.
+	LoginMaxAttempts        int64
+	LoginIPMaxAttempts      int64
+	LoginBackoffBaseSeconds int64
+	LoginLockoutSeconds     int64
+
+	// TOTPIssuer is the account issuer shown by authenticator apps
+	TOTPIssuer                            string
+	TwoFactorChallengeExpirationInSeconds int64
+
+	FrontendURL                          string
+	RequireEmailVerification             bool
+	EmailVerificationExpirationInSeconds int64
+	PasswordResetExpirationInSeconds     int64
+
+	// Single sign-on is enabled when OIDCIssuerURL is set. OIDCGroupRoles maps
+	// groups to project roles, e.g. "eng-leads=API:lead,eng=API:member".
+	// Setting DisablePasswordLogin makes single sign-on the only way in.
+	OIDCIssuerURL        string
+	OIDCClientID         string
+	OIDCClientSecret     string
+	OIDCRedirectURL      string
+	OIDCScopes           string
+	OIDCGroupsClaim      string
+	OIDCGroupRoles       string
+	DisablePasswordLogin bool
+
+	// MailDriver selects the mailer, "smtp" or "log". The log mailer writes
+	// emails to MailLogDir, or to the application log when it is empty.
+	MailDriver   string
+	MailFrom     string
+	MailLogDir   string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// BlobDriver selects where attachments are kept, "local" or "s3". The S3
+	// driver works with any S3-compatible service, S3PathStyle addresses the
+	// bucket in the path as MinIO and most stand-ins expect.
+	BlobDriver        string
+	BlobLocalDir      string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+
+	// AttachmentAllowedTypes is a comma separated list of sniffed content
+	// types or prefixes like "image/", empty allows any type.
+	AttachmentMaxBytes     int64
+	AttachmentAllowedTypes string
 }
 
 var Envs = initConfig()
@@ -28,7 +85,51 @@ func initConfig() Config {
 		DBAddress:              fmt.Sprintf("%s:%s", getEnv("DB_HOST", "127.0.0.1"), getEnv("DB_PORT", "3306")),
 		DBName:                 getEnv("DB_NAME", "issue_tracking_system"),
 		JWTSecret:              getEnv("JWT_SECRET", "secret"),
-		JWTExpirationInSeconds: getEnvAsInt("JWT_XP", 3600*25*7),
+		JWTExpirationInSeconds: getEnvAsInt("JWT_XP", 60*15),
+
+		RefreshTokenExpirationInSeconds: getEnvAsInt("REFRESH_XP", 3600*24*7),
+
+		LoginMaxAttempts:        getEnvAsInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginIPMaxAttempts:      getEnvAsInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+		LoginBackoffBaseSeconds: getEnvAsInt("LOGIN_BACKOFF_BASE_SECONDS", 1),
+		LoginLockoutSeconds:     getEnvAsInt("LOGIN_LOCKOUT_SECONDS", 60*15),
+
+		TOTPIssuer:                            getEnv("TOTP_ISSUER", "Issue Tracking System"),
+		TwoFactorChallengeExpirationInSeconds: getEnvAsInt("TWO_FACTOR_CHALLENGE_XP", 60*5),
+
+		FrontendURL:                          getEnv("FRONTEND_URL", "http://localhost:5173"),
+		RequireEmailVerification:             getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
+		EmailVerificationExpirationInSeconds: getEnvAsInt("EMAIL_VERIFICATION_XP", 3600*24*2),
+		PasswordResetExpirationInSeconds:     getEnvAsInt("PASSWORD_RESET_XP", 3600),
+
+		OIDCIssuerURL:        getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:         getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:     getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:      getEnv("OIDC_REDIRECT_URL", getEnv("FRONTEND_URL", "http://localhost:5173")+"/sso/callback"),
+		OIDCScopes:           getEnv("OIDC_SCOPES", "openid email profile groups"),
+		OIDCGroupsClaim:      getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCGroupRoles:       getEnv("OIDC_GROUP_ROLES", ""),
+		DisablePasswordLogin: getEnvAsBool("DISABLE_PASSWORD_LOGIN", false),
+
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+		MailLogDir:   getEnv("MAIL_LOG_DIR", ""),
+		SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		BlobDriver:        getEnv("BLOB_DRIVER", "local"),
+		BlobLocalDir:      getEnv("BLOB_LOCAL_DIR", "data/attachments"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3PathStyle:       getEnvAsBool("S3_PATH_STYLE", true),
+
+		AttachmentMaxBytes:     getEnvAsInt("ATTACHMENT_MAX_BYTES", 10<<20),
+		AttachmentAllowedTypes: getEnv("ATTACHMENT_ALLOWED_TYPES", ""),
 	}
 }
 
@@ -49,3 +150,14 @@ func getEnvAsInt(key string, fallback int64) int64 {
 	}
 	return fallback
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fallback
+		}
+		return b
+	}
+	return fallback
+}
@@ -0,0 +1,63 @@
+const API_URL = "http://localhost:8080/api/v1";
+
+// Tokens from /login, /login/2fa and /refresh, kept in localStorage.
+export const saveTokens = (data) => {
+    localStorage.setItem("token", data.token);
+    localStorage.setItem("refreshToken", data.refreshToken);
+};
+
+export const clearTokens = () => {
+    localStorage.removeItem("token");
+    localStorage.removeItem("refreshToken");
+};
+
+// Parallel requests failing at once share a single refresh, the refresh
+// token is rotated and can only be used once.
+let refreshing = null;
+
+const refreshTokens = () => {
+    if (!refreshing) {
+        refreshing = (async () => {
+            const refreshToken = localStorage.getItem("refreshToken");
+            if (!refreshToken) return false;
+
+            const res = await fetch(`${API_URL}/refresh`, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ refreshToken }),
+            });
+            if (!res.ok) {
+                clearTokens();
+                return false;
+            }
+            saveTokens(await res.json());
+            return true;
+        })().finally(() => {
+            refreshing = null;
+        });
+    }
+    return refreshing;
+};
+
+const withToken = (options) => {
+    const headers = new Headers(options.headers);
+    const token = localStorage.getItem("token");
+    if (token) headers.set("Authorization", `Bearer ${token}`);
+    return { ...options, headers };
+};
+
+// apiFetch is fetch with the access token of the signed in user. An expired
+// token is refreshed once and the request retried, when that fails too the
+// user is sent to sign in again.
+export const apiFetch = async (url, options = {}) => {
+    const res = await fetch(url, withToken(options));
+    if (res.status !== 401 || !localStorage.getItem("refreshToken")) {
+        return res;
+    }
+
+    if (!(await refreshTokens())) {
+        window.location.assign("/login");
+        return res;
+    }
+    return fetch(url, withToken(options));
+};
@@ -1,6 +1,8 @@
+import { apiFetch } from "./client";
+
 export const fetchIssues = async (key) => {
   try {
-    const res = await fetch(`http://localhost:8080/api/v1/issues/${key}`);
+    const res = await apiFetch(`http://localhost:8080/api/v1/issues/${key}`);
     if (!res.ok) throw new Error("Issues not found");
     const data = await res.json();
     return data.issues;
@@ -11,7 +13,7 @@ export const fetchIssues = async (key) => {
 
 export const fetchIssue = async (id) => {
   try {
-    const res = await fetch(`http://localhost:8080/api/v1/issue/${id}`)
+    const res = await apiFetch(`http://localhost:8080/api/v1/issue/${id}`)
     const data = await res.json();
     return data.issue
   } catch (err) {
@@ -22,7 +24,7 @@ export const fetchIssue = async (id) => {
 // ../api/issues.js
 export const fetchIssuesByProject = async (projectKey) => {
   try {
-    const res = await fetch(`http://localhost:8080/api/v1/issues/${projectKey}`)
+    const res = await apiFetch(`http://localhost:8080/api/v1/issues/${projectKey}`)
     const data = await res.json()
     return data
   } catch (error) {
@@ -1,6 +1,8 @@
+import { apiFetch } from "./client";
+
 export const fetchCycleTimeByProject = async (projectKey) => {
     try {
-        const res = await fetch(`http://localhost:8080/api/v1/cycle-time/${projectKey}`)
+        const res = await apiFetch(`http://localhost:8080/api/v1/cycle-time/${projectKey}`)
         const data = await res.json()
         return data
     } catch (error) {
@@ -10,7 +12,7 @@ export const fetchCycleTimeByProject = async (projectKey) => {
 
 export const fetchThroughputByProject = async (projectKey) =>{
     try{
-        const res = await fetch (`http://localhost:8080/api/v1/throughput/${projectKey}`)
+        const res = await apiFetch(`http://localhost:8080/api/v1/throughput/${projectKey}`)
         const data = res.json()
         return data
     } catch (error){
@@ -1,6 +1,8 @@
+import { apiFetch } from "./client";
+
 export const fetchProjectDetails = async (key) => {
   try {
-    const res = await fetch(`http://localhost:8080/api/v1/projects/${key}`);
+    const res = await apiFetch(`http://localhost:8080/api/v1/projects/${key}`);
     if (!res.ok) throw new Error("Project not found");
     return res.json();
   } catch (err) {
@@ -10,7 +12,7 @@ export const fetchProjectDetails = async (key) => {
 
 export const fetchAllProjects = async () => {
   try {
-    const res = await fetch(`http://localhost:8080/api/v1/projects`)
+    const res = await apiFetch(`http://localhost:8080/api/v1/projects`)
     if (!res.ok) throw new Error("Project not found");
     return res.json()
   } catch (err) {
@@ -1,6 +1,8 @@
+import { apiFetch } from "./client";
+
 export const fetchScopeDetails = async (scopeId) => {
   try {
-    const response = await fetch(`http://localhost:8080/api/v1/scopes/details/${scopeId}`);
+    const response = await apiFetch(`http://localhost:8080/api/v1/scopes/details/${scopeId}`);
     if (!response.ok) {
       throw new Error("Failed to fetch scope details");
     }
@@ -12,7 +14,7 @@ export const fetchScopeDetails = async (scopeId) => {
 
 export const fetchScopeIssues = async (scopeId) => {
   try {
-    const response = await fetch(`http://localhost:8080/api/v1/scopes/issues/${scopeId}`);
+    const response = await apiFetch(`http://localhost:8080/api/v1/scopes/issues/${scopeId}`);
     if (!response.ok) {
       throw new Error("Failed to fetch scope issues");
     }
@@ -25,7 +27,7 @@ export const fetchScopeIssues = async (scopeId) => {
 
 export const fetchAllScopeDetails = async () => {
   try {
-    const response = await fetch(`http://localhost:8080/api/v1/scopes`)
+    const response = await apiFetch(`http://localhost:8080/api/v1/scopes`)
     if (!response.ok) {
       throw new Error("Failed to fetch all scope details")
     }
@@ -38,7 +40,7 @@ export const fetchAllScopeDetails = async () => {
 
 export const createScope = async (name, description, projects) => {
   try {
-    const response = await fetch("http://localhost:8080/api/v1/scopes", {
+    const response = await apiFetch("http://localhost:8080/api/v1/scopes", {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
@@ -61,7 +63,7 @@ export const createScope = async (name, description, projects) => {
 // Function to fetch all scopes
 export const fetchAllScopes = async () => {
   try {
-    const response = await fetch("http://localhost:8080/api/v1/scopes");
+    const response = await apiFetch("http://localhost:8080/api/v1/scopes");
     if (!response.ok) {
       throw new Error("Failed to fetch scopes");
     }
@@ -76,7 +78,7 @@ export const fetchAllScopes = async () => {
 // Function to remove projects from a scope
 export const removeProjectsFromScope = async (scopeId, projectKeys) => {
   try {
-    const response = await fetch(`http://localhost:8080/api/v1/scopes/${scopeId}`, {
+    const response = await apiFetch(`http://localhost:8080/api/v1/scopes/${scopeId}`, {
       method: "DELETE",
       headers: {
         "Content-Type": "application/json",
@@ -1,5 +1,7 @@
+import { apiFetch } from "./client";
+
 export const startStandup = async (projectKey) => {
-  const response = await fetch('http://localhost:8080/api/v1/standups/start', {
+  const response = await apiFetch('http://localhost:8080/api/v1/standups/start', {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
@@ -17,7 +19,7 @@ export const startStandup = async (projectKey) => {
 };
 
 export const endStandup = async (projectKey) => {
-  const response = await fetch('http://localhost:8080/api/v1/standups/end', {
+  const response = await apiFetch('http://localhost:8080/api/v1/standups/end', {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
@@ -1,6 +1,8 @@
+import { apiFetch } from "./client";
+
 export const fetchAllUsers = async () => {
     try {
-        const res = await fetch(`http://localhost:8080/api/v1/users`);
+        const res = await apiFetch(`http://localhost:8080/api/v1/users`);
         if (!res.ok) throw new Error("Cant retrievee users");
         return res.json();
     } catch (err) {
@@ -9,7 +11,7 @@ export const fetchAllUsers = async () => {
 };
 export const assignUserToProject = async ({ projectId, userId, role = "member" }) => {
     try {
-        const response = await fetch(
+        const response = await apiFetch(
             `http://localhost:8080/api/v1/projects-assignment/${projectId}/assign/${userId}?role=${role}`,
             {
                 method: "POST",
@@ -34,7 +36,7 @@ export const assignUserToProject = async ({ projectId, userId, role = "member" }
 
 export const removeUserFromProject = async ({ userId, projectId }) => {
     try {
-        const response = await fetch(`http://localhost:8080/api/v1/projects-assignment/${projectId}/remove/${userId}`, {
+        const response = await apiFetch(`http://localhost:8080/api/v1/projects-assignment/${projectId}/remove/${userId}`, {
             method: "DELETE",
         });
 
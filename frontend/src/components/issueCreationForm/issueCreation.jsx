@@ -1,8 +1,9 @@
 import { useState } from "react";
+import { apiFetch } from "../../api/client";
 
 const createIssue = async (issue) => {
   try {
-    const response = await fetch("http://localhost:8080/api/v1/createIssue", {
+    const response = await apiFetch("http://localhost:8080/api/v1/createIssue", {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
@@ -1,6 +1,7 @@
 /* eslint-disable no-useless-catch */
 import { useState } from "react";
 import { useNavigate } from "react-router-dom";
+import { saveTokens } from "../../api/client";
 
 const loginUser = async (email, password) => {
   try {
@@ -35,7 +36,10 @@ const SignIn = () => {
 
     try {
       const data = await loginUser(email, password);
-      localStorage.setItem("token", data.token);
+      if (data.twoFactorRequired) {
+        throw new Error("Two-factor sign in is not supported here yet");
+      }
+      saveTokens(data);
       console.log("Login successful, token stored");
       navigate("/dashboard");
     } catch (error) {
@@ -1,8 +1,9 @@
 import { useState } from "react";
+import { apiFetch } from "../../api/client";
 
 const createProject = async (project) => {
   try {
-    const response = await fetch("http://localhost:8080/api/v1/projects", {
+    const response = await apiFetch("http://localhost:8080/api/v1/projects", {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
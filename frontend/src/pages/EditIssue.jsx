@@ -1,6 +1,7 @@
 import React, { useEffect, useState } from "react";
 import { useParams, useNavigate } from "react-router-dom";
 import { fetchIssue } from "../api/issue";
+import { apiFetch } from "../api/client";
 
 const EditIssue = () => {
   const { id } = useParams();
@@ -54,14 +55,23 @@ const EditIssue = () => {
 
     try {
       setSaving(true);
-      const res = await fetch(`http://localhost:8080/api/v1/issues/${id}`, {
+      const res = await apiFetch(`http://localhost:8080/api/v1/issues/${id}`, {
         method: "PUT",
         headers: {
           "Content-Type": "application/json",
+          "If-Match": `"${issue.version}"`,
         },
         body: JSON.stringify(updatedIssue),
       });
 
+      // Someone else saved first, show their version instead of overwriting it
+      if (res.status === 412) {
+        const conflict = await res.json();
+        setIssue(conflict.issue);
+        window.alert("This issue was changed by someone else. Review the latest version and save again.");
+        return;
+      }
+
       if (!res.ok) {
         const errorData = await res.json();
         throw new Error(errorData.message || "Failed to update issue");
@@ -1,5 +1,6 @@
 import React, { useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom"; // Import this
+import { apiFetch } from "../api/client";
 const Projects = () => {
   const [projects, setProjects] = useState([]);
   const [search, setSearch] = useState("");
@@ -8,7 +9,7 @@ const Projects = () => {
 
   const navigate = useNavigate()
   useEffect(() => {
-    fetch("http://localhost:8080/api/v1/projects")
+    apiFetch("http://localhost:8080/api/v1/projects")
       .then((res) => {
         if (!res.ok) {
           throw new Error("Failed to fetch projects");
@@ -0,0 +1,355 @@
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/service/blob"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+// multipartOverhead is allowed on top of the file size for the multipart
+// framing and other form fields.
+const multipartOverhead = 64 << 10
+
+// inlineTypes are shown in the browser, anything else is downloaded so
+// uploaded HTML or scripts never run on our origin.
+var inlineTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type Handler struct {
+	store  types.AttachmentStore
+	blobs  types.BlobStore
+	issues types.IssueStore
+	roles  types.RoleStore
+}
+
+func NewHandler(store types.AttachmentStore, blobs types.BlobStore, issues types.IssueStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, blobs: blobs, issues: issues, roles: roles}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/issues/{id}/attachments", h.handleGetAttachments).Methods("GET")
+	router.HandleFunc("/issues/{id}/attachments", h.handleUpload).Methods("POST")
+	router.HandleFunc("/issues/{id}/attachments/{attachmentID}", h.handleDownload).Methods("GET")
+	router.HandleFunc("/issues/{id}/attachments/{attachmentID}", h.handleDelete).Methods("DELETE")
+}
+
+func (h *Handler) handleGetAttachments(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.loadIssue(w, r, auth.PermViewIssues)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.store.GetAttachmentsByIssue(issue.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if attachments == nil {
+		attachments = []types.Attachment{}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"issue_id":    issue.ID,
+		"attachments": attachments,
+	})
+}
+
+// handleUpload takes the multipart field "file". The content type is sniffed
+// rather than trusted from the client, and content already in the blob store
+// is not uploaded again.
+func (h *Handler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.loadIssue(w, r, auth.PermEditIssue)
+	if !ok {
+		return
+	}
+
+	maxBytes := config.Envs.AttachmentMaxBytes
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("expected a multipart/form-data upload"))
+		return
+	}
+
+	var part io.ReadCloser
+	var filename string
+	for {
+		p, err := reader.NextPart()
+		if err != nil {
+			if tooLarge(err) {
+				utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("attachments are limited to %d bytes", maxBytes))
+				return
+			}
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing file field"))
+			return
+		}
+		if p.FormName() == "file" {
+			part, filename = p, p.FileName()
+			break
+		}
+		p.Close()
+	}
+	defer part.Close()
+
+	// Spool to disk to learn the size and hash before storing the blob
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(part, maxBytes+1))
+	if err != nil {
+		if tooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("attachments are limited to %d bytes", maxBytes))
+			return
+		}
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to read upload: %v", err))
+		return
+	}
+	if size > maxBytes {
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("attachments are limited to %d bytes", maxBytes))
+		return
+	}
+	if size == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("file is empty"))
+		return
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	contentType := http.DetectContentType(head[:n])
+	if !allowedType(contentType) {
+		utils.WriteError(w, http.StatusUnsupportedMediaType, fmt.Errorf("files of type %s are not allowed", contentType))
+		return
+	}
+
+	attachment := types.Attachment{
+		IssueID:     issue.ID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		UploadedBy:  auth.GetUserIDFromContext(r.Context()),
+	}
+
+	// The row goes in first, so a concurrent delete of another attachment
+	// with the same content sees it and keeps the blob
+	id, err := h.store.CreateAttachment(attachment)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	attachment.ID = id
+
+	if err := h.storeBlob(r, tmp, attachment); err != nil {
+		if err := h.store.DeleteAttachment(id); err != nil {
+			log.Printf("failed to remove attachment %d after a failed upload: %v", id, err)
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	created, err := h.store.GetAttachmentByID(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, created)
+}
+
+// storeBlob uploads the content unless the blob store already has it. The
+// other attachments sharing it don't tell, their upload may still be running
+// or have failed. Keys are content addressed, so uploading twice is harmless.
+func (h *Handler) storeBlob(r *http.Request, content io.ReadSeeker, attachment types.Attachment) error {
+	exists, err := h.blobs.Exists(r.Context(), blobKey(attachment.SHA256))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return h.blobs.Put(r.Context(), blobKey(attachment.SHA256), content, attachment.Size, attachment.ContentType)
+}
+
+func (h *Handler) handleDownload(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.loadIssue(w, r, auth.PermViewIssues)
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.loadAttachment(w, r, issue)
+	if !ok {
+		return
+	}
+
+	content, err := h.blobs.Get(r.Context(), blobKey(attachment.SHA256))
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("attachment content is missing"))
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if inlineTypes[attachment.ContentType] {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("failed to send attachment %d: %v", attachment.ID, err)
+	}
+}
+
+// handleDelete removes the attachment, and the blob once no other
+// attachment shares its content.
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.loadIssue(w, r, auth.PermEditIssue)
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.loadAttachment(w, r, issue)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteAttachment(attachment.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	refs, err := h.store.CountBlobReferences(attachment.SHA256)
+	if err != nil {
+		log.Printf("failed to count references to blob %s: %v", attachment.SHA256, err)
+	} else if refs == 0 {
+		if err := h.blobs.Delete(r.Context(), blobKey(attachment.SHA256)); err != nil {
+			log.Printf("failed to delete blob %s: %v", attachment.SHA256, err)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// loadIssue fetches the issue from the URL and checks perm on its project.
+func (h *Handler) loadIssue(w http.ResponseWriter, r *http.Request, perm auth.Permission) (*types.Issue, bool) {
+	issueID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid issue ID"))
+		return nil, false
+	}
+
+	issue, err := h.issues.GetIssueByID(issueID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("issue not found"))
+		return nil, false
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, issue.ProjectKey, perm) {
+		return nil, false
+	}
+
+	return issue, true
+}
+
+func (h *Handler) loadAttachment(w http.ResponseWriter, r *http.Request, issue *types.Issue) (*types.Attachment, bool) {
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["attachmentID"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid attachment ID"))
+		return nil, false
+	}
+
+	attachment, err := h.store.GetAttachmentByID(attachmentID)
+	if err != nil || attachment.IssueID != issue.ID {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("attachment not found"))
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+// blobKey spreads blobs over directories by the first byte of their hash.
+func blobKey(sha string) string {
+	return "sha256/" + sha[:2] + "/" + sha
+}
+
+// allowedType checks the sniffed type against config.Envs.AttachmentAllowedTypes.
+func allowedType(contentType string) bool {
+	allowed := strings.TrimSpace(config.Envs.AttachmentAllowedTypes)
+	if allowed == "" {
+		return true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for _, t := range strings.Split(allowed, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanFilename(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
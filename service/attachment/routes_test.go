@@ -0,0 +1,363 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/service/blob"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestAttachmentServiceHandlers(t *testing.T) {
+	defer func(max int64, allowed string) {
+		config.Envs.AttachmentMaxBytes, config.Envs.AttachmentAllowedTypes = max, allowed
+	}(config.Envs.AttachmentMaxBytes, config.Envs.AttachmentAllowedTypes)
+	config.Envs.AttachmentMaxBytes = 1024
+	config.Envs.AttachmentAllowedTypes = ""
+
+	store := newMockAttachmentStore()
+	blobs := &countingBlobStore{BlobStore: blob.NewLocalStore(t.TempDir())}
+	issues := &mockIssueStore{issues: map[int]types.Issue{
+		1: {ID: 1, ProjectKey: "PRJ"},
+		2: {ID: 2, ProjectKey: "PRJ"},
+		3: {ID: 3, ProjectKey: "OTHER"},
+	}}
+	roles := &mockRoleStore{roles: map[int]map[string]string{
+		1: {"PRJ": "member"},
+		2: {"PRJ": "viewer"},
+	}}
+	handler := NewHandler(store, blobs, issues, roles)
+
+	t.Run("Upload", func(t *testing.T) {
+		t.Run("should store the file with its sniffed content type", func(t *testing.T) {
+			rr := upload(t, handler, 1, "/issues/1/attachments", "../shot.png", pngHeader, http.StatusCreated)
+
+			var a types.Attachment
+			if err := json.NewDecoder(rr.Body).Decode(&a); err != nil {
+				t.Fatal(err)
+			}
+			if a.ContentType != "image/png" || a.Filename != "shot.png" || a.Size != int64(len(pngHeader)) || a.UploadedBy != 1 {
+				t.Errorf("unexpected attachment %+v", a)
+			}
+			if blobs.puts != 1 {
+				t.Errorf("expected one blob upload, got %d", blobs.puts)
+			}
+		})
+
+		t.Run("should not upload identical content twice", func(t *testing.T) {
+			upload(t, handler, 1, "/issues/2/attachments", "copy.png", pngHeader, http.StatusCreated)
+
+			if blobs.puts != 1 {
+				t.Errorf("expected the blob to be shared, got %d uploads", blobs.puts)
+			}
+		})
+
+		t.Run("should ignore the client content type", func(t *testing.T) {
+			rr := upload(t, handler, 1, "/issues/1/attachments", "fake.png", []byte("<html><script>alert(1)</script></html>"), http.StatusCreated)
+
+			var a types.Attachment
+			json.NewDecoder(rr.Body).Decode(&a)
+			if a.ContentType != "text/html; charset=utf-8" {
+				t.Errorf("expected the content type to be sniffed, got %q", a.ContentType)
+			}
+		})
+
+		t.Run("should reject files over the size limit", func(t *testing.T) {
+			upload(t, handler, 1, "/issues/1/attachments", "big.log", bytes.Repeat([]byte("a"), 2048), http.StatusRequestEntityTooLarge)
+		})
+
+		t.Run("should reject types that are not allowed", func(t *testing.T) {
+			config.Envs.AttachmentAllowedTypes = "image/, application/pdf"
+			defer func() { config.Envs.AttachmentAllowedTypes = "" }()
+
+			upload(t, handler, 1, "/issues/1/attachments", "notes.txt", []byte("plain text"), http.StatusUnsupportedMediaType)
+			upload(t, handler, 1, "/issues/1/attachments", "other.png", append(pngHeader, 1), http.StatusCreated)
+		})
+
+		t.Run("should be forbidden for viewers", func(t *testing.T) {
+			upload(t, handler, 2, "/issues/1/attachments", "viewer.png", pngHeader, http.StatusForbidden)
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			upload(t, handler, 1, "/issues/3/attachments", "outsider.png", pngHeader, http.StatusForbidden)
+		})
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		t.Run("should serve the content with its type", func(t *testing.T) {
+			rr := testRequest(t, handler, 2, http.MethodGet, "/issues/1/attachments/1", http.StatusOK)
+
+			if rr.Header().Get("Content-Type") != "image/png" {
+				t.Errorf("expected image/png, got %q", rr.Header().Get("Content-Type"))
+			}
+			if rr.Header().Get("Content-Disposition") != `inline; filename=shot.png` {
+				t.Errorf("unexpected disposition %q", rr.Header().Get("Content-Disposition"))
+			}
+			if !bytes.Equal(rr.Body.Bytes(), pngHeader) {
+				t.Errorf("unexpected content %q", rr.Body.Bytes())
+			}
+		})
+
+		t.Run("should download html instead of rendering it", func(t *testing.T) {
+			rr := testRequest(t, handler, 1, http.MethodGet, "/issues/1/attachments/3", http.StatusOK)
+
+			if rr.Header().Get("Content-Disposition") != `attachment; filename=fake.png` || rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+				t.Errorf("unexpected headers %v", rr.Header())
+			}
+		})
+
+		t.Run("should not find attachments through another issue", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodGet, "/issues/2/attachments/1", http.StatusNotFound)
+		})
+	})
+
+	t.Run("List", func(t *testing.T) {
+		rr := testRequest(t, handler, 2, http.MethodGet, "/issues/1/attachments", http.StatusOK)
+
+		var resp struct {
+			Attachments []types.Attachment `json:"attachments"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Attachments) != 3 {
+			t.Errorf("expected 3 attachments, got %d", len(resp.Attachments))
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		t.Run("should be forbidden for viewers", func(t *testing.T) {
+			testRequest(t, handler, 2, http.MethodDelete, "/issues/1/attachments/1", http.StatusForbidden)
+		})
+
+		t.Run("should keep a blob still in use", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodDelete, "/issues/1/attachments/1", http.StatusOK)
+
+			if blobs.deletes != 0 {
+				t.Errorf("expected the shared blob to be kept, got %d deletes", blobs.deletes)
+			}
+			testRequest(t, handler, 1, http.MethodGet, "/issues/2/attachments/2", http.StatusOK)
+		})
+
+		t.Run("should delete the blob with its last attachment", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodDelete, "/issues/2/attachments/2", http.StatusOK)
+
+			if blobs.deletes != 1 {
+				t.Errorf("expected the blob to be deleted, got %d deletes", blobs.deletes)
+			}
+		})
+	})
+
+	t.Run("should upload content again when the blob of another attachment is missing", func(t *testing.T) {
+		var a types.Attachment
+		rr := upload(t, handler, 1, "/issues/1/attachments", "first.png", pngHeader, http.StatusCreated)
+		if err := json.NewDecoder(rr.Body).Decode(&a); err != nil {
+			t.Fatal(err)
+		}
+		// As if the upload of the first attachment had failed
+		blobs.BlobStore.Delete(context.Background(), blobKey(a.SHA256))
+
+		puts := blobs.puts
+		upload(t, handler, 1, "/issues/2/attachments", "again.png", pngHeader, http.StatusCreated)
+		if blobs.puts != puts+1 {
+			t.Errorf("expected the missing blob to be uploaded, got %d uploads", blobs.puts-puts)
+		}
+		if ok, _ := blobs.Exists(context.Background(), blobKey(a.SHA256)); !ok {
+			t.Errorf("expected the blob to be stored")
+		}
+	})
+}
+
+// upload - Helper function to post a file as multipart form data
+func upload(t testing.TB, handler *Handler, userID int, path, filename string, content []byte, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(content)
+	mw.Close()
+
+	req, err := http.NewRequest(http.MethodPost, path, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return serve(t, handler, userID, req, expectedStatus)
+}
+
+// testRequest - Helper function to perform HTTP requests as a user and check the response
+func testRequest(t testing.TB, handler *Handler, userID int, method, path string, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serve(t, handler, userID, req, expectedStatus)
+}
+
+func serve(t testing.TB, handler *Handler, userID int, req *http.Request, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, userID))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+		fmt.Printf("Response Body: %s\n", rr.Body.String())
+	}
+
+	return rr
+}
+
+// countingBlobStore - Counts the uploads and deletes reaching the blob store
+type countingBlobStore struct {
+	types.BlobStore
+	puts    int
+	deletes int
+}
+
+func (c *countingBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	c.puts++
+	return c.BlobStore.Put(ctx, key, r, size, contentType)
+}
+
+func (c *countingBlobStore) Delete(ctx context.Context, key string) error {
+	c.deletes++
+	return c.BlobStore.Delete(ctx, key)
+}
+
+// mockAttachmentStore - Mock implementation of the attachment store
+type mockAttachmentStore struct {
+	attachments map[int]types.Attachment
+	nextID      int
+}
+
+func newMockAttachmentStore() *mockAttachmentStore {
+	return &mockAttachmentStore{attachments: make(map[int]types.Attachment)}
+}
+
+func (m *mockAttachmentStore) CreateAttachment(a types.Attachment) (int, error) {
+	m.nextID++
+	a.ID = m.nextID
+	a.CreatedAt = time.Now()
+	m.attachments[a.ID] = a
+	return a.ID, nil
+}
+
+func (m *mockAttachmentStore) GetAttachmentByID(id int) (*types.Attachment, error) {
+	a, ok := m.attachments[id]
+	if !ok {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	return &a, nil
+}
+
+func (m *mockAttachmentStore) GetAttachmentsByIssue(issueID int) ([]types.Attachment, error) {
+	var result []types.Attachment
+	for id := 1; id <= m.nextID; id++ {
+		if a, ok := m.attachments[id]; ok && a.IssueID == issueID {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockAttachmentStore) DeleteAttachment(id int) error {
+	delete(m.attachments, id)
+	return nil
+}
+
+func (m *mockAttachmentStore) CountBlobReferences(sha256 string) (int, error) {
+	count := 0
+	for _, a := range m.attachments {
+		if a.SHA256 == sha256 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// mockIssueStore - Mock implementation of the issue store
+type mockIssueStore struct {
+	issues map[int]types.Issue
+}
+
+func (m *mockIssueStore) CreateIssue(issue types.Issue, actorID int) (int, error) {
+	return 0, nil
+}
+
+func (m *mockIssueStore) UpdateIssue(issue types.Issue, actorID int) error {
+	return nil
+}
+
+func (m *mockIssueStore) GetIssueHistory(issueID int) ([]types.IssueChange, error) {
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetIssueByID(id int) (*types.Issue, error) {
+	issue, ok := m.issues[id]
+	if !ok {
+		return nil, fmt.Errorf("issue not found")
+	}
+	return &issue, nil
+}
+
+func (m *mockIssueStore) GetIssuesByProject(projectKey string, filter types.IssueFilter) (*types.IssuePage, error) {
+	return &types.IssuePage{}, nil
+}
+
+func (m *mockIssueStore) GetAverageCycleTime(projectKey string, filter types.IssueFilter) (time.Duration, error) {
+	return 0, nil
+}
+
+func (m *mockIssueStore) GetWeeklyThroughput(projectKey string, filter types.IssueFilter) (map[string]int, error) {
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetBoard(projectKey string, agingAfter time.Duration) (*types.Board, error) {
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetChildren(issueID int) ([]types.Issue, error) {
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetProgress(issueID int) (*types.IssueProgress, error) {
+	return nil, nil
+}
+
+// mockRoleStore - Mock implementation of the role store, roles per user and project
+type mockRoleStore struct {
+	roles map[int]map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[userID][projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,91 @@
+package attachment
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const attachmentColumns = "id, issue_id, filename, content_type, size, sha256, COALESCE(uploaded_by, 0), created_at"
+
+func scanAttachment(row interface{ Scan(dest ...any) error }) (*types.Attachment, error) {
+	a := &types.Attachment{}
+	err := row.Scan(&a.ID, &a.IssueID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.UploadedBy, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *Store) CreateAttachment(a types.Attachment) (int, error) {
+	res, err := s.db.Exec("INSERT INTO attachments (issue_id, filename, content_type, size, sha256, uploaded_by) VALUES (?, ?, ?, ?, ?, ?)",
+		a.IssueID, a.Filename, a.ContentType, a.Size, a.SHA256, a.UploadedBy)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert attachment: %v", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve attachment id: %v", err)
+	}
+	return int(id), nil
+}
+
+func (s *Store) GetAttachmentByID(id int) (*types.Attachment, error) {
+	a, err := scanAttachment(s.db.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE id = ?", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *Store) GetAttachmentsByIssue(issueID int) ([]types.Attachment, error) {
+	rows, err := s.db.Query("SELECT "+attachmentColumns+" FROM attachments WHERE issue_id = ? ORDER BY created_at, id", issueID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %v", err)
+	}
+	defer rows.Close()
+
+	var attachments []types.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment row: %v", err)
+		}
+		attachments = append(attachments, *a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return attachments, nil
+}
+
+func (s *Store) DeleteAttachment(id int) error {
+	if _, err := s.db.Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete attachment: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) CountBlobReferences(sha256 string) (int, error) {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM attachments WHERE sha256 = ?", sha256).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count attachments: %v", err)
+	}
+	return count, nil
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
@@ -8,11 +9,13 @@ import (
 	"github.com/maximis3d/issue-tracking-system/config"
 )
 
-func CreateJWT(secret []byte, userID int) (string, error) {
+// CreateJWT issues a short lived access token bound to a server side session.
+func CreateJWT(secret []byte, userID int, sessionID int) (string, error) {
 	expiration := time.Second * time.Duration(config.Envs.JWTExpirationInSeconds)
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"userID":    strconv.Itoa(userID),
+		"sessionID": strconv.Itoa(sessionID),
 		"expiredAt": time.Now().Add(expiration).Unix(),
 	})
 
@@ -24,3 +27,56 @@ func CreateJWT(secret []byte, userID int) (string, error) {
 
 	return tokenString, nil
 }
+
+// validateJWT checks the signature and the expiredAt claim and returns the
+// user and session IDs the token was issued for.
+func validateJWT(tokenString string, secret []byte) (int, int, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, 0, fmt.Errorf("invalid token")
+	}
+
+	expiredAt, ok := claims["expiredAt"].(float64)
+	if !ok {
+		return 0, 0, fmt.Errorf("missing expiredAt claim")
+	}
+	if time.Now().Unix() > int64(expiredAt) {
+		return 0, 0, fmt.Errorf("token expired")
+	}
+
+	userID, err := intClaim(claims, "userID")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sessionID, err := intClaim(claims, "sessionID")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return userID, sessionID, nil
+}
+
+func intClaim(claims jwt.MapClaims, name string) (int, error) {
+	str, ok := claims[name].(string)
+	if !ok {
+		return 0, fmt.Errorf("missing %s claim", name)
+	}
+
+	value, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s claim: %v", name, err)
+	}
+
+	return value, nil
+}
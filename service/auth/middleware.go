@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type contextKey string
+
+const (
+	UserKey    contextKey = "userID"
+	SessionKey contextKey = "sessionID"
+	ScopesKey  contextKey = "scopes"
+)
+
+// WithAuth returns a middleware that only lets requests through when they
+// carry a valid bearer token for an existing, active user. The token is
+// either a JWT whose session has not been revoked, or a personal access token
+// whose scopes cover the route. The user ID is stored in the request context
+// and can be read back with GetUserIDFromContext.
+func WithAuth(store types.UserStore, sessions types.SessionStore, tokens types.AccessTokenStore) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := getTokenFromRequest(r)
+			if tokenString == "" {
+				unauthorized(w, fmt.Errorf("missing authorization token"))
+				return
+			}
+
+			ctx := r.Context()
+			var userID int
+
+			if IsPersonalAccessToken(tokenString) {
+				token, err := validateAccessToken(tokens, tokenString)
+				if err != nil {
+					log.Printf("failed to validate access token: %v", err)
+					unauthorized(w, fmt.Errorf("invalid or expired token"))
+					return
+				}
+
+				scope := requiredScope(r)
+				if !HasScope(token.Scopes, scope) {
+					utils.WriteError(w, http.StatusForbidden, scopeError(scope))
+					return
+				}
+
+				userID = token.UserID
+				ctx = context.WithValue(ctx, ScopesKey, token.Scopes)
+			} else {
+				var sessionID int
+				var err error
+
+				userID, sessionID, err = validateJWT(tokenString, []byte(config.Envs.JWTSecret))
+				if err != nil {
+					log.Printf("failed to validate token: %v", err)
+					unauthorized(w, fmt.Errorf("invalid or expired token"))
+					return
+				}
+
+				active, err := sessions.IsSessionActive(sessionID)
+				if err != nil || !active {
+					log.Printf("session %d is not active: %v", sessionID, err)
+					unauthorized(w, fmt.Errorf("session has been revoked or expired"))
+					return
+				}
+
+				ctx = context.WithValue(ctx, SessionKey, sessionID)
+			}
+
+			u, err := store.GetUserByID(userID)
+			if err != nil {
+				log.Printf("failed to get user by id: %v", err)
+				unauthorized(w, fmt.Errorf("invalid or expired token"))
+				return
+			}
+			if !u.IsActive {
+				unauthorized(w, fmt.Errorf("account is deactivated"))
+				return
+			}
+
+			ctx = context.WithValue(ctx, UserKey, u.ID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetUserIDFromContext returns the authenticated user's ID, or -1 when the
+// request did not pass through WithAuth.
+func GetUserIDFromContext(ctx context.Context) int {
+	userID, ok := ctx.Value(UserKey).(int)
+	if !ok {
+		return -1
+	}
+
+	return userID
+}
+
+// GetSessionIDFromContext returns the session the request was authenticated
+// with, or -1 when there is none, e.g. for personal access tokens.
+func GetSessionIDFromContext(ctx context.Context) int {
+	sessionID, ok := ctx.Value(SessionKey).(int)
+	if !ok {
+		return -1
+	}
+
+	return sessionID
+}
+
+func getTokenFromRequest(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return ""
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+func unauthorized(w http.ResponseWriter, err error) {
+	utils.WriteError(w, http.StatusUnauthorized, err)
+}
@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestWithAuth(t *testing.T) {
+	store := &mockUserStore{users: map[int]types.User{
+		1: {ID: 1, Email: "user@example.com", IsActive: true},
+		2: {ID: 2, Email: "deactivated@example.com"},
+	}}
+	sessions := newMockSessionStore()
+	tokens := newMockAccessTokenStore()
+	sessionID, _ := sessions.CreateSession(types.Session{UserID: 1, TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)})
+	secret := []byte(config.Envs.JWTSecret)
+
+	var gotUserID, gotSessionID int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = GetUserIDFromContext(r.Context())
+		gotSessionID = GetSessionIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := mux.NewRouter()
+	handler.Handle("/issues/{key}", next).Methods("GET", "POST")
+	handler.Handle("/tokens", next).Methods("GET")
+	handler.Handle("/projects/{key}/require-2fa", next).Methods("PUT")
+	handler.Handle("/projects/{key}/workflow", next).Methods("GET", "PUT")
+	handler.Handle("/projects-assignment/{projectID}/assign/{userID}", next).Methods("POST")
+	handler.Use(WithAuth(store, sessions, tokens))
+
+	t.Run("should reject requests without a token", func(t *testing.T) {
+		rr := serve(handler, http.MethodGet, "")
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("should reject tokens signed with another secret", func(t *testing.T) {
+		token, _ := CreateJWT([]byte("other-secret"), 1, sessionID)
+		rr := serve(handler, http.MethodGet, "Bearer "+token)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("should reject expired tokens", func(t *testing.T) {
+		token := signClaims(t, secret, jwt.MapClaims{
+			"userID":    "1",
+			"sessionID": "1",
+			"expiredAt": time.Now().Add(-time.Minute).Unix(),
+		})
+		rr := serve(handler, http.MethodGet, "Bearer "+token)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("should reject tokens for unknown users", func(t *testing.T) {
+		token, _ := CreateJWT(secret, 42, sessionID)
+		rr := serve(handler, http.MethodGet, "Bearer "+token)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("should reject deactivated users", func(t *testing.T) {
+		deactivatedSession, _ := sessions.CreateSession(types.Session{UserID: 2, TokenHash: "other", ExpiresAt: time.Now().Add(time.Hour)})
+		token, _ := CreateJWT(secret, 2, deactivatedSession)
+		rr := serve(handler, http.MethodGet, "Bearer "+token)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("should put the user and session IDs into the context", func(t *testing.T) {
+		token, _ := CreateJWT(secret, 1, sessionID)
+		rr := serve(handler, http.MethodGet, "Bearer "+token)
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if gotUserID != 1 {
+			t.Errorf("expected user ID 1 in context, got %d", gotUserID)
+		}
+		if gotSessionID != sessionID {
+			t.Errorf("expected session ID %d in context, got %d", sessionID, gotSessionID)
+		}
+	})
+
+	t.Run("should reject tokens of revoked sessions", func(t *testing.T) {
+		token, _ := CreateJWT(secret, 1, sessionID)
+		sessions.RevokeSession(1, sessionID)
+		rr := serve(handler, http.MethodGet, "Bearer "+token)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("Personal Access Tokens", func(t *testing.T) {
+		readToken, _ := GenerateAccessToken()
+		tokens.CreateAccessToken(types.AccessToken{UserID: 1, TokenHash: HashToken(readToken), Scopes: []string{"read:issues"}})
+
+		writeToken, _ := GenerateAccessToken()
+		tokens.CreateAccessToken(types.AccessToken{UserID: 1, TokenHash: HashToken(writeToken), Scopes: []string{"write:issues"}})
+
+		expiredToken, _ := GenerateAccessToken()
+		expiredAt := time.Now().Add(-time.Hour)
+		tokens.CreateAccessToken(types.AccessToken{UserID: 1, TokenHash: HashToken(expiredToken), Scopes: []string{"write:issues"}, ExpiresAt: &expiredAt})
+
+		t.Run("should allow reads with a read scope", func(t *testing.T) {
+			gotUserID = 0
+			rr := serve(handler, http.MethodGet, "Bearer "+readToken)
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+			}
+			if gotUserID != 1 {
+				t.Errorf("expected user ID 1 in context, got %d", gotUserID)
+			}
+		})
+
+		t.Run("should reject writes with a read scope", func(t *testing.T) {
+			rr := serve(handler, http.MethodPost, "Bearer "+readToken)
+			if rr.Code != http.StatusForbidden {
+				t.Errorf("expected status %d, got %d", http.StatusForbidden, rr.Code)
+			}
+		})
+
+		t.Run("should allow reads and writes with a write scope", func(t *testing.T) {
+			for _, method := range []string{http.MethodGet, http.MethodPost} {
+				rr := serve(handler, method, "Bearer "+writeToken)
+				if rr.Code != http.StatusOK {
+					t.Errorf("%s: expected status %d, got %d", method, http.StatusOK, rr.Code)
+				}
+			}
+		})
+
+		t.Run("should reject routes without a scope", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tokens", nil)
+			req.Header.Set("Authorization", "Bearer "+writeToken)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusForbidden {
+				t.Errorf("expected status %d, got %d", http.StatusForbidden, rr.Code)
+			}
+		})
+
+		t.Run("should reject security settings and membership changes", func(t *testing.T) {
+			projectsToken, _ := GenerateAccessToken()
+			tokens.CreateAccessToken(types.AccessToken{UserID: 1, TokenHash: HashToken(projectsToken), Scopes: []string{"write:projects"}})
+
+			requests := []struct{ method, path string }{
+				{http.MethodPut, "/projects/PRJ/require-2fa"},
+				{http.MethodPut, "/projects/PRJ/workflow"},
+				{http.MethodPost, "/projects-assignment/1/assign/2"},
+			}
+			for _, request := range requests {
+				req := httptest.NewRequest(request.method, request.path, nil)
+				req.Header.Set("Authorization", "Bearer "+projectsToken)
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				if rr.Code != http.StatusForbidden {
+					t.Errorf("%s %s: expected status %d, got %d", request.method, request.path, http.StatusForbidden, rr.Code)
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/projects/PRJ/workflow", nil)
+			req.Header.Set("Authorization", "Bearer "+projectsToken)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected the workflow to be readable, got %d", rr.Code)
+			}
+		})
+
+		t.Run("should reject expired tokens", func(t *testing.T) {
+			rr := serve(handler, http.MethodGet, "Bearer "+expiredToken)
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+			}
+		})
+
+		t.Run("should reject unknown tokens", func(t *testing.T) {
+			rr := serve(handler, http.MethodGet, "Bearer "+AccessTokenPrefix+"unknown")
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+			}
+		})
+
+		t.Run("should record when a token was last used", func(t *testing.T) {
+			token, _ := tokens.GetAccessTokenByHash(HashToken(readToken))
+			if token.LastUsedAt == nil {
+				t.Error("expected last used time to be set")
+			}
+		})
+	})
+}
+
+func serve(handler http.Handler, method, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/issues/PRJ", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func signClaims(t testing.TB, secret []byte, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// mockUserStore - Mock implementation of the user store
+type mockUserStore struct {
+	users map[int]types.User
+}
+
+func (m *mockUserStore) GetUserByEmail(email string) (*types.User, error) {
+	for _, u := range m.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (m *mockUserStore) GetUserByID(id int) (*types.User, error) {
+	u, ok := m.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user with id %d not found", id)
+	}
+	return &u, nil
+}
+
+func (m *mockUserStore) CreateUser(user types.User) error {
+	m.users[user.ID] = user
+	return nil
+}
+
+// mockAccessTokenStore - Mock implementation of the access token store
+type mockAccessTokenStore struct {
+	tokens map[int]*types.AccessToken
+}
+
+func newMockAccessTokenStore() *mockAccessTokenStore {
+	return &mockAccessTokenStore{tokens: make(map[int]*types.AccessToken)}
+}
+
+func (m *mockAccessTokenStore) CreateAccessToken(token types.AccessToken) (int, error) {
+	token.ID = len(m.tokens) + 1
+	m.tokens[token.ID] = &token
+	return token.ID, nil
+}
+
+func (m *mockAccessTokenStore) GetAccessTokenByHash(tokenHash string) (*types.AccessToken, error) {
+	for _, t := range m.tokens {
+		if t.TokenHash == tokenHash {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("access token not found")
+}
+
+func (m *mockAccessTokenStore) GetAccessTokensForUser(userID int) ([]types.AccessToken, error) {
+	var tokens []types.AccessToken
+	for _, t := range m.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *mockAccessTokenStore) UpdateAccessTokenLastUsed(tokenID int) error {
+	now := time.Now()
+	m.tokens[tokenID].LastUsedAt = &now
+	return nil
+}
+
+func (m *mockAccessTokenStore) RevokeAccessToken(userID, tokenID int) error {
+	delete(m.tokens, tokenID)
+	return nil
+}
+
+func (m *mockUserStore) MarkEmailVerified(userID int) error {
+	return nil
+}
+
+func (m *mockUserStore) UpdatePassword(userID int, hashedPassword string) error {
+	return nil
+}
+
+func (m *mockUserStore) UpdateProfile(userID int, firstName, lastName, email string) error {
+	return nil
+}
+
+func (m *mockUserStore) SetUserActive(userID int, active bool) error {
+	return nil
+}
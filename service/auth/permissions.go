@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+// Roles a user can hold on a project, stored in project_assignments.role.
+// The project lead recorded on the project itself is always treated as RoleLead.
+const (
+	RoleViewer     = "viewer"
+	RoleMember     = "member"
+	RoleMaintainer = "maintainer"
+	RoleLead       = "lead"
+)
+
+type Permission string
+
+const (
+	PermViewIssues     Permission = "view_issues"
+	PermCreateIssue    Permission = "create_issue"
+	PermEditIssue      Permission = "edit_issue"
+	PermComment        Permission = "comment"
+	PermRunStandups    Permission = "run_standups"
+	PermManageSprints  Permission = "manage_sprints"
+	PermManageScopes   Permission = "manage_scopes"
+	PermChangeWIPLimit Permission = "change_wip_limit"
+	PermManageWorkflow Permission = "manage_workflow"
+	// PermManageLabels covers the components and labels of a project
+	PermManageLabels   Permission = "manage_labels"
+	PermManageMembers  Permission = "manage_members"
+	PermManageSecurity Permission = "manage_security"
+	// PermModerateComments allows deleting comments written by others
+	PermModerateComments Permission = "moderate_comments"
+)
+
+var memberPermissions = []Permission{PermViewIssues, PermCreateIssue, PermEditIssue, PermComment, PermRunStandups}
+var maintainerPermissions = append(append([]Permission{}, memberPermissions...), PermManageSprints, PermManageScopes, PermChangeWIPLimit, PermManageWorkflow, PermManageLabels, PermModerateComments)
+
+// rolePermissions is the permission matrix used by every project scoped handler.
+var rolePermissions = map[string][]Permission{
+	RoleViewer:     {PermViewIssues},
+	RoleMember:     memberPermissions,
+	RoleMaintainer: maintainerPermissions,
+	RoleLead:       append(append([]Permission{}, maintainerPermissions...), PermManageMembers, PermManageSecurity),
+}
+
+// IsValidRole reports whether role is one of the defined project roles.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// roleOrder ranks the roles from least to most privileged.
+var roleOrder = []string{RoleViewer, RoleMember, RoleMaintainer, RoleLead}
+
+// HigherRole returns the more privileged of two roles, unknown or empty
+// roles rank lowest.
+func HigherRole(a, b string) string {
+	rank := func(role string) int {
+		for i, r := range roleOrder {
+			if r == role {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if rank(b) > rank(a) {
+		return b
+	}
+	return a
+}
+
+// HasPermission reports whether role grants perm.
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// HasProjectPermission reports whether the user holds perm on the project and
+// meets its two-factor requirement. It is for listings that leave out what the
+// user can't see, handlers refusing the request use RequireProjectPermission.
+func HasProjectPermission(store types.RoleStore, projectKey string, userID int, perm Permission) (bool, error) {
+	role, err := store.GetUserRole(projectKey, userID)
+	if err != nil || !HasPermission(role, perm) {
+		return false, err
+	}
+	if perm == PermManageSecurity {
+		return true, nil
+	}
+	return store.MeetsTwoFactorRequirement(projectKey, userID)
+}
+
+// ProjectViewer returns a check whether the user may view the issues of a
+// project, asking the store once per project. It is for responses mixing
+// issues of several projects.
+func ProjectViewer(store types.RoleStore, userID int) func(projectKey string) (bool, error) {
+	visible := make(map[string]bool)
+	return func(projectKey string) (bool, error) {
+		if ok, seen := visible[projectKey]; seen {
+			return ok, nil
+		}
+		ok, err := HasProjectPermission(store, projectKey, userID, PermViewIssues)
+		if err != nil {
+			return false, fmt.Errorf("failed to check project permissions: %v", err)
+		}
+		visible[projectKey] = ok
+		return ok, nil
+	}
+}
+
+// RequireProjectPermission checks that the authenticated user holds perm on the
+// given project. When the check fails the error response is written and false
+// is returned, so handlers can simply return.
+func RequireProjectPermission(w http.ResponseWriter, r *http.Request, store types.RoleStore, projectKey string, perm Permission) bool {
+	userID := GetUserIDFromContext(r.Context())
+	if userID == -1 {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("authentication required"))
+		return false
+	}
+
+	role, err := store.GetUserRole(projectKey, userID)
+	if err != nil {
+		log.Printf("failed to get role for user %d on project %s: %v", userID, projectKey, err)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to check project permissions"))
+		return false
+	}
+
+	if role == "" {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("you are not a member of project %s", projectKey))
+		return false
+	}
+
+	if !HasPermission(role, perm) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("role %q on project %s does not allow %s", role, projectKey, perm))
+		return false
+	}
+
+	// The security settings stay reachable so a lead can't lock the project out
+	if perm != PermManageSecurity {
+		ok, err := store.MeetsTwoFactorRequirement(projectKey, userID)
+		if err != nil {
+			log.Printf("failed to check two-factor requirement for user %d on project %s: %v", userID, projectKey, err)
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to check project permissions"))
+			return false
+		}
+		if !ok {
+			utils.WriteError(w, http.StatusForbidden, fmt.Errorf("project %s requires two-factor authentication", projectKey))
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+package auth
+
+import "testing"
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		role string
+		perm Permission
+		want bool
+	}{
+		{RoleViewer, PermViewIssues, true},
+		{RoleViewer, PermEditIssue, false},
+		{RoleMember, PermEditIssue, true},
+		{RoleViewer, PermComment, false},
+		{RoleMember, PermComment, true},
+		{RoleMember, PermModerateComments, false},
+		{RoleMaintainer, PermModerateComments, true},
+		{RoleMember, PermChangeWIPLimit, false},
+		{RoleMaintainer, PermChangeWIPLimit, true},
+		{RoleMember, PermManageWorkflow, false},
+		{RoleMaintainer, PermManageWorkflow, true},
+		{RoleMember, PermManageLabels, false},
+		{RoleMaintainer, PermManageLabels, true},
+		{RoleMaintainer, PermManageMembers, false},
+		{RoleLead, PermManageMembers, true},
+		{RoleMaintainer, PermManageSecurity, false},
+		{RoleLead, PermManageSecurity, true},
+		{"", PermViewIssues, false},
+		{"owner", PermViewIssues, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasPermission(tt.role, tt.perm); got != tt.want {
+			t.Errorf("HasPermission(%q, %q) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidRole(t *testing.T) {
+	for _, role := range []string{RoleViewer, RoleMember, RoleMaintainer, RoleLead} {
+		if !IsValidRole(role) {
+			t.Errorf("expected %q to be a valid role", role)
+		}
+	}
+
+	if IsValidRole("admin") {
+		t.Error("expected admin not to be a project role")
+	}
+}
+
+func TestHigherRole(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{"", RoleViewer, RoleViewer},
+		{RoleLead, RoleMember, RoleLead},
+		{RoleMember, RoleMaintainer, RoleMaintainer},
+		{"owner", RoleViewer, RoleViewer},
+	}
+
+	for _, tt := range tests {
+		if got := HigherRole(tt.a, tt.b); got != tt.want {
+			t.Errorf("HigherRole(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// roleStore - Mock role store, users without 2FA on projects requiring it
+type roleStore struct {
+	roles      map[string]string
+	require2FA map[string]bool
+}
+
+func (s *roleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return s.roles[projectKey], nil
+}
+
+func (s *roleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return !s.require2FA[projectKey], nil
+}
+
+func TestHasProjectPermission(t *testing.T) {
+	store := &roleStore{
+		roles:      map[string]string{"VIEW": RoleViewer, "LEAD": RoleLead, "SAFE": RoleMember},
+		require2FA: map[string]bool{"SAFE": true, "LEAD": true},
+	}
+
+	tests := []struct {
+		project string
+		perm    Permission
+		want    bool
+	}{
+		{"VIEW", PermViewIssues, true},
+		{"VIEW", PermEditIssue, false},
+		{"NONE", PermViewIssues, false},
+		{"SAFE", PermViewIssues, false},
+		{"LEAD", PermViewIssues, false},
+		{"LEAD", PermManageSecurity, true},
+	}
+
+	for _, tt := range tests {
+		got, err := HasProjectPermission(store, tt.project, 1, tt.perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("HasProjectPermission(%q, %q) = %v, want %v", tt.project, tt.perm, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+var ErrIncorrectPassword = errors.New("current password is incorrect")
+
+// RecordLoginFailures counts a failed login for the account and for the
+// address it came from, each against its own limit.
+func RecordLoginFailures(store types.LoginAttemptStore, emailKey, ipKey string, now time.Time) {
+	if err := RecordLoginFailure(store, emailKey, config.Envs.LoginMaxAttempts, now); err != nil {
+		log.Printf("failed to record login failure: %v", err)
+	}
+	if err := RecordLoginFailure(store, ipKey, config.Envs.LoginIPMaxAttempts, now); err != nil {
+		log.Printf("failed to record login failure: %v", err)
+	}
+}
+
+// ReauthenticatePassword checks the current password of the signed in user
+// and, when two-factor authentication is on, a code before a sensitive
+// change. A stolen session alone is not enough to take over the account.
+// When the check fails the error response is written and false is returned.
+func ReauthenticatePassword(w http.ResponseWriter, r *http.Request, attempts types.LoginAttemptStore, twoFactor types.TwoFactorStore, u *types.User, password, code string) bool {
+	if config.Envs.DisablePasswordLogin {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("password login is disabled, sign in with single sign-on"))
+		return false
+	}
+
+	return reauthenticate(w, r, attempts, u, func(now time.Time) error {
+		if password == "" || !ComparePassword(u.Password, []byte(password)) {
+			return ErrIncorrectPassword
+		}
+		if u.TOTPEnabled {
+			return VerifyTwoFactorCode(twoFactor, u, code, now)
+		}
+		return nil
+	})
+}
+
+// ReauthenticateCode checks a two-factor code of the signed in user, who
+// must have two-factor authentication on, like ReauthenticatePassword.
+func ReauthenticateCode(w http.ResponseWriter, r *http.Request, attempts types.LoginAttemptStore, twoFactor types.TwoFactorStore, u *types.User, code string) bool {
+	return reauthenticate(w, r, attempts, u, func(now time.Time) error {
+		return VerifyTwoFactorCode(twoFactor, u, code, now)
+	})
+}
+
+// reauthenticate runs check under the same throttle as logins, so it can't
+// be used to guess the password or a code faster. A wrong password or
+// code counts as a failed login.
+func reauthenticate(w http.ResponseWriter, r *http.Request, attempts types.LoginAttemptStore, u *types.User, check func(now time.Time) error) bool {
+	now := time.Now()
+	emailKey := EmailThrottleKey(u.Email)
+	ipKey := IPThrottleKey(ClientIP(r))
+
+	wait, err := LoginRetryAfter(attempts, now, emailKey, ipKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many failed login attempts, try again later"))
+		return false
+	}
+
+	if err := check(now); err != nil {
+		if !errors.Is(err, ErrInvalidTwoFactorCode) && !errors.Is(err, ErrIncorrectPassword) {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return false
+		}
+		RecordLoginFailures(attempts, emailKey, ipKey, now)
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// CreateSession starts a new server side session for the user and returns a
+// short lived access token together with the session's refresh token.
+func CreateSession(store types.SessionStore, userID int, r *http.Request) (*types.TokenPair, error) {
+	refreshToken, err := GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	sessionID, err := store.CreateSession(types.Session{
+		UserID:    userID,
+		TokenHash: HashToken(refreshToken),
+		UserAgent: userAgent,
+		IPAddress: ClientIP(r),
+		ExpiresAt: refreshTokenExpiry(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+
+	accessToken, err := CreateJWT([]byte(config.Envs.JWTSecret), userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// RefreshSession exchanges a refresh token for a new token pair. Refresh
+// tokens rotate on every use; presenting an already rotated token revokes the
+// whole session since it means the token has leaked.
+func RefreshSession(store types.SessionStore, refreshToken string) (*types.TokenPair, error) {
+	tokenHash := HashToken(refreshToken)
+
+	session, err := store.GetSessionByRefreshToken(tokenHash)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if session.TokenHash != tokenHash {
+		log.Printf("refresh token reuse detected for session %d, revoking it", session.ID)
+		if err := store.RevokeSession(session.UserID, session.ID); err != nil {
+			log.Printf("failed to revoke session %d: %v", session.ID, err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.RotateRefreshToken(session.ID, tokenHash, HashToken(newRefreshToken), refreshTokenExpiry()); err != nil {
+		log.Printf("failed to rotate refresh token for session %d: %v", session.ID, err)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	accessToken, err := CreateJWT([]byte(config.Envs.JWTSecret), session.UserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.TokenPair{AccessToken: accessToken, RefreshToken: newRefreshToken}, nil
+}
+
+// RequireAdmin checks that the authenticated user is an administrator. When
+// the check fails the error response is written and false is returned.
+func RequireAdmin(w http.ResponseWriter, r *http.Request, store types.UserStore) bool {
+	userID := GetUserIDFromContext(r.Context())
+	if userID == -1 {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("authentication required"))
+		return false
+	}
+
+	u, err := store.GetUserByID(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("authentication required"))
+		return false
+	}
+
+	if !u.IsAdmin {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("admin privileges required"))
+		return false
+	}
+
+	return true
+}
+
+// GenerateToken returns a random URL safe token with 256 bits of entropy.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of a token. Only hashes of
+// opaque tokens are ever stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClientIP returns the remote address of the request without the port.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func refreshTokenExpiry() time.Time {
+	return time.Now().Add(time.Second * time.Duration(config.Envs.RefreshTokenExpirationInSeconds))
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestRefreshSession(t *testing.T) {
+	store := newMockSessionStore()
+	req := httptest.NewRequest("POST", "/login", nil)
+
+	tokens, err := CreateSession(store, 1, req)
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatal("expected access and refresh tokens not to be empty")
+	}
+
+	t.Run("should rotate the refresh token", func(t *testing.T) {
+		rotated, err := RefreshSession(store, tokens.RefreshToken)
+		if err != nil {
+			t.Fatalf("error refreshing session: %v", err)
+		}
+		if rotated.RefreshToken == tokens.RefreshToken {
+			t.Error("expected a new refresh token")
+		}
+
+		t.Run("and revoke the session when an old token is reused", func(t *testing.T) {
+			if _, err := RefreshSession(store, tokens.RefreshToken); err != ErrInvalidRefreshToken {
+				t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+			}
+			if _, err := RefreshSession(store, rotated.RefreshToken); err != ErrInvalidRefreshToken {
+				t.Errorf("expected the rotated token to be revoked too, got %v", err)
+			}
+		})
+	})
+
+	t.Run("should reject unknown tokens", func(t *testing.T) {
+		if _, err := RefreshSession(store, "unknown"); err != ErrInvalidRefreshToken {
+			t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("error generating token: %v", err)
+	}
+
+	if HashToken(token) != HashToken(token) {
+		t.Error("expected hashing to be deterministic")
+	}
+	if HashToken(token) == token {
+		t.Error("expected hash to be different to the token")
+	}
+}
+
+// mockSessionStore - Mock implementation of the session store
+type mockSessionStore struct {
+	sessions map[int]*types.Session
+}
+
+func newMockSessionStore() *mockSessionStore {
+	return &mockSessionStore{sessions: make(map[int]*types.Session)}
+}
+
+func (m *mockSessionStore) CreateSession(session types.Session) (int, error) {
+	session.ID = len(m.sessions) + 1
+	m.sessions[session.ID] = &session
+	return session.ID, nil
+}
+
+func (m *mockSessionStore) GetSessionByRefreshToken(tokenHash string) (*types.Session, error) {
+	for _, s := range m.sessions {
+		if s.TokenHash == tokenHash || s.PreviousTokenHash == tokenHash {
+			session := *s
+			return &session, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found")
+}
+
+func (m *mockSessionStore) RotateRefreshToken(sessionID int, oldHash, newHash string, expiresAt time.Time) error {
+	s, ok := m.sessions[sessionID]
+	if !ok || s.TokenHash != oldHash || s.RevokedAt != nil {
+		return fmt.Errorf("session %d is no longer active", sessionID)
+	}
+	s.PreviousTokenHash, s.TokenHash, s.ExpiresAt = s.TokenHash, newHash, expiresAt
+	return nil
+}
+
+func (m *mockSessionStore) IsSessionActive(sessionID int) (bool, error) {
+	s, ok := m.sessions[sessionID]
+	return ok && s.RevokedAt == nil && time.Now().Before(s.ExpiresAt), nil
+}
+
+func (m *mockSessionStore) GetActiveSessionsForUser(userID int) ([]types.Session, error) {
+	var sessions []types.Session
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *mockSessionStore) RevokeSession(userID, sessionID int) error {
+	s, ok := m.sessions[sessionID]
+	if !ok || s.UserID != userID || s.RevokedAt != nil {
+		return fmt.Errorf("session not found")
+	}
+	now := time.Now()
+	s.RevokedAt = &now
+	return nil
+}
+
+func (m *mockSessionStore) RevokeAllSessionsForUser(userID int) error {
+	for _, s := range m.sessions {
+		if s.UserID == userID {
+			m.RevokeSession(userID, s.ID)
+		}
+	}
+	return nil
+}
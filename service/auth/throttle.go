@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// EmailThrottleKey and IPThrottleKey build the keys failed logins are tracked under.
+func EmailThrottleKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginRetryAfter returns how long the caller has to wait before trying to
+// log in again, zero when none of the keys are currently throttled.
+func LoginRetryAfter(store types.LoginAttemptStore, now time.Time, keys ...string) (time.Duration, error) {
+	var wait time.Duration
+
+	for _, key := range keys {
+		attempt, err := store.GetLoginAttempt(key)
+		if err != nil {
+			return 0, err
+		}
+		if attempt == nil || attempt.LockedUntil == nil {
+			continue
+		}
+
+		if d := attempt.LockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+
+	return wait, nil
+}
+
+// RecordLoginFailure counts a failed login for the key. Every failure backs
+// off exponentially and reaching maxAttempts locks the key for the lockout
+// period. Failures older than the lockout period are forgotten. The store
+// counts atomically, so concurrent failures can't share a count and get more
+// guesses than maxAttempts.
+func RecordLoginFailure(store types.LoginAttemptStore, key string, maxAttempts int64, now time.Time) error {
+	lockout := time.Second * time.Duration(config.Envs.LoginLockoutSeconds)
+
+	failures, err := store.IncrementLoginFailures(key, now, lockout)
+	if err != nil {
+		return err
+	}
+
+	return store.LockLogin(key, now.Add(loginBackoff(failures, maxAttempts, lockout)))
+}
+
+func loginBackoff(failures int, maxAttempts int64, lockout time.Duration) time.Duration {
+	if int64(failures) >= maxAttempts {
+		return lockout
+	}
+
+	base := time.Second * time.Duration(config.Envs.LoginBackoffBaseSeconds)
+	backoff := time.Duration(float64(base) * math.Pow(2, float64(failures-1)))
+	if backoff > lockout || backoff < 0 {
+		return lockout
+	}
+	return backoff
+}
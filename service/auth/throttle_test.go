@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+type mockLoginAttemptStore struct {
+	attempts map[string]types.LoginAttempt
+}
+
+func (m *mockLoginAttemptStore) GetLoginAttempt(key string) (*types.LoginAttempt, error) {
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (m *mockLoginAttemptStore) IncrementLoginFailures(key string, now time.Time, resetAfter time.Duration) (int, error) {
+	attempt, ok := m.attempts[key]
+	if !ok || now.Sub(attempt.LastFailedAt) > resetAfter {
+		attempt = types.LoginAttempt{Key: key}
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = now
+	m.attempts[key] = attempt
+	return attempt.Failures, nil
+}
+
+func (m *mockLoginAttemptStore) LockLogin(key string, until time.Time) error {
+	attempt := m.attempts[key]
+	if attempt.LockedUntil == nil || until.After(*attempt.LockedUntil) {
+		attempt.LockedUntil = &until
+	}
+	m.attempts[key] = attempt
+	return nil
+}
+
+func (m *mockLoginAttemptStore) ClearLoginAttempts(key string) error {
+	delete(m.attempts, key)
+	return nil
+}
+
+func TestLoginThrottle(t *testing.T) {
+	defer func(base, lockout int64) {
+		config.Envs.LoginBackoffBaseSeconds, config.Envs.LoginLockoutSeconds = base, lockout
+	}(config.Envs.LoginBackoffBaseSeconds, config.Envs.LoginLockoutSeconds)
+	config.Envs.LoginBackoffBaseSeconds = 1
+	config.Envs.LoginLockoutSeconds = 900
+
+	store := &mockLoginAttemptStore{attempts: make(map[string]types.LoginAttempt)}
+	key := EmailThrottleKey(" User@Example.com")
+	now := time.Now()
+
+	t.Run("should not throttle unknown keys", func(t *testing.T) {
+		wait, err := LoginRetryAfter(store, now, key)
+		if err != nil || wait != 0 {
+			t.Errorf("expected no wait, got %v (err %v)", wait, err)
+		}
+	})
+
+	t.Run("should back off exponentially", func(t *testing.T) {
+		for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+			if err := RecordLoginFailure(store, key, 5, now); err != nil {
+				t.Fatal(err)
+			}
+			wait, _ := LoginRetryAfter(store, now, key, IPThrottleKey("127.0.0.1"))
+			if wait != expected {
+				t.Errorf("failure %d: expected wait %v, got %v", i+1, expected, wait)
+			}
+		}
+	})
+
+	t.Run("should lock out after max attempts", func(t *testing.T) {
+		RecordLoginFailure(store, key, 5, now)
+		RecordLoginFailure(store, key, 5, now)
+
+		wait, _ := LoginRetryAfter(store, now, key)
+		if wait != 900*time.Second {
+			t.Errorf("expected lockout, got %v", wait)
+		}
+	})
+
+	t.Run("should forget failures older than the lockout", func(t *testing.T) {
+		later := now.Add(901 * time.Second)
+		RecordLoginFailure(store, key, 5, later)
+
+		if attempt, _ := store.GetLoginAttempt(key); attempt.Failures != 1 {
+			t.Errorf("expected failures to reset, got %d", attempt.Failures)
+		}
+	})
+}
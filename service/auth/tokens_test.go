@@ -0,0 +1,35 @@
+package auth
+
+import "testing"
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{[]string{"read:issues"}, "read:issues", true},
+		{[]string{"write:issues"}, "read:issues", true},
+		{[]string{"read:issues"}, "write:issues", false},
+		{[]string{"write:projects"}, "read:issues", false},
+		{[]string{"write:issues"}, "", false},
+		{nil, "read:issues", false},
+	}
+
+	for _, tt := range tests {
+		if got := HasScope(tt.granted, tt.required); got != tt.want {
+			t.Errorf("HasScope(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateAccessToken(t *testing.T) {
+	token, err := GenerateAccessToken()
+	if err != nil {
+		t.Fatalf("error generating access token: %v", err)
+	}
+
+	if !IsPersonalAccessToken(token) {
+		t.Errorf("expected %q to be recognised as a personal access token", token)
+	}
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B, SHA1 secret truncated to six digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, v := range vectors {
+		code, err := TOTPCode(secret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != v.code {
+			t.Errorf("TOTPCode at %d = %s, want %s", v.unix, code, v.code)
+		}
+	}
+
+	t.Run("should accept codes within the allowed skew", func(t *testing.T) {
+		now := time.Unix(1111111109, 0)
+		if _, ok := ValidateTOTP(secret, "081804", now.Add(totpPeriod*time.Second)); !ok {
+			t.Error("expected previous code to be accepted")
+		}
+		if _, ok := ValidateTOTP(secret, "081804", now.Add(3*totpPeriod*time.Second)); ok {
+			t.Error("expected stale code to be rejected")
+		}
+		if _, ok := ValidateTOTP("", "081804", now); ok {
+			t.Error("expected code without a secret to be rejected")
+		}
+	})
+
+	t.Run("should build an otpauth uri", func(t *testing.T) {
+		uri := TOTPURI("Issue Tracker", "user@example.com", secret)
+		if !strings.HasPrefix(uri, "otpauth://totp/Issue%20Tracker:user@example.com?") || !strings.Contains(uri, "secret="+secret) {
+			t.Errorf("unexpected uri %s", uri)
+		}
+	})
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", recoveryCodeCount, len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("unexpected code format %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+
+	if HashRecoveryCode("ABCDE FGHIJ") != HashRecoveryCode("abcde-fghij") {
+		t.Error("expected hashing to ignore case, spaces and dashes")
+	}
+}
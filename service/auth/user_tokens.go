@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// Purposes of the single use user tokens, sent by email or handed out on login.
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+	// PurposeTwoFactorLogin is the challenge handed out by login when the
+	// user still has to provide a second factor.
+	PurposeTwoFactorLogin = "two_factor_login"
+)
+
+var ErrInvalidUserToken = errors.New("invalid, expired or already used token")
+
+// CreateUserToken returns a signed token for the given purpose. The token's
+// jti is recorded so that ConsumeUserToken can only succeed once.
+func CreateUserToken(store types.UserTokenStore, userID int, purpose string, ttl time.Duration) (string, error) {
+	jti, err := GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	if err := store.CreateUserToken(types.UserToken{JTI: jti, UserID: userID, Purpose: purpose, ExpiresAt: expiresAt}); err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userID":    strconv.Itoa(userID),
+		"purpose":   purpose,
+		"jti":       jti,
+		"expiredAt": expiresAt.Unix(),
+	})
+
+	return token.SignedString([]byte(config.Envs.JWTSecret))
+}
+
+// ParseUserToken validates a token created by CreateUserToken for the same
+// purpose without using it up and returns the user it was issued for.
+func ParseUserToken(tokenString string, purpose string) (int, error) {
+	userID, _, err := parseUserToken(tokenString, purpose)
+	return userID, err
+}
+
+// ConsumeUserToken validates a token created by CreateUserToken for the same
+// purpose, marks it as used and returns the user it was issued for.
+func ConsumeUserToken(store types.UserTokenStore, tokenString string, purpose string) (int, error) {
+	userID, jti, err := parseUserToken(tokenString, purpose)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := store.ConsumeUserToken(jti, purpose); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidUserToken, err)
+	}
+
+	return userID, nil
+}
+
+func parseUserToken(tokenString string, purpose string) (int, string, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
+		return []byte(config.Envs.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return 0, "", ErrInvalidUserToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != purpose {
+		return 0, "", ErrInvalidUserToken
+	}
+
+	expiredAt, ok := claims["expiredAt"].(float64)
+	if !ok || time.Now().Unix() > int64(expiredAt) {
+		return 0, "", ErrInvalidUserToken
+	}
+
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return 0, "", ErrInvalidUserToken
+	}
+
+	userID, err := intClaim(claims, "userID")
+	if err != nil {
+		return 0, "", ErrInvalidUserToken
+	}
+
+	return userID, jti, nil
+}
@@ -0,0 +1,290 @@
+package comment
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type Handler struct {
+	store  types.CommentStore
+	issues types.IssueStore
+	roles  types.RoleStore
+	index  types.SearchIndex
+}
+
+func NewHandler(store types.CommentStore, issues types.IssueStore, roles types.RoleStore, index types.SearchIndex) *Handler {
+	return &Handler{store: store, issues: issues, roles: roles, index: index}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/issues/{id}/comments", h.handleGetComments).Methods("GET")
+	router.HandleFunc("/issues/{id}/comments", h.handleCreateComment).Methods("POST")
+	router.HandleFunc("/issues/{id}/comments/{commentID}", h.handleUpdateComment).Methods("PATCH")
+	router.HandleFunc("/issues/{id}/comments/{commentID}", h.handleDeleteComment).Methods("DELETE")
+	router.HandleFunc("/issues/{id}/comments/{commentID}/revisions", h.handleGetRevisions).Methods("GET")
+}
+
+// handleGetComments returns the top level comments oldest first with their
+// replies nested. Deleted comments keep their place in the thread but lose
+// their body.
+func (h *Handler) handleGetComments(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.loadIssue(w, r, auth.PermViewIssues)
+	if !ok {
+		return
+	}
+
+	comments, err := h.store.GetCommentsByIssue(issue.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"issue_id": issue.ID,
+		"comments": buildThreads(comments),
+	})
+}
+
+func (h *Handler) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	var payload types.CommentPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	issue, ok := h.loadIssue(w, r, auth.PermComment)
+	if !ok {
+		return
+	}
+
+	// Replies are one level deep and stay on the issue of their parent
+	if payload.ParentID != nil {
+		parent, err := h.store.GetCommentByID(*payload.ParentID)
+		if err != nil || parent.IssueID != issue.ID {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("parent comment not found on this issue"))
+			return
+		}
+		if parent.ParentID != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("replies can't be replied to"))
+			return
+		}
+		if parent.DeletedAt != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("parent comment was deleted"))
+			return
+		}
+	}
+
+	comment := types.Comment{
+		IssueID:  issue.ID,
+		ParentID: payload.ParentID,
+		AuthorID: auth.GetUserIDFromContext(r.Context()),
+		Body:     payload.Body,
+	}
+
+	id, err := h.store.CreateComment(comment)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.reindex(issue.ID)
+
+	created, err := h.store.GetCommentByID(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, created)
+}
+
+// handleUpdateComment lets authors edit their own comments, the previous
+// body is kept as a revision.
+func (h *Handler) handleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	var payload types.CommentUpdatePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	issue, ok := h.loadIssue(w, r, auth.PermComment)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.loadComment(w, r, issue)
+	if !ok {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if comment.AuthorID != userID {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("only the author can edit a comment"))
+		return
+	}
+
+	if comment.Body != payload.Body {
+		if err := h.store.UpdateComment(comment.ID, payload.Body, userID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		h.reindex(issue.ID)
+	}
+
+	updated, err := h.store.GetCommentByID(comment.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, updated)
+}
+
+// handleDeleteComment soft deletes a comment. Authors can delete their own
+// comments, moderators anyone's.
+func (h *Handler) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.loadIssue(w, r, auth.PermComment)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.loadComment(w, r, issue)
+	if !ok {
+		return
+	}
+
+	if comment.AuthorID != auth.GetUserIDFromContext(r.Context()) &&
+		!auth.RequireProjectPermission(w, r, h.roles, issue.ProjectKey, auth.PermModerateComments) {
+		return
+	}
+
+	if err := h.store.DeleteComment(comment.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.reindex(issue.ID)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Comment deleted successfully",
+	})
+}
+
+func (h *Handler) handleGetRevisions(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.loadIssue(w, r, auth.PermViewIssues)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.loadComment(w, r, issue)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.store.GetCommentRevisions(comment.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"comment_id": comment.ID,
+		"revisions":  revisions,
+	})
+}
+
+// reindex updates the search index of the issue after its comments changed.
+// The write already succeeded, so a failure is only logged.
+func (h *Handler) reindex(issueID int) {
+	if err := h.index.IndexIssue(issueID); err != nil {
+		log.Printf("failed to index issue %d: %v", issueID, err)
+	}
+}
+
+// loadIssue fetches the issue from the URL and checks perm on its project.
+func (h *Handler) loadIssue(w http.ResponseWriter, r *http.Request, perm auth.Permission) (*types.Issue, bool) {
+	issueID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid issue ID"))
+		return nil, false
+	}
+
+	issue, err := h.issues.GetIssueByID(issueID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("issue not found"))
+		return nil, false
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, issue.ProjectKey, perm) {
+		return nil, false
+	}
+
+	return issue, true
+}
+
+// loadComment fetches the comment from the URL, deleted comments and
+// comments of other issues are not found.
+func (h *Handler) loadComment(w http.ResponseWriter, r *http.Request, issue *types.Issue) (*types.Comment, bool) {
+	commentID, err := strconv.Atoi(mux.Vars(r)["commentID"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid comment ID"))
+		return nil, false
+	}
+
+	comment, err := h.store.GetCommentByID(commentID)
+	if err != nil || comment.IssueID != issue.ID || comment.DeletedAt != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("comment not found"))
+		return nil, false
+	}
+
+	return comment, true
+}
+
+// buildThreads nests replies under their parent comment, keeping the order
+// of comments, which is oldest first.
+func buildThreads(comments []types.Comment) []types.Comment {
+	threads := []types.Comment{}
+	index := make(map[int]int)
+
+	for _, c := range comments {
+		if c.DeletedAt != nil {
+			c.Body = ""
+		}
+		if c.ParentID == nil {
+			index[c.ID] = len(threads)
+			threads = append(threads, c)
+		}
+	}
+
+	for _, c := range comments {
+		if c.ParentID == nil {
+			continue
+		}
+		if c.DeletedAt != nil {
+			c.Body = ""
+		}
+		if i, ok := index[*c.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, c)
+		}
+	}
+
+	return threads
+}
@@ -0,0 +1,344 @@
+package comment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestCommentServiceHandlers(t *testing.T) {
+	store := newMockCommentStore()
+	issues := &mockIssueStore{issues: map[int]types.Issue{
+		1: {ID: 1, Key: "PRJ-1", ProjectKey: "PRJ"},
+		2: {ID: 2, Key: "PRJ-2", ProjectKey: "PRJ"},
+		3: {ID: 3, Key: "OTHER-1", ProjectKey: "OTHER"},
+	}}
+	roles := &mockRoleStore{roles: map[int]map[string]string{
+		1: {"PRJ": "member"},
+		2: {"PRJ": "member"},
+		3: {"PRJ": "viewer"},
+		4: {"PRJ": "maintainer"},
+	}}
+	index := &mockSearchIndex{}
+	handler := NewHandler(store, issues, roles, index)
+
+	t.Run("Create Comment", func(t *testing.T) {
+		t.Run("should fail if the body is empty", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/comments", types.CommentPayload{}, http.StatusBadRequest)
+		})
+
+		t.Run("should create a comment authored by the current user", func(t *testing.T) {
+			before := issues.issues[1].UpdatedAt
+			rr := testRequest(t, handler, 1, http.MethodPost, "/issues/1/comments", types.CommentPayload{Body: "first"}, http.StatusCreated)
+
+			var c types.Comment
+			if err := json.NewDecoder(rr.Body).Decode(&c); err != nil {
+				t.Fatal(err)
+			}
+			if c.AuthorID != 1 || c.IssueID != 1 || c.Body != "first" {
+				t.Errorf("unexpected comment %+v", c)
+			}
+			if !store.touched[1].After(before) {
+				t.Error("expected the issue to be touched")
+			}
+			if len(index.indexed) != 1 || index.indexed[0] != 1 {
+				t.Errorf("expected the issue to be indexed, got %v", index.indexed)
+			}
+		})
+
+		t.Run("should create a reply", func(t *testing.T) {
+			parentID := 1
+			testRequest(t, handler, 2, http.MethodPost, "/issues/1/comments", types.CommentPayload{Body: "reply", ParentID: &parentID}, http.StatusCreated)
+		})
+
+		t.Run("should not reply to a reply", func(t *testing.T) {
+			parentID := 2
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/comments", types.CommentPayload{Body: "nested", ParentID: &parentID}, http.StatusBadRequest)
+		})
+
+		t.Run("should not reply to a comment on another issue", func(t *testing.T) {
+			parentID := 1
+			testRequest(t, handler, 1, http.MethodPost, "/issues/2/comments", types.CommentPayload{Body: "elsewhere", ParentID: &parentID}, http.StatusBadRequest)
+		})
+
+		t.Run("should be forbidden for viewers", func(t *testing.T) {
+			testRequest(t, handler, 3, http.MethodPost, "/issues/1/comments", types.CommentPayload{Body: "viewer"}, http.StatusForbidden)
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/issues/3/comments", types.CommentPayload{Body: "outsider"}, http.StatusForbidden)
+		})
+
+		t.Run("should fail if the issue does not exist", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/issues/99/comments", types.CommentPayload{Body: "missing"}, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Update Comment", func(t *testing.T) {
+		t.Run("should keep the previous body as a revision", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPatch, "/issues/1/comments/1", types.CommentUpdatePayload{Body: "first, edited"}, http.StatusOK)
+
+			if store.comments[1].Body != "first, edited" || store.comments[1].EditedAt == nil {
+				t.Errorf("expected the comment to be edited, got %+v", store.comments[1])
+			}
+			if len(store.revisions[1]) != 1 || store.revisions[1][0].Body != "first" {
+				t.Errorf("expected the original body as revision, got %+v", store.revisions[1])
+			}
+		})
+
+		t.Run("should be forbidden for other users", func(t *testing.T) {
+			testRequest(t, handler, 4, http.MethodPatch, "/issues/1/comments/1", types.CommentUpdatePayload{Body: "hijacked"}, http.StatusForbidden)
+		})
+
+		t.Run("should not find the comment through another issue", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPatch, "/issues/2/comments/1", types.CommentUpdatePayload{Body: "moved"}, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Get Revisions", func(t *testing.T) {
+		rr := testRequest(t, handler, 3, http.MethodGet, "/issues/1/comments/1/revisions", nil, http.StatusOK)
+
+		var resp struct {
+			Revisions []types.CommentRevision `json:"revisions"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Revisions) != 1 || resp.Revisions[0].EditedBy != 1 {
+			t.Errorf("unexpected revisions %+v", resp.Revisions)
+		}
+	})
+
+	t.Run("Delete Comment", func(t *testing.T) {
+		t.Run("should be forbidden for other members", func(t *testing.T) {
+			testRequest(t, handler, 2, http.MethodDelete, "/issues/1/comments/1", nil, http.StatusForbidden)
+		})
+
+		t.Run("should let moderators delete any comment", func(t *testing.T) {
+			testRequest(t, handler, 4, http.MethodDelete, "/issues/1/comments/1", nil, http.StatusOK)
+
+			if store.comments[1].DeletedAt == nil {
+				t.Error("expected the comment to be soft deleted")
+			}
+		})
+
+		t.Run("should not edit a deleted comment", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPatch, "/issues/1/comments/1", types.CommentUpdatePayload{Body: "again"}, http.StatusNotFound)
+		})
+
+		t.Run("should let authors delete their own comment", func(t *testing.T) {
+			testRequest(t, handler, 2, http.MethodDelete, "/issues/1/comments/2", nil, http.StatusOK)
+		})
+	})
+
+	t.Run("Get Comments", func(t *testing.T) {
+		t.Run("should nest replies and hide deleted bodies", func(t *testing.T) {
+			rr := testRequest(t, handler, 3, http.MethodGet, "/issues/1/comments", nil, http.StatusOK)
+
+			var resp struct {
+				Comments []types.Comment `json:"comments"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Comments) != 1 || len(resp.Comments[0].Replies) != 1 {
+				t.Fatalf("expected one thread with one reply, got %+v", resp.Comments)
+			}
+			if resp.Comments[0].Body != "" || resp.Comments[0].Replies[0].Body != "" {
+				t.Errorf("expected deleted bodies to be hidden, got %+v", resp.Comments)
+			}
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, 3, http.MethodGet, "/issues/3/comments", nil, http.StatusForbidden)
+		})
+	})
+}
+
+// testRequest - Helper function to perform HTTP requests as a user and check the response
+func testRequest(t testing.TB, handler *Handler, userID int, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body []byte
+	if payload != nil {
+		marshalled, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = marshalled
+	}
+
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, userID))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+		fmt.Printf("Response Body: %s\n", rr.Body.String())
+	}
+
+	return rr
+}
+
+// mockCommentStore - Mock implementation of the comment store
+type mockCommentStore struct {
+	comments  map[int]*types.Comment
+	revisions map[int][]types.CommentRevision
+	touched   map[int]time.Time
+}
+
+func newMockCommentStore() *mockCommentStore {
+	return &mockCommentStore{
+		comments:  make(map[int]*types.Comment),
+		revisions: make(map[int][]types.CommentRevision),
+		touched:   make(map[int]time.Time),
+	}
+}
+
+func (m *mockCommentStore) CreateComment(comment types.Comment) (int, error) {
+	comment.ID = len(m.comments) + 1
+	comment.CreatedAt = time.Now()
+	m.comments[comment.ID] = &comment
+	m.touched[comment.IssueID] = time.Now()
+	return comment.ID, nil
+}
+
+func (m *mockCommentStore) GetCommentByID(id int) (*types.Comment, error) {
+	c, ok := m.comments[id]
+	if !ok {
+		return nil, fmt.Errorf("comment not found")
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (m *mockCommentStore) GetCommentsByIssue(issueID int) ([]types.Comment, error) {
+	var comments []types.Comment
+	for id := 1; id <= len(m.comments); id++ {
+		if c := m.comments[id]; c.IssueID == issueID {
+			comments = append(comments, *c)
+		}
+	}
+	return comments, nil
+}
+
+func (m *mockCommentStore) UpdateComment(id int, body string, editorID int) error {
+	c, ok := m.comments[id]
+	if !ok || c.DeletedAt != nil {
+		return fmt.Errorf("comment not found")
+	}
+	m.revisions[id] = append(m.revisions[id], types.CommentRevision{ID: len(m.revisions[id]) + 1, CommentID: id, Body: c.Body, EditedBy: editorID})
+	now := time.Now()
+	c.Body = body
+	c.EditedAt = &now
+	m.touched[c.IssueID] = now
+	return nil
+}
+
+func (m *mockCommentStore) DeleteComment(id int) error {
+	c, ok := m.comments[id]
+	if !ok || c.DeletedAt != nil {
+		return fmt.Errorf("comment not found")
+	}
+	now := time.Now()
+	c.DeletedAt = &now
+	m.touched[c.IssueID] = now
+	return nil
+}
+
+func (m *mockCommentStore) GetCommentRevisions(commentID int) ([]types.CommentRevision, error) {
+	return m.revisions[commentID], nil
+}
+
+// mockIssueStore - Mock implementation of the issue store
+type mockIssueStore struct {
+	issues map[int]types.Issue
+}
+
+func (m *mockIssueStore) CreateIssue(issue types.Issue, actorID int) (int, error) {
+	return 0, nil
+}
+
+func (m *mockIssueStore) UpdateIssue(issue types.Issue, actorID int) error {
+	return nil
+}
+
+func (m *mockIssueStore) GetIssueHistory(issueID int) ([]types.IssueChange, error) {
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetIssueByID(id int) (*types.Issue, error) {
+	issue, ok := m.issues[id]
+	if !ok {
+		return nil, fmt.Errorf("issue not found")
+	}
+	return &issue, nil
+}
+
+func (m *mockIssueStore) GetIssuesByProject(projectKey string, filter types.IssueFilter) (*types.IssuePage, error) {
+	return &types.IssuePage{}, nil
+}
+
+func (m *mockIssueStore) GetAverageCycleTime(projectKey string, filter types.IssueFilter) (time.Duration, error) {
+	return 0, nil
+}
+
+func (m *mockIssueStore) GetWeeklyThroughput(projectKey string, filter types.IssueFilter) (map[string]int, error) {
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetBoard(projectKey string, agingAfter time.Duration) (*types.Board, error) {
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetChildren(issueID int) ([]types.Issue, error) {
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetProgress(issueID int) (*types.IssueProgress, error) {
+	return nil, nil
+}
+
+// mockSearchIndex - Mock implementation of the search index, records the indexed issues
+type mockSearchIndex struct {
+	indexed []int
+}
+
+func (m *mockSearchIndex) IndexIssue(issueID int) error {
+	m.indexed = append(m.indexed, issueID)
+	return nil
+}
+
+func (m *mockSearchIndex) SearchText(userID int, query types.TextQuery) (*types.TextSearchPage, error) {
+	return &types.TextSearchPage{}, nil
+}
+
+// mockRoleStore - Mock implementation of the role store, roles per user and project
+type mockRoleStore struct {
+	roles map[int]map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[userID][projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
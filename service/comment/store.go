@@ -0,0 +1,211 @@
+package comment
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const commentColumns = "c.id, c.issue_id, c.parent_id, c.author_id, c.body, c.created_at, c.edited_at, c.deleted_at, " +
+	"u.firstName, u.lastName, u.email " +
+	"FROM comments c LEFT JOIN users u ON u.id = c.author_id"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanComment(row scanner) (*types.Comment, error) {
+	c := &types.Comment{}
+	var parentID, authorID sql.NullInt64
+	var editedAt, deletedAt sql.NullTime
+	var firstName, lastName, email sql.NullString
+
+	err := row.Scan(&c.ID, &c.IssueID, &parentID, &authorID, &c.Body, &c.CreatedAt, &editedAt, &deletedAt, &firstName, &lastName, &email)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		c.ParentID = &id
+	}
+	if authorID.Valid {
+		c.AuthorID = int(authorID.Int64)
+		c.Author = &types.UserSummary{ID: c.AuthorID, FirstName: firstName.String, LastName: lastName.String, Email: email.String}
+	}
+	if editedAt.Valid {
+		c.EditedAt = &editedAt.Time
+	}
+	if deletedAt.Valid {
+		c.DeletedAt = &deletedAt.Time
+	}
+
+	return c, nil
+}
+
+// touchIssue bumps the updatedAt of the issue a comment belongs to.
+func touchIssue(tx *sql.Tx, issueID int) error {
+	if _, err := tx.Exec("UPDATE issues SET updatedAt = NOW() WHERE id = ?", issueID); err != nil {
+		return fmt.Errorf("failed to update issue: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) CreateComment(comment types.Comment) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO comments (issue_id, parent_id, author_id, body) VALUES (?, ?, ?, ?)",
+		comment.IssueID, comment.ParentID, comment.AuthorID, comment.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert comment: %v", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve comment id: %v", err)
+	}
+
+	if err := touchIssue(tx, comment.IssueID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) GetCommentByID(id int) (*types.Comment, error) {
+	c, err := scanComment(s.db.QueryRow("SELECT "+commentColumns+" WHERE c.id = ?", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("comment not found")
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *Store) GetCommentsByIssue(issueID int) ([]types.Comment, error) {
+	rows, err := s.db.Query("SELECT "+commentColumns+" WHERE c.issue_id = ? ORDER BY c.created_at, c.id", issueID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %v", err)
+	}
+	defer rows.Close()
+
+	var comments []types.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment row: %v", err)
+		}
+		comments = append(comments, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return comments, nil
+}
+
+// UpdateComment stores the current body as a revision before replacing it.
+// The row is locked so concurrent edits each keep the body they replaced.
+func (s *Store) UpdateComment(id int, body string, editorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var issueID int
+	var previous string
+	err = tx.QueryRow("SELECT issue_id, body FROM comments WHERE id = ? AND deleted_at IS NULL FOR UPDATE", id).Scan(&issueID, &previous)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("comment not found")
+		}
+		return fmt.Errorf("failed to fetch comment: %v", err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO comment_revisions (comment_id, body, edited_by) VALUES (?, ?, ?)", id, previous, editorID); err != nil {
+		return fmt.Errorf("failed to insert comment revision: %v", err)
+	}
+
+	if _, err := tx.Exec("UPDATE comments SET body = ?, edited_at = NOW() WHERE id = ?", body, id); err != nil {
+		return fmt.Errorf("failed to update comment: %v", err)
+	}
+
+	if err := touchIssue(tx, issueID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteComment only marks the comment as deleted so its replies and
+// revisions stay in place.
+func (s *Store) DeleteComment(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var issueID int
+	err = tx.QueryRow("SELECT issue_id FROM comments WHERE id = ? AND deleted_at IS NULL FOR UPDATE", id).Scan(&issueID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("comment not found")
+		}
+		return fmt.Errorf("failed to fetch comment: %v", err)
+	}
+
+	if _, err := tx.Exec("UPDATE comments SET deleted_at = NOW() WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete comment: %v", err)
+	}
+
+	if err := touchIssue(tx, issueID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) GetCommentRevisions(commentID int) ([]types.CommentRevision, error) {
+	rows, err := s.db.Query("SELECT id, comment_id, body, COALESCE(edited_by, 0), created_at FROM comment_revisions WHERE comment_id = ? ORDER BY id", commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comment revisions: %v", err)
+	}
+	defer rows.Close()
+
+	var revisions []types.CommentRevision
+	for rows.Next() {
+		var rev types.CommentRevision
+		if err := rows.Scan(&rev.ID, &rev.CommentID, &rev.Body, &rev.EditedBy, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan comment revision row: %v", err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,173 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type Handler struct {
+	store types.ComponentStore
+	roles types.RoleStore
+}
+
+func NewHandler(store types.ComponentStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, roles: roles}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/projects/{key}/components", h.handleGetComponents).Methods("GET")
+	router.HandleFunc("/projects/{key}/components", h.handleCreateComponent).Methods("POST")
+	router.HandleFunc("/projects/{key}/components/{id}", h.handleUpdateComponent).Methods("PUT")
+	router.HandleFunc("/projects/{key}/components/{id}", h.handleDeleteComponent).Methods("DELETE")
+}
+
+func (h *Handler) handleGetComponents(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	components, err := h.store.GetComponents(projectKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"project_key": projectKey,
+		"components":  components,
+	})
+}
+
+func (h *Handler) handleCreateComponent(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageLabels) {
+		return
+	}
+
+	component, ok := h.parseComponent(w, r, projectKey)
+	if !ok {
+		return
+	}
+
+	id, err := h.store.CreateComponent(component)
+	if err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+	component.ID = id
+
+	utils.WriteJSON(w, http.StatusCreated, component)
+}
+
+func (h *Handler) handleUpdateComponent(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid component ID"))
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageLabels) {
+		return
+	}
+
+	component, ok := h.parseComponent(w, r, projectKey)
+	if !ok {
+		return
+	}
+	component.ID = id
+
+	if err := h.store.UpdateComponent(component); err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, component)
+}
+
+// handleDeleteComponent removes the component from the project and its
+// issues.
+func (h *Handler) handleDeleteComponent(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid component ID"))
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageLabels) {
+		return
+	}
+
+	if err := h.store.DeleteComponent(projectKey, id); err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Component deleted successfully",
+	})
+}
+
+// parseComponent reads a component of the project from the body. Names can't
+// contain commas, which separate them in issue filters, and the default
+// assignee has to be on the project.
+func (h *Handler) parseComponent(w http.ResponseWriter, r *http.Request, projectKey string) (types.Component, bool) {
+	var payload types.ComponentPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return types.Component{}, false
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return types.Component{}, false
+	}
+	if strings.Contains(payload.Name, ",") {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("component names can't contain commas"))
+		return types.Component{}, false
+	}
+
+	if payload.DefaultAssigneeID != 0 {
+		role, err := h.roles.GetUserRole(projectKey, payload.DefaultAssigneeID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return types.Component{}, false
+		}
+		if role == "" {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("default assignee %d is not a member of project %s", payload.DefaultAssigneeID, projectKey))
+			return types.Component{}, false
+		}
+	}
+
+	return types.Component{
+		ProjectKey:        projectKey,
+		Name:              payload.Name,
+		Description:       payload.Description,
+		DefaultAssigneeID: payload.DefaultAssigneeID,
+	}, true
+}
+
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrComponentExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrComponentNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
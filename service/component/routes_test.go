@@ -0,0 +1,183 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestComponentServiceHandlers(t *testing.T) {
+	store := &mockComponentStore{}
+	roles := &mockRoleStore{roles: map[int]map[string]string{
+		1: {"PRJ": "maintainer"},
+		2: {"PRJ": "member"},
+	}}
+	handler := NewHandler(store, roles)
+
+	t.Run("Create Component", func(t *testing.T) {
+		t.Run("should create a component with a default assignee", func(t *testing.T) {
+			payload := types.ComponentPayload{Name: " Backend ", Description: "API and jobs", DefaultAssigneeID: 2}
+			rr := testRequest(t, handler, 1, http.MethodPost, "/projects/PRJ/components", payload, http.StatusCreated)
+
+			var c types.Component
+			if err := json.NewDecoder(rr.Body).Decode(&c); err != nil {
+				t.Fatal(err)
+			}
+			if c.ID != 1 || c.Name != "Backend" || c.ProjectKey != "PRJ" || c.DefaultAssigneeID != 2 {
+				t.Errorf("unexpected component %+v", c)
+			}
+		})
+
+		t.Run("should fail if the name is taken", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/projects/PRJ/components", types.ComponentPayload{Name: "backend"}, http.StatusConflict)
+		})
+
+		t.Run("should fail if the name has a comma", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/projects/PRJ/components", types.ComponentPayload{Name: "API, jobs"}, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if the default assignee is not a member", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/projects/PRJ/components", types.ComponentPayload{Name: "Docs", DefaultAssigneeID: 3}, http.StatusBadRequest)
+		})
+
+		t.Run("should be forbidden for members", func(t *testing.T) {
+			testRequest(t, handler, 2, http.MethodPost, "/projects/PRJ/components", types.ComponentPayload{Name: "Docs"}, http.StatusForbidden)
+		})
+	})
+
+	t.Run("Update Component", func(t *testing.T) {
+		t.Run("should clear the default assignee", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPut, "/projects/PRJ/components/1", types.ComponentPayload{Name: "Backend"}, http.StatusOK)
+
+			if store.components[0].DefaultAssigneeID != 0 {
+				t.Errorf("expected no default assignee, got %d", store.components[0].DefaultAssigneeID)
+			}
+		})
+
+		t.Run("should fail if the component does not exist", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPut, "/projects/PRJ/components/9", types.ComponentPayload{Name: "Missing"}, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Get Components", func(t *testing.T) {
+		rr := testRequest(t, handler, 2, http.MethodGet, "/projects/PRJ/components", nil, http.StatusOK)
+
+		var resp struct {
+			Components []types.Component `json:"components"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Components) != 1 {
+			t.Errorf("expected one component, got %+v", resp.Components)
+		}
+	})
+
+	t.Run("Delete Component", func(t *testing.T) {
+		testRequest(t, handler, 1, http.MethodDelete, "/projects/PRJ/components/1", nil, http.StatusOK)
+		testRequest(t, handler, 1, http.MethodDelete, "/projects/PRJ/components/1", nil, http.StatusNotFound)
+	})
+}
+
+// testRequest - Helper function to perform HTTP requests as a user and check the response
+func testRequest(t testing.TB, handler *Handler, userID int, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body []byte
+	if payload != nil {
+		marshalled, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = marshalled
+	}
+
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, userID))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+		fmt.Printf("Response Body: %s\n", rr.Body.String())
+	}
+
+	return rr
+}
+
+// mockComponentStore - Mock implementation of the component store
+type mockComponentStore struct {
+	components []types.Component
+	nextID     int
+}
+
+func (m *mockComponentStore) GetComponents(projectKey string) ([]types.Component, error) {
+	var components []types.Component
+	for _, c := range m.components {
+		if c.ProjectKey == projectKey {
+			components = append(components, c)
+		}
+	}
+	return components, nil
+}
+
+func (m *mockComponentStore) CreateComponent(component types.Component) (int, error) {
+	for _, c := range m.components {
+		if c.ProjectKey == component.ProjectKey && strings.EqualFold(c.Name, component.Name) {
+			return 0, fmt.Errorf("%w: %s", ErrComponentExists, component.Name)
+		}
+	}
+	m.nextID++
+	component.ID = m.nextID
+	m.components = append(m.components, component)
+	return component.ID, nil
+}
+
+func (m *mockComponentStore) UpdateComponent(component types.Component) error {
+	for n, c := range m.components {
+		if c.ID == component.ID && c.ProjectKey == component.ProjectKey {
+			m.components[n] = component
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d", ErrComponentNotFound, component.ID)
+}
+
+func (m *mockComponentStore) DeleteComponent(projectKey string, id int) error {
+	for n, c := range m.components {
+		if c.ID == id && c.ProjectKey == projectKey {
+			m.components = append(m.components[:n], m.components[n+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d", ErrComponentNotFound, id)
+}
+
+// mockRoleStore - Mock implementation of the role store, roles per user and project
+type mockRoleStore struct {
+	roles map[int]map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[userID][projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,163 @@
+package component
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+var (
+	ErrComponentExists   = errors.New("component already exists")
+	ErrComponentNotFound = errors.New("component not found")
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetComponents(projectKey string) ([]types.Component, error) {
+	rows, err := s.db.Query(`
+        SELECT c.id, c.project_key, c.name, c.description, u.id, u.firstName, u.lastName, u.email,
+            (SELECT COUNT(*) FROM issue_components ic WHERE ic.component_id = c.id)
+        FROM components c
+        LEFT JOIN users u ON u.id = c.default_assignee_id
+        WHERE c.project_key = ?
+        ORDER BY c.name
+    `, projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query components: %v", err)
+	}
+	defer rows.Close()
+
+	components := []types.Component{}
+	for rows.Next() {
+		var c types.Component
+		var assigneeID sql.NullInt64
+		var firstName, lastName, email sql.NullString
+		err := rows.Scan(&c.ID, &c.ProjectKey, &c.Name, &c.Description, &assigneeID, &firstName, &lastName, &email, &c.IssueCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan component row: %v", err)
+		}
+		if assigneeID.Valid {
+			c.DefaultAssigneeID = int(assigneeID.Int64)
+			c.DefaultAssignee = &types.UserSummary{
+				ID:        c.DefaultAssigneeID,
+				FirstName: firstName.String,
+				LastName:  lastName.String,
+				Email:     email.String,
+			}
+		}
+		components = append(components, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return components, nil
+}
+
+func (s *Store) CreateComponent(component types.Component) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkName(tx, component); err != nil {
+		return 0, err
+	}
+
+	res, err := tx.Exec("INSERT INTO components (project_key, name, description, default_assignee_id) VALUES (?, ?, ?, ?)",
+		component.ProjectKey, component.Name, component.Description, nullableID(component.DefaultAssigneeID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert component: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get component id: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return int(id), nil
+}
+
+// UpdateComponent renames the component on all its issues as well, they
+// refer to it by id.
+func (s *Store) UpdateComponent(component types.Component) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow("SELECT id FROM components WHERE id = ? AND project_key = ? FOR UPDATE", component.ID, component.ProjectKey).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrComponentNotFound, component.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch component: %v", err)
+	}
+
+	if err := checkName(tx, component); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE components SET name = ?, description = ?, default_assignee_id = ? WHERE id = ?",
+		component.Name, component.Description, nullableID(component.DefaultAssigneeID), component.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update component: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteComponent(projectKey string, id int) error {
+	res, err := s.db.Exec("DELETE FROM components WHERE id = ? AND project_key = ?", id, projectKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete component: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check deleted rows: %v", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrComponentNotFound, id)
+	}
+	return nil
+}
+
+// checkName fails when another component of the project has the name. The
+// lock on the name keeps a concurrent create from taking it.
+func checkName(tx *sql.Tx, component types.Component) error {
+	var id int
+	err := tx.QueryRow("SELECT id FROM components WHERE project_key = ? AND name = ? FOR UPDATE", component.ProjectKey, component.Name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check component name: %v", err)
+	}
+	if id != component.ID {
+		return fmt.Errorf("%w: %s", ErrComponentExists, component.Name)
+	}
+	return nil
+}
+
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,466 @@
+package issue
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+	defaultSort     = "created"
+	overdueSort     = "due"
+)
+
+// sortColumns are the fields issue listings can be ordered by. The issue id
+// breaks ties, which keeps cursors stable. Ascending priority and severity
+// put the most urgent issues first, issues without a severity or due date
+// come last.
+var sortColumns = map[string]string{
+	"created":  "i.createdAt",
+	"updated":  "i.updatedAt",
+	"summary":  "i.summary",
+	"status":   "i.status",
+	"priority": "i.priority",
+	"severity": SeveritySort,
+	"due":      DueDateSort,
+}
+
+// ParseFilter reads an issue filter from the query string:
+//
+//	status, type        one or more values, comma separated or repeated
+//	exclude_type        one or more types to leave out, like sub-task
+//	assignee, reporter  user email
+//	sprint              sprint ID, or "none" for issues outside any sprint
+//	parent              parent issue ID, or "none" for issues without a parent
+//	created_after, created_before, updated_after, updated_before
+//	                    RFC 3339 time or date, after is inclusive and before is not
+//	q                   text in the key, summary or description
+//	label, component    one or more values, matching issues with any of them
+//	priority, severity  one or more values
+//	overdue             true for issues past their due date that are not done
+//	sort                created, updated, summary, status, priority, severity or
+//	                    due, - prefix for descending
+//	limit, cursor       page size and the next_cursor of the previous page
+func ParseFilter(r *http.Request) (types.IssueFilter, error) {
+	return parseFilter(r, defaultSort)
+}
+
+// ParseOverdueFilter reads a filter of the overdue issues, which are sorted
+// by due date unless the query asks otherwise.
+func ParseOverdueFilter(r *http.Request) (types.IssueFilter, error) {
+	filter, err := parseFilter(r, overdueSort)
+	filter.Overdue = true
+	return filter, err
+}
+
+func parseFilter(r *http.Request, defaultSort string) (types.IssueFilter, error) {
+	query := r.URL.Query()
+	filter := types.IssueFilter{
+		Statuses:     listParam(query["status"]),
+		IssueTypes:   listParam(query["type"]),
+		ExcludeTypes: listParam(query["exclude_type"]),
+		Labels:       listParam(query["label"]),
+		Components:   listParam(query["component"]),
+		Priorities:   listParam(query["priority"]),
+		Severities:   listParam(query["severity"]),
+		Assignee:     strings.TrimSpace(query.Get("assignee")),
+		Reporter:     strings.TrimSpace(query.Get("reporter")),
+		Text:         strings.TrimSpace(query.Get("q")),
+		Sort:         query.Get("sort"),
+		Cursor:       query.Get("cursor"),
+	}
+
+	ids := []struct {
+		name string
+		dest **int
+	}{
+		{"sprint", &filter.SprintID},
+		{"parent", &filter.ParentID},
+	}
+	for _, p := range ids {
+		value := query.Get(p.name)
+		if value == "" {
+			continue
+		}
+		id := 0
+		if value != "none" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return filter, fmt.Errorf("invalid %s %q", p.name, value)
+			}
+			id = n
+		}
+		*p.dest = &id
+	}
+
+	if overdue := query.Get("overdue"); overdue != "" {
+		value, err := strconv.ParseBool(overdue)
+		if err != nil {
+			return filter, fmt.Errorf("invalid overdue %q", overdue)
+		}
+		filter.Overdue = value
+	}
+
+	times := []struct {
+		name string
+		dest *time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+	}
+	for _, t := range times {
+		value := query.Get(t.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseTime(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s %q, expected an RFC 3339 time or a date", t.name, value)
+		}
+		*t.dest = parsed
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = defaultSort
+	}
+	if _, ok := sortColumns[strings.TrimPrefix(filter.Sort, "-")]; !ok {
+		return filter, fmt.Errorf("cannot sort by %q", filter.Sort)
+	}
+
+	filter.Limit = defaultPageSize
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageSize {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		filter.Limit = n
+	}
+
+	if filter.Cursor != "" {
+		if _, err := decodeCursor(filter.Cursor, filter.Sort); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// ListIssues returns a page of the issues matching both where, which limits
+// the listing to a project, scope or sprint, and the filter.
+func ListIssues(db *sql.DB, where string, whereArgs []any, filter types.IssueFilter) (*types.IssuePage, error) {
+	conds, args := filterConditions(filter)
+	conds = append([]string{where}, conds...)
+	args = append(append([]any{}, whereArgs...), args...)
+
+	page := &types.IssuePage{}
+	err := db.QueryRow("SELECT COUNT(*) "+issueFrom+" WHERE "+strings.Join(conds, " AND "), args...).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count issues: %v", err)
+	}
+
+	sort := filter.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	field := strings.TrimPrefix(sort, "-")
+	column, ok := sortColumns[field]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort by %q", sort)
+	}
+	direction, comparison := "ASC", ">"
+	if strings.HasPrefix(sort, "-") {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.Cursor != "" {
+		after, err := decodeCursor(filter.Cursor, sort)
+		if err != nil {
+			return nil, err
+		}
+		value, err := after.sortValue(field)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, fmt.Sprintf("(%s %s ? OR (%s = ? AND i.id %s ?))", column, comparison, column, comparison))
+		args = append(args, value, value, after.ID)
+	}
+
+	limit := filter.Limit
+	if limit < 1 || limit > maxPageSize {
+		limit = defaultPageSize
+	}
+
+	query := "SELECT " + issueColumns + " WHERE " + strings.Join(conds, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, i.id %s LIMIT %d", column, direction, direction, limit+1)
+	if page.Issues, err = queryIssues(db, query, args); err != nil {
+		return nil, err
+	}
+
+	// One row past the limit tells there is another page
+	if len(page.Issues) > limit {
+		page.Issues = page.Issues[:limit]
+		page.NextCursor = encodeCursor(sort, page.Issues[limit-1])
+	}
+
+	return page, nil
+}
+
+// QueryIssues returns a page of the issues matching a search. It counts all
+// matches, the page is limit issues after skipping offset of them.
+func QueryIssues(db *sql.DB, query types.IssueQuery, limit, offset int) (*types.IssuePage, error) {
+	page := &types.IssuePage{}
+	err := db.QueryRow("SELECT COUNT(*) "+issueFrom+" WHERE "+query.Where, query.Args...).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count issues: %v", err)
+	}
+
+	page.Issues, err = queryIssues(db, "SELECT "+issueColumns+" WHERE "+query.Where+
+		fmt.Sprintf(" ORDER BY %s LIMIT %d OFFSET %d", query.OrderBy, limit, offset), query.Args)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func queryIssues(db *sql.DB, query string, args []any) ([]types.Issue, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issues: %v", err)
+	}
+	defer rows.Close()
+
+	issues := []types.Issue{}
+	for rows.Next() {
+		i, err := scanIssue(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan issue row: %v", err)
+		}
+		issues = append(issues, *i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+	return issues, nil
+}
+
+// filterConditions returns the conditions of the filter on the issues
+// selected by issueFrom, without its sort and page.
+func filterConditions(filter types.IssueFilter) ([]string, []any) {
+	var conds []string
+	var args []any
+
+	if len(filter.Statuses) > 0 {
+		conds = append(conds, "i.status IN ("+placeholders(len(filter.Statuses))+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if len(filter.IssueTypes) > 0 {
+		conds = append(conds, "i.issueType IN ("+placeholders(len(filter.IssueTypes))+")")
+		for _, issueType := range filter.IssueTypes {
+			args = append(args, issueType)
+		}
+	}
+	if len(filter.ExcludeTypes) > 0 {
+		conds = append(conds, "i.issueType NOT IN ("+placeholders(len(filter.ExcludeTypes))+")")
+		for _, issueType := range filter.ExcludeTypes {
+			args = append(args, issueType)
+		}
+	}
+	if filter.Assignee != "" {
+		conds = append(conds, "COALESCE(a.email, i.assignee) = ?")
+		args = append(args, filter.Assignee)
+	}
+	if filter.Reporter != "" {
+		conds = append(conds, "COALESCE(r.email, i.reporter) = ?")
+		args = append(args, filter.Reporter)
+	}
+	if filter.SprintID != nil {
+		if *filter.SprintID == 0 {
+			conds = append(conds, "i.sprint_id IS NULL")
+		} else {
+			conds = append(conds, "i.sprint_id = ?")
+			args = append(args, *filter.SprintID)
+		}
+	}
+	if filter.ParentID != nil {
+		if *filter.ParentID == 0 {
+			conds = append(conds, "i.parent_id IS NULL")
+		} else {
+			conds = append(conds, "i.parent_id = ?")
+			args = append(args, *filter.ParentID)
+		}
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conds = append(conds, "i.createdAt >= ?")
+		args = append(args, filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conds = append(conds, "i.createdAt < ?")
+		args = append(args, filter.CreatedBefore)
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		conds = append(conds, "i.updatedAt >= ?")
+		args = append(args, filter.UpdatedAfter)
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		conds = append(conds, "i.updatedAt < ?")
+		args = append(args, filter.UpdatedBefore)
+	}
+	if filter.Text != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Text) + "%"
+		conds = append(conds, "(i.`key` LIKE ? OR i.summary LIKE ? OR i.description LIKE ?)")
+		args = append(args, pattern, pattern, pattern)
+	}
+	if len(filter.Labels) > 0 {
+		conds = append(conds, "EXISTS (SELECT 1 FROM issue_labels il JOIN labels l ON l.id = il.label_id WHERE il.issue_id = i.id AND l.name IN ("+placeholders(len(filter.Labels))+"))")
+		for _, label := range filter.Labels {
+			args = append(args, label)
+		}
+	}
+	if len(filter.Components) > 0 {
+		conds = append(conds, "EXISTS (SELECT 1 FROM issue_components ic JOIN components c ON c.id = ic.component_id WHERE ic.issue_id = i.id AND c.name IN ("+placeholders(len(filter.Components))+"))")
+		for _, component := range filter.Components {
+			args = append(args, component)
+		}
+	}
+
+	if len(filter.Priorities) > 0 {
+		conds = append(conds, "i.priority IN ("+placeholders(len(filter.Priorities))+")")
+		for _, priority := range filter.Priorities {
+			args = append(args, priority)
+		}
+	}
+	if len(filter.Severities) > 0 {
+		conds = append(conds, "i.severity IN ("+placeholders(len(filter.Severities))+")")
+		for _, severity := range filter.Severities {
+			args = append(args, severity)
+		}
+	}
+	if filter.Overdue {
+		conds = append(conds, "(i.due_date < CURDATE() AND NOT "+inCategory+")")
+		args = append(args, types.StatusCategoryDone)
+	}
+
+	return conds, args
+}
+
+func andConditions(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " AND " + strings.Join(conds, " AND ")
+}
+
+// cursor marks the last issue of a page by its sort value and id.
+type cursor struct {
+	Sort  string `json:"sort"`
+	Value string `json:"value"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(sort string, last types.Issue) string {
+	c := cursor{Sort: sort, ID: last.ID}
+	switch strings.TrimPrefix(sort, "-") {
+	case "created":
+		c.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	case "updated":
+		c.Value = last.UpdatedAt.Format(time.RFC3339Nano)
+	case "summary":
+		c.Value = last.Summary
+	case "status":
+		c.Value = last.Status
+	case "priority":
+		c.Value = last.Priority
+	case "severity":
+		c.Value = strconv.Itoa(severityRank(last.Severity))
+	case "due":
+		c.Value = last.DueDate
+		if c.Value == "" {
+			c.Value = noDueDate
+		}
+	}
+
+	encoded, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeCursor fails for cursors of a listing with another sort order, as
+// their position means nothing in this one.
+func decodeCursor(encoded, sort string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID < 1 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("cursor was made for sort %q", c.Sort)
+	}
+	if _, err := c.sortValue(strings.TrimPrefix(sort, "-")); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *cursor) sortValue(field string) (any, error) {
+	switch field {
+	case "created", "updated":
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		return t, nil
+	case "severity":
+		rank, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		return rank, nil
+	case "due":
+		if _, err := time.Parse(time.DateOnly, c.Value); err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+	}
+	return c.Value, nil
+}
+
+// listParam splits comma separated values of repeated parameters.
+func listParam(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
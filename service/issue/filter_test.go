@@ -0,0 +1,67 @@
+package issue
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestCursor(t *testing.T) {
+	last := types.Issue{ID: 7, Summary: "Login fails", UpdatedAt: time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)}
+
+	t.Run("should round trip the sort value", func(t *testing.T) {
+		c, err := decodeCursor(encodeCursor("-updated", last), "-updated")
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := c.sortValue("updated")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.ID != 7 || !value.(time.Time).Equal(last.UpdatedAt) {
+			t.Errorf("unexpected cursor %+v with value %v", c, value)
+		}
+	})
+
+	t.Run("should rank severities and put missing values last", func(t *testing.T) {
+		for _, tc := range []struct {
+			sort  string
+			issue types.Issue
+			value any
+		}{
+			{"severity", types.Issue{ID: 1, Severity: "critical"}, 1},
+			{"severity", types.Issue{ID: 1}, 5},
+			{"-due", types.Issue{ID: 1, DueDate: "2025-06-30"}, "2025-06-30"},
+			{"due", types.Issue{ID: 1}, noDueDate},
+			{"priority", types.Issue{ID: 1, Priority: "P1"}, "P1"},
+		} {
+			c, err := decodeCursor(encodeCursor(tc.sort, tc.issue), tc.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, err := c.sortValue(strings.TrimPrefix(tc.sort, "-"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != tc.value {
+				t.Errorf("expected %s value %v, got %v", tc.sort, tc.value, value)
+			}
+		}
+	})
+
+	t.Run("should refuse a cursor of another sort order", func(t *testing.T) {
+		if _, err := decodeCursor(encodeCursor("summary", last), "-summary"); err == nil {
+			t.Error("expected the cursor to be refused")
+		}
+	})
+
+	t.Run("should refuse a tampered cursor", func(t *testing.T) {
+		for _, encoded := range []string{"not base64!", "bm90IGpzb24", encodeCursor("updated", types.Issue{})} {
+			if _, err := decodeCursor(encoded, "updated"); err == nil {
+				t.Errorf("expected cursor %q to be refused", encoded)
+			}
+		}
+	})
+}
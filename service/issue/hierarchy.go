@@ -0,0 +1,234 @@
+package issue
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+var ErrInvalidParent = errors.New("invalid parent")
+
+// parentTypes lists the types each type of issue can sit under. Epics sit
+// at the top, and nothing sits under a sub-task.
+var parentTypes = map[string][]string{
+	types.IssueTypeStory:   {types.IssueTypeEpic},
+	types.IssueTypeTask:    {types.IssueTypeEpic},
+	types.IssueTypeBug:     {types.IssueTypeEpic},
+	types.IssueTypeSubTask: {types.IssueTypeStory, types.IssueTypeTask},
+}
+
+// AllowedParent tells whether an issue of childType can sit under an issue
+// of parentType.
+func AllowedParent(childType, parentType string) bool {
+	for _, t := range parentTypes[childType] {
+		if t == parentType {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckHierarchy checks what can be told about the place of an issue in the
+// hierarchy without its parent, the store checks the rest.
+func CheckHierarchy(issue types.Issue) error {
+	if issue.IssueType == types.IssueTypeSubTask && issue.ParentID == 0 {
+		return fmt.Errorf("%w, sub-tasks need a parent", ErrInvalidParent)
+	}
+	if issue.ParentID != 0 && len(parentTypes[issue.IssueType]) == 0 {
+		return fmt.Errorf("%w, a %s can't have a parent", ErrInvalidParent, issue.IssueType)
+	}
+	if issue.ParentID != 0 && issue.ParentID == issue.ID {
+		return fmt.Errorf("%w, an issue can't be its own parent", ErrInvalidParent)
+	}
+	if issue.DeriveStatus && issue.IssueType != types.IssueTypeEpic {
+		return fmt.Errorf("only epics can derive their status")
+	}
+	return nil
+}
+
+// checkParent makes sure the parent is in the same project and of a type
+// the issue can sit under. The parent is share locked, so it can't change
+// type or project before the issue is saved.
+func checkParent(tx *sql.Tx, issue types.Issue) error {
+	if err := CheckHierarchy(issue); err != nil {
+		return err
+	}
+	if issue.ParentID == 0 {
+		return nil
+	}
+
+	var parentType, parentProject string
+	err := tx.QueryRow("SELECT issueType, project_key FROM issues WHERE id = ? FOR SHARE", issue.ParentID).Scan(&parentType, &parentProject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w, issue %d not found", ErrInvalidParent, issue.ParentID)
+		}
+		return fmt.Errorf("failed to fetch parent: %v", err)
+	}
+	if parentProject != issue.ProjectKey {
+		return fmt.Errorf("%w, issue %d belongs to project %s", ErrInvalidParent, issue.ParentID, parentProject)
+	}
+	if !AllowedParent(issue.IssueType, parentType) {
+		return fmt.Errorf("%w, a %s can't sit under a %s", ErrInvalidParent, issue.IssueType, parentType)
+	}
+	return nil
+}
+
+// checkChildren makes sure the children of an issue can stay under it after
+// its type or project changes.
+func checkChildren(tx *sql.Tx, issue types.Issue) error {
+	rows, err := tx.Query("SELECT `key`, issueType, project_key FROM issues WHERE parent_id = ? FOR SHARE", issue.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch children: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, childType, childProject string
+		if err := rows.Scan(&key, &childType, &childProject); err != nil {
+			return fmt.Errorf("failed to scan child: %v", err)
+		}
+		if childProject != issue.ProjectKey {
+			return fmt.Errorf("%w, move child %s to project %s first", ErrInvalidParent, key, issue.ProjectKey)
+		}
+		if !AllowedParent(childType, issue.IssueType) {
+			return fmt.Errorf("%w, child %s can't sit under a %s", ErrInvalidParent, key, issue.IssueType)
+		}
+	}
+	return rows.Err()
+}
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// childCategories counts the children of an issue by the category of their
+// status, a status missing from the workflow counts as to do.
+func childCategories(q querier, issueID int) (map[string]int, int, error) {
+	rows, err := q.Query(`
+		SELECT COALESCE(s.category, ?), COUNT(*)
+		FROM issues c
+		LEFT JOIN workflow_statuses s ON s.project_key = c.project_key AND s.name = c.status
+		WHERE c.parent_id = ?
+		GROUP BY 1`, types.StatusCategoryTodo, issueID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch child statuses: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	total := 0
+	for rows.Next() {
+		var category string
+		var count int
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan child statuses: %v", err)
+		}
+		counts[category] += count
+		total += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error after iterating child statuses: %v", err)
+	}
+	return counts, total, nil
+}
+
+// deriveCategory returns the category an epic takes from its children:
+// done once they all are, in progress once any of them has started and to
+// do otherwise. A blocked epic stays in progress until its blockers are
+// done, like it couldn't be moved to done by hand.
+func deriveCategory(counts map[string]int, total int, blocked bool) string {
+	switch {
+	case counts[types.StatusCategoryDone] == total && !blocked:
+		return types.StatusCategoryDone
+	case counts[types.StatusCategoryDone] > 0 || counts[types.StatusCategoryInProgress] > 0:
+		return types.StatusCategoryInProgress
+	}
+	return types.StatusCategoryTodo
+}
+
+// derivedStatus returns the status an epic takes from its children, see
+// deriveCategory. A status already in that category is kept, else the first
+// one of the workflow is taken. Epics without children keep their status.
+func derivedStatus(tx *sql.Tx, epicID int, projectKey, status, category string) (string, string, error) {
+	counts, total, err := childCategories(tx, epicID)
+	if err != nil || total == 0 {
+		return status, category, err
+	}
+
+	blocked := false
+	if counts[types.StatusCategoryDone] == total {
+		if err := checkBlockers(tx, epicID); errors.Is(err, ErrOpenBlockers) {
+			blocked = true
+		} else if err != nil {
+			return "", "", err
+		}
+	}
+
+	derived := deriveCategory(counts, total, blocked)
+	if derived == category {
+		return status, category, nil
+	}
+
+	var name string
+	err = tx.QueryRow("SELECT name FROM workflow_statuses WHERE project_key = ? AND category = ? ORDER BY position, name LIMIT 1", projectKey, derived).Scan(&name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The workflow has no status to move to
+			return status, category, nil
+		}
+		return "", "", fmt.Errorf("failed to fetch derived status: %v", err)
+	}
+	return name, derived, nil
+}
+
+// updateDerivedStatus moves an epic that derives its status to the status
+// of its children, recording the change as made by the actor whose change
+// to a child caused it. Moving into progress skips the WIP limit, the
+// children doing the work already count towards it and deriving epics are
+// left out of the count.
+func updateDerivedStatus(tx *sql.Tx, epicID, actorID int) error {
+	var issueType, projectKey, status string
+	var derive bool
+	err := tx.QueryRow("SELECT issueType, project_key, status, derive_status FROM issues WHERE id = ? FOR UPDATE", epicID).Scan(&issueType, &projectKey, &status, &derive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch parent: %v", err)
+	}
+	if issueType != types.IssueTypeEpic || !derive {
+		return nil
+	}
+
+	_, category, err := lookupStatus(tx, projectKey, status)
+	if err != nil && !errors.Is(err, ErrUnknownStatus) {
+		return err
+	}
+	newStatus, newCategory, err := derivedStatus(tx, epicID, projectKey, status, category)
+	if err != nil || newStatus == status {
+		return err
+	}
+
+	query := "UPDATE issues SET status = ?, version = version + 1, updatedAt = NOW()"
+	if newCategory == types.StatusCategoryInProgress && category != types.StatusCategoryInProgress {
+		query += ", started_at = NOW()"
+	}
+	if newCategory == types.StatusCategoryDone {
+		query += ", finished_at = NOW()"
+	} else if category == types.StatusCategoryDone {
+		query += ", finished_at = NULL"
+	}
+	if _, err := tx.Exec(query+" WHERE id = ?", newStatus, epicID); err != nil {
+		return fmt.Errorf("failed to update parent status: %v", err)
+	}
+
+	_, err = tx.Exec("INSERT INTO issue_history (issue_id, actor_id, field, old_value, new_value) VALUES (?, ?, 'status', ?, ?)",
+		epicID, nullableID(actorID), status, newStatus)
+	if err != nil {
+		return fmt.Errorf("failed to record issue history: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package issue
+
+import (
+	"testing"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestHierarchy(t *testing.T) {
+	t.Run("should allow sub-tasks under stories and tasks, and those under epics", func(t *testing.T) {
+		allowed := [][2]string{{"sub-task", "story"}, {"sub-task", "task"}, {"story", "epic"}, {"task", "epic"}, {"bug", "epic"}}
+		for _, pair := range allowed {
+			if !AllowedParent(pair[0], pair[1]) {
+				t.Errorf("expected a %s to sit under a %s", pair[0], pair[1])
+			}
+		}
+
+		refused := [][2]string{{"sub-task", "epic"}, {"sub-task", "sub-task"}, {"story", "story"}, {"epic", "epic"}, {"story", "sub-task"}}
+		for _, pair := range refused {
+			if AllowedParent(pair[0], pair[1]) {
+				t.Errorf("expected a %s not to sit under a %s", pair[0], pair[1])
+			}
+		}
+	})
+
+	t.Run("should check the place of an issue without its parent", func(t *testing.T) {
+		valid := []types.Issue{
+			{IssueType: "epic", DeriveStatus: true},
+			{IssueType: "story", ParentID: 2},
+			{ID: 3, IssueType: "sub-task", ParentID: 2},
+		}
+		for _, issue := range valid {
+			if err := CheckHierarchy(issue); err != nil {
+				t.Errorf("expected %+v to be valid, got %v", issue, err)
+			}
+		}
+
+		invalid := []types.Issue{
+			{IssueType: "sub-task"},
+			{IssueType: "epic", ParentID: 2},
+			{ID: 2, IssueType: "story", ParentID: 2},
+			{IssueType: "story", DeriveStatus: true},
+		}
+		for _, issue := range invalid {
+			if err := CheckHierarchy(issue); err == nil {
+				t.Errorf("expected %+v to be invalid", issue)
+			}
+		}
+	})
+
+	t.Run("should derive the category of an epic from its children", func(t *testing.T) {
+		todo, inProgress, done := types.StatusCategoryTodo, types.StatusCategoryInProgress, types.StatusCategoryDone
+
+		if got := deriveCategory(map[string]int{todo: 2}, 2, false); got != todo {
+			t.Errorf("expected %s with no child started, got %s", todo, got)
+		}
+		if got := deriveCategory(map[string]int{todo: 1, inProgress: 1}, 2, false); got != inProgress {
+			t.Errorf("expected %s with a child started, got %s", inProgress, got)
+		}
+		if got := deriveCategory(map[string]int{todo: 1, done: 1}, 2, false); got != inProgress {
+			t.Errorf("expected %s with a child done, got %s", inProgress, got)
+		}
+		if got := deriveCategory(map[string]int{done: 2}, 2, false); got != done {
+			t.Errorf("expected %s with all children done, got %s", done, got)
+		}
+	})
+
+	t.Run("should keep a blocked epic in progress when its last child is done", func(t *testing.T) {
+		done := types.StatusCategoryDone
+		if got := deriveCategory(map[string]int{done: 2}, 2, true); got != types.StatusCategoryInProgress {
+			t.Errorf("expected %s, got %s", types.StatusCategoryInProgress, got)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package issue
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// diffIssues returns a change for every tracked field that differs between
+// the stored issue and its update. The reporter and assignee are compared by
+// user when both sides know it, so a user changing their email is no change.
+func diffIssues(old, updated types.Issue) []types.IssueChange {
+	var changes []types.IssueChange
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, types.IssueChange{IssueID: old.ID, Field: field, OldValue: from, NewValue: to})
+		}
+	}
+
+	add("summary", old.Summary, updated.Summary)
+	add("description", old.Description, updated.Description)
+	add("project", old.ProjectKey, updated.ProjectKey)
+
+	if old.ReporterID == 0 || updated.ReporterID == 0 || old.ReporterID != updated.ReporterID {
+		add("reporter", old.Reporter, updated.Reporter)
+	}
+	if old.AssigneeID == 0 || updated.AssigneeID == 0 || old.AssigneeID != updated.AssigneeID {
+		add("assignee", old.Assignee, updated.Assignee)
+	}
+
+	add("status", old.Status, updated.Status)
+	add("issueType", old.IssueType, updated.IssueType)
+	add("sprint_id", idValue(old.SprintID), idValue(updated.SprintID))
+	add("parent_id", idValue(old.ParentID), idValue(updated.ParentID))
+	add("derive_status", strconv.FormatBool(old.DeriveStatus), strconv.FormatBool(updated.DeriveStatus))
+	add("labels", strings.Join(old.Labels, ", "), strings.Join(updated.Labels, ", "))
+	add("components", strings.Join(old.Components, ", "), strings.Join(updated.Components, ", "))
+	add("priority", old.Priority, updated.Priority)
+	add("severity", old.Severity, updated.Severity)
+	add("due_date", old.DueDate, updated.DueDate)
+	add("story_points", pointsString(old.StoryPoints), pointsString(updated.StoryPoints))
+	add("original_estimate", estimateString(old.OriginalEstimate), estimateString(updated.OriginalEstimate))
+	add("remaining_estimate", estimateString(old.RemainingEstimate), estimateString(updated.RemainingEstimate))
+
+	return changes
+}
+
+// idValue records an issue outside any sprint or without a parent as an
+// empty value.
+func idValue(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+// pointsString and estimateString record a missing estimate as an empty
+// value, estimates in minutes.
+func pointsString(points *float64) string {
+	if points == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*points, 'f', -1, 64)
+}
+
+func estimateString(minutes *int) string {
+	if minutes == nil {
+		return ""
+	}
+	return strconv.Itoa(*minutes)
+}
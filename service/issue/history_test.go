@@ -0,0 +1,54 @@
+package issue
+
+import (
+	"testing"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestDiffIssues(t *testing.T) {
+	old := types.Issue{
+		ID:          1,
+		Summary:     "Login fails",
+		Description: "Steps",
+		ProjectKey:  "PRJ",
+		Reporter:    "old@example.com",
+		ReporterID:  1,
+		Assignee:    "dev@example.com",
+		AssigneeID:  2,
+		Status:      "open",
+		IssueType:   "bug",
+	}
+
+	t.Run("should find nothing for an identical issue", func(t *testing.T) {
+		if changes := diffIssues(old, old); len(changes) != 0 {
+			t.Errorf("expected no changes, got %+v", changes)
+		}
+	})
+
+	t.Run("should ignore a changed email of the same user", func(t *testing.T) {
+		updated := old
+		updated.Reporter = "new@example.com"
+		if changes := diffIssues(old, updated); len(changes) != 0 {
+			t.Errorf("expected no changes, got %+v", changes)
+		}
+	})
+
+	t.Run("should record every changed field", func(t *testing.T) {
+		updated := old
+		updated.Status = "in_progress"
+		updated.Assignee = "other@example.com"
+		updated.AssigneeID = 3
+
+		changes := diffIssues(old, updated)
+		if len(changes) != 2 {
+			t.Fatalf("expected 2 changes, got %+v", changes)
+		}
+		if changes[0].Field != "assignee" || changes[0].OldValue != "dev@example.com" || changes[0].NewValue != "other@example.com" {
+			t.Errorf("unexpected assignee change %+v", changes[0])
+		}
+		if changes[1].Field != "status" || changes[1].OldValue != "open" || changes[1].NewValue != "in_progress" || changes[1].IssueID != 1 {
+			t.Errorf("unexpected status change %+v", changes[1])
+		}
+	})
+}
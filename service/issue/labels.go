@@ -0,0 +1,135 @@
+package issue
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the longest label or component name.
+const MaxNameLength = 64
+
+var ErrUnknownComponent = errors.New("unknown component")
+
+// NormalizeLabel trims a label and checks it. Labels are single words, so
+// they can't contain spaces, commas or slashes, which keeps them usable in
+// filters and URLs.
+func NormalizeLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return "", fmt.Errorf("labels can't be empty")
+	}
+	if utf8.RuneCountInString(label) > MaxNameLength {
+		return "", fmt.Errorf("label %q is longer than %d characters", label, MaxNameLength)
+	}
+	if strings.IndexFunc(label, func(r rune) bool { return unicode.IsSpace(r) || r == ',' || r == '/' }) >= 0 {
+		return "", fmt.Errorf("label %q can't contain spaces, commas or slashes", label)
+	}
+	return label, nil
+}
+
+// NormalizeLabels checks every label and sorts them, dropping duplicates
+// that only differ in case like the database does.
+func NormalizeLabels(labels []string) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label, err := NormalizeLabel(label)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, label)
+	}
+	return sortNames(normalized), nil
+}
+
+// NormalizeComponents trims and sorts component names, whether they exist is
+// up to the store.
+func NormalizeComponents(components []string) ([]string, error) {
+	normalized := make([]string, 0, len(components))
+	for _, component := range components {
+		component = strings.TrimSpace(component)
+		if component == "" {
+			return nil, fmt.Errorf("component names can't be empty")
+		}
+		normalized = append(normalized, component)
+	}
+	return sortNames(normalized), nil
+}
+
+func sortNames(names []string) []string {
+	sort.SliceStable(names, func(a, b int) bool {
+		return strings.ToLower(names[a]) < strings.ToLower(names[b])
+	})
+
+	unique := names[:0]
+	for _, name := range names {
+		if len(unique) == 0 || !strings.EqualFold(unique[len(unique)-1], name) {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// setLabels replaces the labels of an issue, creating the ones its project
+// doesn't have yet.
+func setLabels(tx *sql.Tx, issueID int, projectKey string, labels []string) error {
+	if _, err := tx.Exec("DELETE FROM issue_labels WHERE issue_id = ?", issueID); err != nil {
+		return fmt.Errorf("failed to clear labels: %v", err)
+	}
+
+	for _, label := range labels {
+		res, err := tx.Exec("INSERT INTO labels (project_key, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)", projectKey, label)
+		if err != nil {
+			return fmt.Errorf("failed to create label: %v", err)
+		}
+		labelID, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get label id: %v", err)
+		}
+		if _, err := tx.Exec("INSERT INTO issue_labels (issue_id, label_id) VALUES (?, ?)", issueID, labelID); err != nil {
+			return fmt.Errorf("failed to add label: %v", err)
+		}
+	}
+	return nil
+}
+
+// setComponents replaces the components of an issue, they have to exist in
+// its project.
+func setComponents(tx *sql.Tx, issueID int, projectKey string, components []string) error {
+	if _, err := tx.Exec("DELETE FROM issue_components WHERE issue_id = ?", issueID); err != nil {
+		return fmt.Errorf("failed to clear components: %v", err)
+	}
+
+	for _, component := range components {
+		var componentID int
+		err := tx.QueryRow("SELECT id FROM components WHERE project_key = ? AND name = ? FOR SHARE", projectKey, component).Scan(&componentID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w %s in project %s", ErrUnknownComponent, component, projectKey)
+			}
+			return fmt.Errorf("failed to fetch component: %v", err)
+		}
+		if _, err := tx.Exec("INSERT INTO issue_components (issue_id, component_id) VALUES (?, ?)", issueID, componentID); err != nil {
+			return fmt.Errorf("failed to add component: %v", err)
+		}
+	}
+	return nil
+}
+
+// issueNames reads the names of the labels or components of an issue that
+// issueColumns aggregates into a JSON array, NULL when there are none.
+func issueNames(aggregated sql.NullString) ([]string, error) {
+	names := []string{}
+	if !aggregated.Valid {
+		return names, nil
+	}
+	if err := json.Unmarshal([]byte(aggregated.String), &names); err != nil {
+		return nil, err
+	}
+	return sortNames(names), nil
+}
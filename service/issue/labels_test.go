@@ -0,0 +1,27 @@
+package issue
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLabels(t *testing.T) {
+	t.Run("should trim, sort and drop duplicates ignoring case", func(t *testing.T) {
+		labels, err := NormalizeLabels([]string{"ui ", "Backend", "UI", "api"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(labels, []string{"api", "Backend", "ui"}) {
+			t.Errorf("unexpected labels %v", labels)
+		}
+	})
+
+	t.Run("should refuse labels filters can't express", func(t *testing.T) {
+		for _, label := range []string{"", "two words", "a,b", "a/b", strings.Repeat("a", MaxNameLength+1)} {
+			if _, err := NormalizeLabels([]string{label}); err == nil {
+				t.Errorf("expected label %q to be refused", label)
+			}
+		}
+	})
+}
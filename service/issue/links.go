@@ -0,0 +1,145 @@
+package issue
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+var ErrOpenBlockers = errors.New("issue has open blockers")
+
+// linkDescriptions reads each link type from its outward and inward side.
+var linkDescriptions = map[string][2]string{
+	types.LinkBlocks:     {"blocks", "is blocked by"},
+	types.LinkDuplicates: {"duplicates", "is duplicated by"},
+	types.LinkRelates:    {"relates to", "relates to"},
+	types.LinkClones:     {"clones", "is cloned by"},
+}
+
+// LinkDescription reads a link from the side of the given direction.
+func LinkDescription(linkType, direction string) string {
+	descriptions := linkDescriptions[linkType]
+	if direction == "inward" {
+		return descriptions[1]
+	}
+	return descriptions[0]
+}
+
+// LinkedIssueColumns selects a linked issue of issues i, joined with its
+// status by LinkedIssueStatus.
+const LinkedIssueColumns = "i.id, i.`key`, i.summary, i.project_key, i.status, COALESCE(s.category, '')"
+
+const LinkedIssueStatus = "LEFT JOIN workflow_statuses s ON s.project_key = i.project_key AND s.name = i.status"
+
+// ScanLinkedIssue reads dest followed by the columns of LinkedIssueColumns.
+func ScanLinkedIssue(row scanner, dest ...any) (types.LinkedIssue, error) {
+	var i types.LinkedIssue
+	err := row.Scan(append(dest, &i.ID, &i.Key, &i.Summary, &i.ProjectKey, &i.Status, &i.StatusCategory)...)
+	return i, err
+}
+
+// GetLinks returns the links of an issue, outward links first.
+func GetLinks(db *sql.DB, issueID int) ([]types.IssueLink, error) {
+	rows, err := db.Query("SELECT l.id, l.link_type, l.direction, l.created_at, "+LinkedIssueColumns+
+		" FROM issue_links l JOIN issues i ON i.id = l.linked_issue_id "+LinkedIssueStatus+
+		" WHERE l.issue_id = ? ORDER BY l.direction DESC, l.link_type, i.id", issueID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issue links: %v", err)
+	}
+	defer rows.Close()
+
+	links := []types.IssueLink{}
+	for rows.Next() {
+		var l types.IssueLink
+		var err error
+		l.Issue, err = ScanLinkedIssue(rows, &l.ID, &l.Type, &l.Direction, &l.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan issue link: %v", err)
+		}
+		l.Description = LinkDescription(l.Type, l.Direction)
+		links = append(links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating issue links: %v", err)
+	}
+
+	return links, nil
+}
+
+// VisibleLinks drops the links to issues the user can't see, linked issues
+// may belong to other projects of a scope.
+func VisibleLinks(links []types.IssueLink, canView func(projectKey string) (bool, error)) ([]types.IssueLink, error) {
+	visible := []types.IssueLink{}
+	for _, l := range links {
+		ok, err := canView(l.Issue.ProjectKey)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			visible = append(visible, l)
+		}
+	}
+	return visible, nil
+}
+
+// checkBlockers fails when an issue is blocked by issues that are not done.
+func checkBlockers(tx *sql.Tx, issueID int) error {
+	rows, err := tx.Query("SELECT i.`key` FROM issue_links l JOIN issues i ON i.id = l.linked_issue_id "+
+		"WHERE l.issue_id = ? AND l.link_type = ? AND l.direction = 'inward' AND NOT "+inCategory+" ORDER BY i.id",
+		issueID, types.LinkBlocks, types.StatusCategoryDone)
+	if err != nil {
+		return fmt.Errorf("failed to fetch blockers: %v", err)
+	}
+	defer rows.Close()
+
+	var blockers []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return fmt.Errorf("failed to scan blocker: %v", err)
+		}
+		blockers = append(blockers, key)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error after iterating blockers: %v", err)
+	}
+
+	if len(blockers) > 0 {
+		return fmt.Errorf("%w, it is blocked by %s", ErrOpenBlockers, strings.Join(blockers, ", "))
+	}
+	return nil
+}
+
+// updateBlockedEpics derives the status of the epics an issue blocks again,
+// once it is done they may be too.
+func updateBlockedEpics(tx *sql.Tx, issueID, actorID int) error {
+	rows, err := tx.Query("SELECT linked_issue_id FROM issue_links WHERE issue_id = ? AND link_type = ? AND direction = 'outward'",
+		issueID, types.LinkBlocks)
+	if err != nil {
+		return fmt.Errorf("failed to fetch blocked issues: %v", err)
+	}
+
+	var blocked []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan blocked issue: %v", err)
+		}
+		blocked = append(blocked, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error after iterating blocked issues: %v", err)
+	}
+
+	for _, id := range blocked {
+		if err := updateDerivedStatus(tx, id, actorID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
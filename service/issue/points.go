@@ -0,0 +1,130 @@
+package issue
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+var ErrInvalidPoints = errors.New("invalid story points")
+
+// ParsePointScale reads a point scale as stored with the project, like
+// 0,1,2,3,5,8.
+func ParsePointScale(stored string) ([]float64, error) {
+	scale := []float64{}
+	for _, field := range strings.Split(stored, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		points, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid point scale %q: %v", stored, err)
+		}
+		scale = append(scale, points)
+	}
+	return scale, nil
+}
+
+// FormatPointScale sorts the scale and drops repeated points, so it can be
+// stored with the project.
+func FormatPointScale(scale []float64) string {
+	scale = slices.Clone(scale)
+	slices.Sort(scale)
+	scale = slices.Compact(scale)
+
+	fields := make([]string, len(scale))
+	for i, points := range scale {
+		fields[i] = strconv.FormatFloat(points, 'f', -1, 64)
+	}
+	return strings.Join(fields, ",")
+}
+
+// CheckPoints makes sure the points are on the scale, no points are always
+// fine.
+func CheckPoints(scale []float64, points *float64) error {
+	if points == nil || len(scale) == 0 || slices.Contains(scale, *points) {
+		return nil
+	}
+	return fmt.Errorf("%w, %s is not on the point scale %s", ErrInvalidPoints, strconv.FormatFloat(*points, 'f', -1, 64), FormatPointScale(scale))
+}
+
+// checkPoints checks the points of an issue against the point scale of its
+// project.
+func checkPoints(tx *sql.Tx, projectKey string, points *float64) error {
+	if points == nil {
+		return nil
+	}
+
+	var stored string
+	err := tx.QueryRow("SELECT point_scale FROM projects WHERE project_key = ?", projectKey).Scan(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to fetch point scale: %v", err)
+	}
+	scale, err := ParsePointScale(stored)
+	if err != nil {
+		return err
+	}
+	return CheckPoints(scale, points)
+}
+
+// SumPoints adds up the estimates of the issues i matching where. Points of
+// issues in a done status count as done, the others as remaining.
+func SumPoints(db *sql.DB, where string, args []any) (*types.PointTotals, error) {
+	totals := &types.PointTotals{}
+	err := db.QueryRow(`
+		SELECT COUNT(*),
+			COUNT(*) - COUNT(i.story_points),
+			COALESCE(SUM(i.story_points), 0),
+			COALESCE(SUM(IF(`+inCategory+`, i.story_points, 0)), 0),
+			COALESCE(SUM(i.original_estimate), 0),
+			COALESCE(SUM(i.remaining_estimate), 0)
+		FROM issues i
+		WHERE `+where, append([]any{types.StatusCategoryDone}, args...)...).Scan(
+		&totals.Issues,
+		&totals.Unestimated,
+		&totals.Points,
+		&totals.DonePoints,
+		&totals.OriginalEstimate,
+		&totals.RemainingEstimate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum story points: %v", err)
+	}
+	totals.RemainingPoints = totals.Points - totals.DonePoints
+	return totals, nil
+}
+
+// nullablePoints and nullableEstimate store a missing estimate as NULL.
+func nullablePoints(points *float64) any {
+	if points == nil {
+		return nil
+	}
+	return *points
+}
+
+func nullableEstimate(minutes *int) any {
+	if minutes == nil {
+		return nil
+	}
+	return *minutes
+}
+
+func pointsValue(points sql.NullFloat64) *float64 {
+	if !points.Valid {
+		return nil
+	}
+	return &points.Float64
+}
+
+func estimateValue(minutes sql.NullInt64) *int {
+	if !minutes.Valid {
+		return nil
+	}
+	value := int(minutes.Int64)
+	return &value
+}
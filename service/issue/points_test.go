@@ -0,0 +1,54 @@
+package issue
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestPointScale(t *testing.T) {
+	t.Run("should read and write a point scale", func(t *testing.T) {
+		scale, err := ParsePointScale("0, 0.5,1,2,3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(scale, []float64{0, 0.5, 1, 2, 3}) {
+			t.Errorf("unexpected scale %v", scale)
+		}
+
+		if stored := FormatPointScale([]float64{8, 1, 0.5, 3, 1}); stored != "0.5,1,3,8" {
+			t.Errorf("expected a sorted scale without repeats, got %s", stored)
+		}
+	})
+
+	t.Run("should read an empty scale", func(t *testing.T) {
+		scale, err := ParsePointScale("")
+		if err != nil || len(scale) != 0 {
+			t.Errorf("expected an empty scale, got %v, %v", scale, err)
+		}
+	})
+
+	t.Run("should fail on a broken scale", func(t *testing.T) {
+		if _, err := ParsePointScale("1,two,3"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("should check points against the scale", func(t *testing.T) {
+		fibonacci := []float64{0, 1, 2, 3, 5, 8, 13, 21}
+		five, four := 5.0, 4.0
+
+		if err := CheckPoints(fibonacci, &five); err != nil {
+			t.Errorf("expected 5 to be on the scale, got %v", err)
+		}
+		if err := CheckPoints(fibonacci, &four); !errors.Is(err, ErrInvalidPoints) {
+			t.Errorf("expected 4 to be off the scale, got %v", err)
+		}
+		if err := CheckPoints(fibonacci, nil); err != nil {
+			t.Errorf("expected no points to be fine, got %v", err)
+		}
+		if err := CheckPoints(nil, &four); err != nil {
+			t.Errorf("expected an empty scale to allow any points, got %v", err)
+		}
+	})
+}
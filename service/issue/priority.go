@@ -0,0 +1,54 @@
+package issue
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// DefaultPriority is given to issues created without a priority.
+const DefaultPriority = "P2"
+
+// noDueDate sorts issues without a due date after all others.
+const noDueDate = "9999-12-31"
+
+// severities are ordered from the most to the least severe.
+var severities = []string{"critical", "major", "minor", "trivial"}
+
+// IsBug tells whether an issue type can have a severity.
+func IsBug(issueType string) bool {
+	return strings.EqualFold(issueType, "bug")
+}
+
+// CheckSeverity refuses a severity on anything but a bug, so changing the
+// type of a bug has to clear its severity as well.
+func CheckSeverity(issue types.Issue) error {
+	if issue.Severity != "" && !IsBug(issue.IssueType) {
+		return fmt.Errorf("severity can only be set on bugs, not on a %s", issue.IssueType)
+	}
+	return nil
+}
+
+// IsUrgent tells whether an issue has a priority that makes it age on the
+// board.
+func IsUrgent(priority string) bool {
+	return priority == "P0" || priority == "P1"
+}
+
+// severityRank orders severities like SeveritySort, no severity comes last.
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i + 1
+		}
+	}
+	return len(severities) + 1
+}
+
+// SeveritySort ranks the severity column of issues i the way severityRank
+// does, so the most severe issues come first in ascending order.
+var SeveritySort = "FIELD(i.severity, '" + strings.Join(severities, "', '") + "', '')"
+
+// DueDateSort orders issues i by due date, those without one last.
+const DueDateSort = "COALESCE(i.due_date, '" + noDueDate + "')"
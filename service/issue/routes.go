@@ -2,31 +2,47 @@ package issue
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 	"github.com/maximis3d/issue-tracking-system/utils"
 )
 
 type Handler struct {
-	store types.IssueStore
+	store      types.IssueStore
+	members    types.ProjectAssignmentStore
+	projects   types.ProjectStore
+	users      types.UserStore
+	components types.ComponentStore
+	index      types.SearchIndex
 }
 
-func NewHandler(store types.IssueStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.IssueStore, members types.ProjectAssignmentStore, projects types.ProjectStore, users types.UserStore, components types.ComponentStore, index types.SearchIndex) *Handler {
+	return &Handler{store: store, members: members, projects: projects, users: users, components: components, index: index}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/createIssue", h.handleCreateIssue).Methods("POST")
 	router.HandleFunc("/issues/{id}", h.handleUpdateIssue).Methods("PUT")
+	router.HandleFunc("/issues/{id}", h.handlePatchIssue).Methods("PATCH")
 	router.HandleFunc("/issue/{id}", h.handleGetIssueById).Methods("GET")
+	router.HandleFunc("/issues/{id}/history", h.handleGetIssueHistory).Methods("GET")
+	router.HandleFunc("/issues/{id}/children", h.handleGetChildren).Methods("GET")
+	router.HandleFunc("/issues/{id}/progress", h.handleGetProgress).Methods("GET")
 
 	router.HandleFunc("/issues/{key}", h.handleGetIssuesByProject).Methods("GET")
+	router.HandleFunc("/issues/{key}/overdue", h.handleGetOverdueIssues).Methods("GET")
+	router.HandleFunc("/board/{project_key}", h.handleGetBoard).Methods("GET")
 	router.HandleFunc("/cycle-time/{project_key}", h.handleGetAverageCycleTime).Methods("GET")
 	router.HandleFunc("/throughput/{project_key}", h.handleGetWeeklyThroughput).Methods("GET")
 }
@@ -44,32 +60,85 @@ func (h *Handler) handleCreateIssue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.RequireProjectPermission(w, r, h.members, issue.ProjectKey, auth.PermCreateIssue) {
+		return
+	}
+
 	newIssue := types.Issue{
-		Summary:     issue.Summary,
-		Description: issue.Description,
-		ProjectKey:  issue.ProjectKey,
-		Reporter:    issue.Reporter,
-		Assignee:    issue.Assignee,
-		Status:      issue.Status,
-		IssueType:   issue.IssueType,
+		Summary:      issue.Summary,
+		Description:  issue.Description,
+		ProjectKey:   issue.ProjectKey,
+		Reporter:     issue.Reporter,
+		Assignee:     issue.Assignee,
+		Status:       issue.Status,
+		IssueType:    issue.IssueType,
+		Labels:       issue.Labels,
+		Components:   issue.Components,
+		Priority:     issue.Priority,
+		Severity:     issue.Severity,
+		DueDate:      issue.DueDate,
+		ParentID:     issue.ParentID,
+		DeriveStatus: issue.DeriveStatus,
+
+		StoryPoints:       issue.StoryPoints,
+		OriginalEstimate:  issue.OriginalEstimate,
+		RemainingEstimate: issue.RemainingEstimate,
+	}
+	if newIssue.Priority == "" {
+		newIssue.Priority = DefaultPriority
+	}
+	if newIssue.RemainingEstimate == nil {
+		newIssue.RemainingEstimate = newIssue.OriginalEstimate
 	}
 
-	err := h.store.CreateIssue(newIssue)
+	if !checkFields(w, newIssue) {
+		return
+	}
+
+	if !normalizeNames(w, &newIssue) {
+		return
+	}
+
+	if newIssue.Assignee == "" && !h.defaultAssignee(w, &newIssue) {
+		return
+	}
+
+	if !h.resolveParticipants(w, &newIssue) {
+		return
+	}
+
+	issueID, err := h.store.CreateIssue(newIssue, auth.GetUserIDFromContext(r.Context()))
 
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		utils.WriteError(w, storeErrorStatus(err), err)
 		return
 	}
 
+	h.reindex(issueID)
+
 	utils.WriteJSON(w, http.StatusCreated, map[string]string{
 		"message": "Issue created successfully",
 	})
 }
 
+// handleUpdateIssue replaces the issue. The required fields have to be
+// sent, the others keep their current value when left out, so clients that
+// don't know about a field don't clear it. A field sent as null or empty is
+// cleared.
 func (h *Handler) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
-	var issue types.Issue
+	var body json.RawMessage
+	if err := utils.ParseJSON(r, &body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
 
-	if err := utils.ParseJSON(r, &issue); err != nil {
+	var issue types.Issue
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(body, &issue); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := json.Unmarshal(body, &present); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -94,26 +163,456 @@ func (h *Handler) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.RequireProjectPermission(w, r, h.members, existingIssue.ProjectKey, auth.PermEditIssue) {
+		return
+	}
+
+	// Moving an issue needs edit rights on the target project as well
+	if issue.ProjectKey != existingIssue.ProjectKey &&
+		!auth.RequireProjectPermission(w, r, h.members, issue.ProjectKey, auth.PermEditIssue) {
+		return
+	}
+
 	issue.ID = existingIssue.ID
+	keepAbsentFields(&issue, existingIssue, present)
+
+	// An issue always has a priority
+	if issue.Priority == "" {
+		issue.Priority = existingIssue.Priority
+	}
+	if !checkFields(w, issue) {
+		return
+	}
+
+	version, ok := requestVersion(w, r, issue.Version)
+	if !ok {
+		return
+	}
+	issue.Version = version
+
+	if !normalizeNames(w, &issue) {
+		return
+	}
+
+	if !h.resolveParticipants(w, &issue) {
+		return
+	}
+
+	if err := h.store.UpdateIssue(issue, auth.GetUserIDFromContext(r.Context())); err != nil {
+		h.writeUpdateError(w, r, issue.ID, err)
+		return
+	}
+
+	h.reindex(issue.ID)
+	h.writeUpdatedIssue(w, r, issue.ID)
+}
+
+// keepAbsentFields gives the optional fields left out of a full update
+// their current value.
+func keepAbsentFields(issue, current *types.Issue, present map[string]json.RawMessage) {
+	keep := func(field string) bool {
+		_, ok := present[field]
+		return !ok
+	}
+
+	if keep("sprint_id") {
+		issue.SprintID = current.SprintID
+	}
+	if keep("parent_id") {
+		issue.ParentID = current.ParentID
+	}
+	if keep("derive_status") {
+		issue.DeriveStatus = current.DeriveStatus
+	}
+	if keep("labels") {
+		issue.Labels = current.Labels
+	}
+	if keep("components") {
+		issue.Components = current.Components
+	}
+	if keep("priority") {
+		issue.Priority = current.Priority
+	}
+	if keep("severity") {
+		issue.Severity = current.Severity
+	}
+	if keep("due_date") {
+		issue.DueDate = current.DueDate
+	}
+	if keep("story_points") {
+		issue.StoryPoints = current.StoryPoints
+	}
+	if keep("original_estimate") {
+		issue.OriginalEstimate = current.OriginalEstimate
+	}
+	if keep("remaining_estimate") {
+		issue.RemainingEstimate = current.RemainingEstimate
+	}
+}
 
-	if err := h.store.UpdateIssue(issue); err != nil {
+// handlePatchIssue applies only the fields present in the payload, the
+// merged issue goes through the same checks as a full update.
+func (h *Handler) handlePatchIssue(w http.ResponseWriter, r *http.Request) {
+	issueID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid issue ID"))
+		return
+	}
+
+	var payload types.IssueUpdatePayload
+	if err := utils.ParseJSONStrict(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	existingIssue, err := h.store.GetIssueByID(issueID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("issue not found"))
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.members, existingIssue.ProjectKey, auth.PermEditIssue) {
+		return
+	}
+
+	var bodyVersion int
+	if payload.Version != nil {
+		bodyVersion = *payload.Version
+	}
+	version, ok := requestVersion(w, r, bodyVersion)
+	if !ok {
+		return
+	}
+
+	issue := applyUpdate(*existingIssue, payload)
+	issue.Version = version
+
+	// Moving an issue needs edit rights on the target project as well
+	if issue.ProjectKey != existingIssue.ProjectKey &&
+		!auth.RequireProjectPermission(w, r, h.members, issue.ProjectKey, auth.PermEditIssue) {
+		return
+	}
+
+	if err := utils.Validate.Struct(issue); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	if !checkFields(w, issue) {
+		return
+	}
+
+	if !normalizeNames(w, &issue) {
+		return
+	}
+
+	// Participants are checked again only when they or the project change, so
+	// a status change doesn't fail on an assignee who has since left
+	if payload.Reporter != nil || payload.Assignee != nil || payload.ProjectKey != nil {
+		if !h.resolveParticipants(w, &issue) {
+			return
+		}
+	}
+
+	if err := h.store.UpdateIssue(issue, auth.GetUserIDFromContext(r.Context())); err != nil {
+		h.writeUpdateError(w, r, issue.ID, err)
+		return
+	}
+
+	h.reindex(issue.ID)
+	h.writeUpdatedIssue(w, r, issue.ID)
+}
+
+// requestVersion returns the version of the issue the client edited, from the
+// If-Match header or else the version in the body. Updates without one are
+// refused, so a client can't overwrite changes it never saw.
+func requestVersion(w http.ResponseWriter, r *http.Request, bodyVersion int) (int, bool) {
+	if match := r.Header.Get("If-Match"); match != "" {
+		version, err := strconv.Atoi(strings.Trim(match, `"`))
+		if err != nil || version < 1 {
+			utils.WriteError(w, http.StatusPreconditionFailed, fmt.Errorf("If-Match %s does not name an issue version", match))
+			return 0, false
+		}
+		return version, true
+	}
+
+	if bodyVersion < 1 {
+		utils.WriteError(w, http.StatusPreconditionRequired, fmt.Errorf("an If-Match header or version is required"))
+		return 0, false
+	}
+	return bodyVersion, true
+}
+
+// writeUpdateError answers a stale update with the current state of the
+// issue, so the client can show what changed before retrying.
+func (h *Handler) writeUpdateError(w http.ResponseWriter, r *http.Request, issueID int, err error) {
+	if !errors.Is(err, ErrVersionConflict) {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	current, getErr := h.store.GetIssueByID(issueID)
+	if getErr == nil {
+		getErr = h.hideLinks(r, current)
+	}
+	if getErr != nil {
+		utils.WriteError(w, http.StatusInternalServerError, getErr)
+		return
+	}
+
+	w.Header().Set("ETag", etag(current.Version))
+	utils.WriteJSON(w, http.StatusPreconditionFailed, map[string]any{
+		"error": err.Error(),
+		"issue": current,
+	})
+}
+
+func (h *Handler) writeUpdatedIssue(w http.ResponseWriter, r *http.Request, issueID int) {
+	updated, err := h.store.GetIssueByID(issueID)
+	if err == nil {
+		err = h.hideLinks(r, updated)
+	}
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+	w.Header().Set("ETag", etag(updated.Version))
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
 		"message": "Issue updated successfully",
-		"issue":   issue,
+		"issue":   updated,
 	})
 }
 
+// hideLinks drops the links of the issue to issues the user can't see.
+func (h *Handler) hideLinks(r *http.Request, issue *types.Issue) error {
+	links, err := VisibleLinks(issue.Links, auth.ProjectViewer(h.members, auth.GetUserIDFromContext(r.Context())))
+	if err != nil {
+		return err
+	}
+	issue.Links = links
+	return nil
+}
+
+// reindex updates the search index after a write. The write already
+// succeeded, so a failure is only logged.
+func (h *Handler) reindex(issueID int) {
+	if err := h.index.IndexIssue(issueID); err != nil {
+		log.Printf("failed to index issue %d: %v", issueID, err)
+	}
+}
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// applyUpdate returns the issue with the fields present in the payload set.
+func applyUpdate(issue types.Issue, payload types.IssueUpdatePayload) types.Issue {
+	if payload.Summary != nil {
+		issue.Summary = *payload.Summary
+	}
+	if payload.Description != nil {
+		issue.Description = *payload.Description
+	}
+	if payload.ProjectKey != nil {
+		issue.ProjectKey = *payload.ProjectKey
+	}
+	if payload.Reporter != nil {
+		issue.Reporter = *payload.Reporter
+	}
+	if payload.Assignee != nil {
+		issue.Assignee = *payload.Assignee
+	}
+	if payload.Status != nil {
+		issue.Status = *payload.Status
+	}
+	if payload.IssueType != nil {
+		issue.IssueType = *payload.IssueType
+	}
+	if payload.SprintID != nil {
+		issue.SprintID = *payload.SprintID
+	}
+	if payload.ParentID != nil {
+		issue.ParentID = *payload.ParentID
+	}
+	if payload.DeriveStatus != nil {
+		issue.DeriveStatus = *payload.DeriveStatus
+	}
+	if payload.Labels != nil {
+		issue.Labels = *payload.Labels
+	}
+	if payload.Components != nil {
+		issue.Components = *payload.Components
+	}
+	if payload.Priority != nil {
+		issue.Priority = *payload.Priority
+	}
+	if payload.Severity != nil {
+		issue.Severity = *payload.Severity
+	}
+	if payload.DueDate != nil {
+		issue.DueDate = *payload.DueDate
+	}
+	if payload.StoryPoints != nil {
+		issue.StoryPoints = pointsOrNil(*payload.StoryPoints)
+	}
+	if payload.OriginalEstimate != nil {
+		issue.OriginalEstimate = estimateOrNil(*payload.OriginalEstimate)
+	}
+	if payload.RemainingEstimate != nil {
+		issue.RemainingEstimate = estimateOrNil(*payload.RemainingEstimate)
+	}
+	return issue
+}
+
+// pointsOrNil and estimateOrNil clear an estimate given as a negative
+// number.
+func pointsOrNil(points float64) *float64 {
+	if points < 0 {
+		return nil
+	}
+	return &points
+}
+
+func estimateOrNil(minutes int) *int {
+	if minutes < 0 {
+		return nil
+	}
+	return &minutes
+}
+
+// checkFields checks the fields that depend on each other, like the
+// severity and parent that depend on the type.
+func checkFields(w http.ResponseWriter, issue types.Issue) bool {
+	if err := CheckSeverity(issue); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+	if err := CheckHierarchy(issue); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// normalizeNames checks the labels and component names of the issue and puts
+// them in the order the store keeps them.
+func normalizeNames(w http.ResponseWriter, issue *types.Issue) bool {
+	labels, err := NormalizeLabels(issue.Labels)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+	components, err := NormalizeComponents(issue.Components)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+
+	issue.Labels = labels
+	issue.Components = components
+	return true
+}
+
+// defaultAssignee assigns a new issue without an assignee to the default
+// assignee of its first component that has one.
+func (h *Handler) defaultAssignee(w http.ResponseWriter, issue *types.Issue) bool {
+	components, err := h.components.GetComponents(issue.ProjectKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+
+	for _, name := range issue.Components {
+		for _, c := range components {
+			if strings.EqualFold(c.Name, name) && c.DefaultAssignee != nil {
+				issue.Assignee = c.DefaultAssignee.Email
+				return true
+			}
+		}
+	}
+
+	utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("assignee is required unless a component of the issue has a default assignee"))
+	return false
+}
+
+// storeErrorStatus reports statuses and components missing from the project,
+// sprints of other projects, parents out of the hierarchy and points off the
+// scale as bad requests, and changes the workflow, WIP limit or open blockers
+// don't allow as conflicts.
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownStatus), errors.Is(err, ErrInvalidSprint), errors.Is(err, ErrUnknownComponent), errors.Is(err, ErrInvalidParent), errors.Is(err, ErrInvalidPoints):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrWIPLimitReached), errors.Is(err, ErrOpenBlockers):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// resolveParticipants links the reporter and assignee emails of the issue to
+// users. The assignee has to be a member of the project the issue lives in,
+// the project lead counts as a member.
+func (h *Handler) resolveParticipants(w http.ResponseWriter, issue *types.Issue) bool {
+	reporter, err := h.users.GetUserByEmail(issue.Reporter)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown reporter %s", issue.Reporter))
+		return false
+	}
+
+	assignee, err := h.users.GetUserByEmail(issue.Assignee)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown assignee %s", issue.Assignee))
+		return false
+	}
+
+	project, err := h.projects.GetProjectByKey(issue.ProjectKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project %s not found", issue.ProjectKey))
+		return false
+	}
+
+	if project.ProjectLead != assignee.ID {
+		member, err := h.members.IsUserAssignedToProject(project.ID, assignee.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return false
+		}
+		if !member {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("assignee %s is not a member of project %s", issue.Assignee, issue.ProjectKey))
+			return false
+		}
+	}
+
+	issue.ReporterID = reporter.ID
+	issue.AssigneeID = assignee.ID
+	return true
+}
+
 func (h *Handler) handleGetIssuesByProject(w http.ResponseWriter, r *http.Request) {
 	// Extract the project key from URL parameters
 	vars := mux.Vars(r)
 	projectKey := vars["key"]
 
+	if !auth.RequireProjectPermission(w, r, h.members, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	filter, err := ParseFilter(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	// Fetch the issues for the given project from the store
-	issues, err := h.store.GetIssuesByProject(projectKey)
+	page, err := h.store.GetIssuesByProject(projectKey, filter)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// No issues found for the provided project key
@@ -127,9 +626,77 @@ func (h *Handler) handleGetIssuesByProject(w http.ResponseWriter, r *http.Reques
 
 	// Return the issues in a JSON response
 	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
-		"message":    "Issues fetched successfully",
-		"projectKey": projectKey,
-		"issues":     issues,
+		"message":     "Issues fetched successfully",
+		"projectKey":  projectKey,
+		"issues":      page.Issues,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// handleGetOverdueIssues lists the issues of the project past their due date
+// that are not done, the longest overdue first.
+func (h *Handler) handleGetOverdueIssues(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.members, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	filter, err := ParseOverdueFilter(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.store.GetIssuesByProject(projectKey, filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to fetch overdue issues for project %s: %v", projectKey, err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"message":     "Overdue issues fetched successfully",
+		"projectKey":  projectKey,
+		"issues":      page.Issues,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+const (
+	defaultAgingDays = 7
+	maxAgingDays     = 365
+)
+
+// handleGetBoard shows the project board, P0 and P1 issues in the same
+// status for more than aging_days days, 7 by default, are flagged as aging.
+func (h *Handler) handleGetBoard(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["project_key"]
+
+	if !auth.RequireProjectPermission(w, r, h.members, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	agingDays := defaultAgingDays
+	if value := r.URL.Query().Get("aging_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 || days > maxAgingDays {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("aging_days must be between 1 and %d", maxAgingDays))
+			return
+		}
+		agingDays = days
+	}
+
+	board, err := h.store.GetBoard(projectKey, time.Duration(agingDays)*24*time.Hour)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to fetch board for project %s: %v", projectKey, err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "Board fetched successfully",
+		"board":   board,
 	})
 }
 
@@ -150,6 +717,17 @@ func (h *Handler) handleGetIssueById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.RequireProjectPermission(w, r, h.members, issue.ProjectKey, auth.PermViewIssues) {
+		return
+	}
+
+	if err := h.hideLinks(r, issue); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(issue.Version))
+
 	// Adding cycle time to the response
 	utils.WriteJSON(w, http.StatusOK, map[string]any{
 		"message":   "Issue fetched successfully",
@@ -158,6 +736,95 @@ func (h *Handler) handleGetIssueById(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) handleGetIssueHistory(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.viewIssue(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.store.GetIssueHistory(issue.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if history == nil {
+		history = []types.IssueChange{}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"issue_id": issue.ID,
+		"key":      issue.Key,
+		"history":  history,
+	})
+}
+
+// handleGetChildren lists the children of an issue with their progress.
+func (h *Handler) handleGetChildren(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.viewIssue(w, r)
+	if !ok {
+		return
+	}
+
+	children, err := h.store.GetChildren(issue.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	progress, err := h.store.GetProgress(issue.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"issue_id": issue.ID,
+		"key":      issue.Key,
+		"children": children,
+		"progress": progress,
+	})
+}
+
+func (h *Handler) handleGetProgress(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.viewIssue(w, r)
+	if !ok {
+		return
+	}
+
+	progress, err := h.store.GetProgress(issue.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"issue_id": issue.ID,
+		"key":      issue.Key,
+		"progress": progress,
+	})
+}
+
+// viewIssue fetches the issue of the route for a user who can see its
+// project.
+func (h *Handler) viewIssue(w http.ResponseWriter, r *http.Request) (*types.Issue, bool) {
+	issueID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid issue ID"))
+		return nil, false
+	}
+
+	issue, err := h.store.GetIssueByID(issueID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("issue not found"))
+		return nil, false
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.members, issue.ProjectKey, auth.PermViewIssues) {
+		return nil, false
+	}
+	return issue, true
+}
+
 func (h *Handler) handleGetAverageCycleTime(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	projectKey := vars["project_key"]
@@ -166,7 +833,17 @@ func (h *Handler) handleGetAverageCycleTime(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	avgCycleTime, err := h.store.GetAverageCycleTime(projectKey)
+	if !auth.RequireProjectPermission(w, r, h.members, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	filter, err := ParseFilter(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	avgCycleTime, err := h.store.GetAverageCycleTime(projectKey, filter)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get average cycle time: %v", err), http.StatusInternalServerError)
 		return
@@ -198,7 +875,17 @@ func (h *Handler) handleGetWeeklyThroughput(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	data, err := h.store.GetWeeklyThroughput(projectKey)
+	if !auth.RequireProjectPermission(w, r, h.members, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	filter, err := ParseFilter(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	data, err := h.store.GetWeeklyThroughput(projectKey, filter)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get throughput: %v", err), http.StatusInternalServerError)
 		return
@@ -2,20 +2,42 @@ package issue
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
 func TestIssueServiceHandlers(t *testing.T) {
 	issueStore := newMockIssueStore()
-	handler := NewHandler(issueStore)
+	issueStore.pointScale = []float64{0, 1, 2, 3, 5, 8, 13}
+	members := &mockRoleStore{
+		roles:   map[string]string{"PRJ": "member", "VIEW": "viewer"},
+		members: map[int][]int{1: {2}},
+	}
+	projects := &mockProjectStore{projects: map[string]types.Project{
+		"PRJ":  {ID: 1, ProjectKey: "PRJ", ProjectLead: 1},
+		"VIEW": {ID: 2, ProjectKey: "VIEW", ProjectLead: 1},
+	}}
+	users := &mockUserStore{users: []types.User{
+		{ID: 1, Email: "reporter@example.com"},
+		{ID: 2, Email: "assignee@example.com"},
+		{ID: 3, Email: "outsider@example.com"},
+	}}
+	components := &mockComponentStore{components: []types.Component{
+		{ID: 1, ProjectKey: "PRJ", Name: "Backend", DefaultAssigneeID: 2, DefaultAssignee: &types.UserSummary{ID: 2, Email: "assignee@example.com"}},
+		{ID: 2, ProjectKey: "PRJ", Name: "Docs"},
+	}}
+	index := &mockSearchIndex{}
+	handler := NewHandler(issueStore, members, projects, users, components, index)
 
 	t.Run("Create Issue", func(t *testing.T) {
 		t.Run("should fail if payload is invalid", func(t *testing.T) {
@@ -42,6 +64,290 @@ func TestIssueServiceHandlers(t *testing.T) {
 				IssueType:   "bug",
 			}
 			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusCreated)
+
+			for _, issue := range issueStore.issues {
+				if issue.Summary == "Test Issue" && (issue.ReporterID != 1 || issue.AssigneeID != 2) {
+					t.Errorf("expected reporter 1 and assignee 2, got %d and %d", issue.ReporterID, issue.AssigneeID)
+				}
+				if issue.Summary == "Test Issue" && !slices.Contains(index.indexed, issue.ID) {
+					t.Errorf("expected issue %d to be indexed, got %v", issue.ID, index.indexed)
+				}
+			}
+		})
+
+		t.Run("should allow assigning the project lead", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Lead Issue",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "assignee@example.com",
+				Assignee:    "reporter@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusCreated)
+		})
+
+		t.Run("should sort labels and drop duplicates", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Labelled Issue",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+				Labels:      []string{"urgent", " Frontend", "URGENT"},
+				Components:  []string{"Docs"},
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusCreated)
+
+			for _, issue := range issueStore.issues {
+				if issue.Summary == "Labelled Issue" && !slices.Equal(issue.Labels, []string{"Frontend", "urgent"}) {
+					t.Errorf("unexpected labels %v", issue.Labels)
+				}
+			}
+		})
+
+		t.Run("should default the priority", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Dated Issue",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+				Severity:    "major",
+				DueDate:     "2025-06-30",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusCreated)
+
+			for _, issue := range issueStore.issues {
+				if issue.Summary == "Dated Issue" && (issue.Priority != "P2" || issue.Severity != "major" || issue.DueDate != "2025-06-30") {
+					t.Errorf("unexpected priority, severity or due date %+v", issue)
+				}
+			}
+		})
+
+		t.Run("should fail on an invalid priority or due date", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Invalid Priority",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+				Priority:    "P5",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+
+			payload.Priority = "P1"
+			payload.DueDate = "30/06/2025"
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should estimate the issue", func(t *testing.T) {
+			points, estimate := 5.0, 120
+			payload := types.IssuePayload{
+				Summary:          "Estimated Issue",
+				Description:      "Test Description",
+				ProjectKey:       "PRJ",
+				Reporter:         "reporter@example.com",
+				Assignee:         "assignee@example.com",
+				Status:           "open",
+				IssueType:        "story",
+				StoryPoints:      &points,
+				OriginalEstimate: &estimate,
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusCreated)
+
+			for _, issue := range issueStore.issues {
+				if issue.Summary != "Estimated Issue" {
+					continue
+				}
+				if issue.StoryPoints == nil || *issue.StoryPoints != 5 || issue.RemainingEstimate == nil || *issue.RemainingEstimate != 120 {
+					t.Errorf("expected 5 points and 120 minutes remaining, got %+v", issue)
+				}
+			}
+		})
+
+		t.Run("should fail on points off the scale or a negative estimate", func(t *testing.T) {
+			points, estimate := 4.0, -30
+			payload := types.IssuePayload{
+				Summary:     "Misestimated Issue",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "story",
+				StoryPoints: &points,
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+
+			payload.StoryPoints = nil
+			payload.OriginalEstimate = &estimate
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should fail on a severity for anything but a bug", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Severe Task",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "task",
+				Severity:    "critical",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should place issues in the hierarchy", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:      "Checkout Epic",
+				Description:  "Test Description",
+				ProjectKey:   "PRJ",
+				Reporter:     "reporter@example.com",
+				Assignee:     "assignee@example.com",
+				Status:       "open",
+				IssueType:    "epic",
+				DeriveStatus: true,
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusCreated)
+
+			var epicID int
+			for _, issue := range issueStore.issues {
+				if issue.Summary == "Checkout Epic" {
+					epicID = issue.ID
+				}
+			}
+
+			payload.Summary = "Checkout Story"
+			payload.IssueType = "story"
+			payload.DeriveStatus = false
+			payload.ParentID = epicID
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusCreated)
+
+			for _, issue := range issueStore.issues {
+				if issue.Summary == "Checkout Story" && issue.ParentID != epicID {
+					t.Errorf("expected parent %d, got %d", epicID, issue.ParentID)
+				}
+			}
+		})
+
+		t.Run("should fail on an issue out of the hierarchy", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Misplaced Issue",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "sub-task",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+
+			payload.IssueType = "epic"
+			payload.ParentID = 1
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+
+			payload.IssueType = "task"
+			payload.ParentID = 0
+			payload.DeriveStatus = true
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+
+			payload.IssueType = "feature"
+			payload.DeriveStatus = false
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should fail on a label with spaces", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Spaced Label",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+				Labels:      []string{"needs review"},
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should assign the default assignee of a component", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Component Issue",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+				Components:  []string{"Docs", "backend"},
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusCreated)
+
+			for _, issue := range issueStore.issues {
+				if issue.Summary == "Component Issue" && (issue.Assignee != "assignee@example.com" || issue.AssigneeID != 2) {
+					t.Errorf("expected the default assignee, got %s (%d)", issue.Assignee, issue.AssigneeID)
+				}
+			}
+		})
+
+		t.Run("should fail without an assignee or a default assignee", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Unassigned Issue",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+				Components:  []string{"Docs"},
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if the assignee is unknown", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Unknown Assignee",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "asignee@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if the reporter is unknown", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Unknown Reporter",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "nobody@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if the assignee is not a project member", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Outsider Issue",
+				Description: "Test Description",
+				ProjectKey:  "PRJ",
+				Reporter:    "reporter@example.com",
+				Assignee:    "outsider@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
 		})
 
 		t.Run("should fail if issue already exists", func(t *testing.T) {
@@ -55,10 +361,36 @@ func TestIssueServiceHandlers(t *testing.T) {
 				IssueType:   "bug",
 			}
 
-			issueStore.CreateIssue(payload)
+			issueStore.CreateIssue(payload, 0)
 
 			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusBadRequest)
 		})
+
+		t.Run("should be forbidden for viewers", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Viewer Issue",
+				Description: "Test Description",
+				ProjectKey:  "VIEW",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusForbidden)
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			payload := types.IssuePayload{
+				Summary:     "Other Issue",
+				Description: "Test Description",
+				ProjectKey:  "OTHER",
+				Reporter:    "reporter@example.com",
+				Assignee:    "assignee@example.com",
+				Status:      "open",
+				IssueType:   "bug",
+			}
+			testRequest(t, handler, http.MethodPost, "/createIssue", payload, http.StatusForbidden)
+		})
 	})
 
 	t.Run("Get Issue By ID", func(t *testing.T) {
@@ -75,12 +407,278 @@ func TestIssueServiceHandlers(t *testing.T) {
 				IssueType:   "bug",
 			}
 
-			issueStore.CreateIssue(payload)
+			issueStore.CreateIssue(payload, 0)
 
 			testRequest(t, handler, http.MethodGet, "/issue/1", nil, http.StatusOK)
 		})
 	})
 
+	t.Run("Update Issue", func(t *testing.T) {
+		issue := types.Issue{
+			ID:          1,
+			Key:         "PRJ-001",
+			Summary:     "Updated Issue",
+			Description: "Test Description",
+			ProjectKey:  "PRJ",
+			Reporter:    "reporter@example.com",
+			Assignee:    "assignee@example.com",
+			Status:      "open",
+			IssueType:   "bug",
+			Version:     1,
+			UpdatedAt:   time.Now(),
+		}
+
+		t.Run("should update the issue successfully", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", issue, http.StatusOK)
+		})
+
+		t.Run("should fail if the assignee is not a project member", func(t *testing.T) {
+			outsider := issue
+			outsider.Assignee = "outsider@example.com"
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", outsider, http.StatusBadRequest)
+
+			if issueStore.issues[1].Assignee != "assignee@example.com" {
+				t.Errorf("expected the assignee to be unchanged, got %s", issueStore.issues[1].Assignee)
+			}
+		})
+
+		t.Run("should record the changed fields with the actor", func(t *testing.T) {
+			moved := issue
+			moved.Status = "resolved"
+			moved.Version = issueStore.issues[1].Version
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", moved, http.StatusOK)
+
+			if index.indexed[len(index.indexed)-1] != 1 {
+				t.Errorf("expected the updated issue to be indexed, got %v", index.indexed)
+			}
+
+			history, _ := issueStore.GetIssueHistory(1)
+			last := history[len(history)-1]
+			if last.Field != "status" || last.OldValue != "open" || last.NewValue != "resolved" || last.ActorID != 1 {
+				t.Errorf("unexpected history entry %+v", last)
+			}
+
+			testRequest(t, handler, http.MethodGet, "/issues/1/history", nil, http.StatusOK)
+		})
+
+		t.Run("should fail if the workflow does not allow the transition", func(t *testing.T) {
+			reopened := issue
+			reopened.Status = "in_progress"
+			reopened.Version = issueStore.issues[1].Version
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", reopened, http.StatusConflict)
+
+			if issueStore.issues[1].Status != "resolved" {
+				t.Errorf("expected the status to be unchanged, got %s", issueStore.issues[1].Status)
+			}
+		})
+
+		t.Run("should fail to finish an issue with open blockers", func(t *testing.T) {
+			reopened := issue
+			reopened.Status = "open"
+			reopened.Version = issueStore.issues[1].Version
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", reopened, http.StatusOK)
+
+			issueStore.blockers = map[int][]string{1: {"PRJ-2"}}
+
+			resolved := issue
+			resolved.Status = "resolved"
+			resolved.Version = issueStore.issues[1].Version
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", resolved, http.StatusConflict)
+
+			issueStore.blockers = nil
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", resolved, http.StatusOK)
+		})
+
+		t.Run("should fail if the status is not in the workflow", func(t *testing.T) {
+			unknown := issue
+			unknown.Status = "blocked"
+			unknown.Version = issueStore.issues[1].Version
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", unknown, http.StatusBadRequest)
+		})
+
+		t.Run("should keep the fields an older client leaves out", func(t *testing.T) {
+			stored := issueStore.issues[1]
+			stored.Labels = []string{"urgent"}
+			stored.ParentID = 7
+			stored.DueDate = "2030-01-31"
+			issueStore.issues[1] = stored
+
+			// The issue as sent before labels, parents and due dates existed
+			old := map[string]any{
+				"summary":     "Updated Issue",
+				"key":         "PRJ-1",
+				"description": "Test Description",
+				"project":     "PRJ",
+				"reporter":    "reporter@example.com",
+				"assignee":    "assignee@example.com",
+				"status":      stored.Status,
+				"issueType":   "bug",
+				"version":     stored.Version,
+				"updatedAt":   time.Now(),
+			}
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", old, http.StatusOK)
+
+			updated := issueStore.issues[1]
+			if !slices.Equal(updated.Labels, []string{"urgent"}) || updated.ParentID != 7 || updated.DueDate != "2030-01-31" {
+				t.Errorf("expected the left out fields to be kept, got %+v", updated)
+			}
+		})
+
+		t.Run("should clear the fields sent empty", func(t *testing.T) {
+			cleared := issue
+			cleared.Status = issueStore.issues[1].Status
+			cleared.Version = issueStore.issues[1].Version
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", cleared, http.StatusOK)
+
+			updated := issueStore.issues[1]
+			if len(updated.Labels) != 0 || updated.ParentID != 0 || updated.DueDate != "" {
+				t.Errorf("expected the fields to be cleared, got %+v", updated)
+			}
+		})
+	})
+
+	t.Run("Patch Issue", func(t *testing.T) {
+		t.Run("should change only the fields present", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"status": "open", "sprint_id": 4, "version": issueStore.issues[1].Version}, http.StatusOK)
+
+			patched := issueStore.issues[1]
+			if patched.Status != "open" || patched.SprintID != 4 {
+				t.Errorf("expected the status and sprint to change, got %+v", patched)
+			}
+			if patched.Summary != "Updated Issue" || patched.Assignee != "assignee@example.com" || patched.AssigneeID != 2 {
+				t.Errorf("expected the other fields to be kept, got %+v", patched)
+			}
+		})
+
+		t.Run("should take the issue out of its sprint", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"sprint_id": 0, "version": issueStore.issues[1].Version}, http.StatusOK)
+
+			if issueStore.issues[1].SprintID != 0 {
+				t.Errorf("expected no sprint, got %d", issueStore.issues[1].SprintID)
+			}
+		})
+
+		t.Run("should set and clear the priority, severity and due date", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"priority": "P0", "severity": "critical", "due_date": "2025-07-01", "version": issueStore.issues[1].Version}, http.StatusOK)
+
+			patched := issueStore.issues[1]
+			if patched.Priority != "P0" || patched.Severity != "critical" || patched.DueDate != "2025-07-01" {
+				t.Errorf("expected the priority, severity and due date to change, got %+v", patched)
+			}
+
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"severity": "", "due_date": "", "version": issueStore.issues[1].Version}, http.StatusOK)
+
+			patched = issueStore.issues[1]
+			if patched.Priority != "P0" || patched.Severity != "" || patched.DueDate != "" {
+				t.Errorf("expected the severity and due date to be cleared, got %+v", patched)
+			}
+		})
+
+		t.Run("should set and clear the estimates", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"story_points": 8, "remaining_estimate": 60, "version": issueStore.issues[1].Version}, http.StatusOK)
+
+			patched := issueStore.issues[1]
+			if patched.StoryPoints == nil || *patched.StoryPoints != 8 || patched.RemainingEstimate == nil || *patched.RemainingEstimate != 60 {
+				t.Errorf("expected the points and remaining estimate to change, got %+v", patched)
+			}
+
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"story_points": 4, "version": issueStore.issues[1].Version}, http.StatusBadRequest)
+
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"story_points": -1, "remaining_estimate": -1, "version": issueStore.issues[1].Version}, http.StatusOK)
+
+			patched = issueStore.issues[1]
+			if patched.StoryPoints != nil || patched.RemainingEstimate != nil {
+				t.Errorf("expected the points and remaining estimate to be cleared, got %+v", patched)
+			}
+		})
+
+		t.Run("should fail on a severity once the issue is no longer a bug", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"severity": "minor", "version": issueStore.issues[1].Version}, http.StatusOK)
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"issueType": "task", "version": issueStore.issues[1].Version}, http.StatusBadRequest)
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"issueType": "task", "severity": "", "version": issueStore.issues[1].Version}, http.StatusOK)
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"issueType": "bug", "version": issueStore.issues[1].Version}, http.StatusOK)
+		})
+
+		t.Run("should fail on an invalid priority or due date", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"priority": "high", "version": issueStore.issues[1].Version}, http.StatusBadRequest)
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"due_date": "tomorrow", "version": issueStore.issues[1].Version}, http.StatusBadRequest)
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"severity": "blocker", "version": issueStore.issues[1].Version}, http.StatusBadRequest)
+		})
+
+		t.Run("should fail on unknown fields", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"resolution": "fixed"}, http.StatusBadRequest)
+		})
+
+		t.Run("should fail on invalid values", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"summary": "", "version": issueStore.issues[1].Version}, http.StatusBadRequest)
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"sprint_id": -1}, http.StatusBadRequest)
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"status": 3}, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if the new assignee is not a project member", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"assignee": "outsider@example.com", "version": issueStore.issues[1].Version}, http.StatusBadRequest)
+
+			if issueStore.issues[1].Assignee != "assignee@example.com" {
+				t.Errorf("expected the assignee to be unchanged, got %s", issueStore.issues[1].Assignee)
+			}
+		})
+
+		t.Run("should fail if the issue does not exist", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/99", map[string]any{"status": "open"}, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Concurrent Updates", func(t *testing.T) {
+		t.Run("should return the version as ETag", func(t *testing.T) {
+			rr := testRequest(t, handler, http.MethodGet, "/issue/1", nil, http.StatusOK)
+
+			if want := fmt.Sprintf(`"%d"`, issueStore.issues[1].Version); rr.Header().Get("ETag") != want {
+				t.Errorf("expected ETag %s, got %q", want, rr.Header().Get("ETag"))
+			}
+		})
+
+		t.Run("should require a version", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPatch, "/issues/1", map[string]any{"summary": "No version"}, http.StatusPreconditionRequired)
+		})
+
+		t.Run("should update with a matching If-Match header", func(t *testing.T) {
+			version := issueStore.issues[1].Version
+			rr := testRequestWithHeaders(t, handler, http.MethodPatch, "/issues/1", map[string]string{"If-Match": fmt.Sprintf(`"%d"`, version)},
+				map[string]any{"summary": "First edit"}, http.StatusOK)
+
+			if want := fmt.Sprintf(`"%d"`, version+1); rr.Header().Get("ETag") != want {
+				t.Errorf("expected ETag %s, got %q", want, rr.Header().Get("ETag"))
+			}
+		})
+
+		t.Run("should fail a stale edit with the current issue", func(t *testing.T) {
+			stale := issueStore.issues[1].Version - 1
+			rr := testRequestWithHeaders(t, handler, http.MethodPatch, "/issues/1", map[string]string{"If-Match": fmt.Sprintf(`"%d"`, stale)},
+				map[string]any{"summary": "Second edit"}, http.StatusPreconditionFailed)
+
+			var resp struct {
+				Issue types.Issue `json:"issue"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Issue.Summary != "First edit" || resp.Issue.Version != stale+1 {
+				t.Errorf("expected the current issue, got %+v", resp.Issue)
+			}
+		})
+
+		t.Run("should fail a stale full update", func(t *testing.T) {
+			stale := issueStore.issues[1]
+			stale.Summary = "Overwrite"
+			stale.Version--
+			testRequest(t, handler, http.MethodPut, "/updateIssue/1", stale, http.StatusPreconditionFailed)
+
+			if issueStore.issues[1].Summary != "First edit" {
+				t.Errorf("expected the summary to be kept, got %s", issueStore.issues[1].Summary)
+			}
+		})
+	})
+
 	t.Run("Get Issues By Project", func(t *testing.T) {
 		t.Run("should return 4200 if no issues exist for the project (empty list)", func(t *testing.T) {
 			testRequest(t, handler, http.MethodGet, "/issues/PRJ", nil, http.StatusOK)
@@ -98,16 +696,169 @@ func TestIssueServiceHandlers(t *testing.T) {
 				IssueType:   "bug",
 			}
 
-			issueStore.CreateIssue(payload)
+			issueStore.CreateIssue(payload, 0)
 
 			testRequest(t, handler, http.MethodGet, "/issues/PRJ", nil, http.StatusOK)
 		})
+
+		t.Run("should filter, sort and page the issues", func(t *testing.T) {
+			rr := testRequest(t, handler, http.MethodGet, "/issues/PRJ?status=open,resolved&type=bug&type=task&sprint=none&parent=4&created_after=2025-06-01&q=login&sort=-updated&limit=10&label=ui,urgent&component=Backend", nil, http.StatusOK)
+
+			f := issueStore.lastFilter
+			if !slices.Equal(f.Statuses, []string{"open", "resolved"}) || !slices.Equal(f.IssueTypes, []string{"bug", "task"}) {
+				t.Errorf("unexpected statuses or types %+v", f)
+			}
+			if f.SprintID == nil || *f.SprintID != 0 || f.ParentID == nil || *f.ParentID != 4 || f.Text != "login" || f.Sort != "-updated" || f.Limit != 10 {
+				t.Errorf("unexpected filter %+v", f)
+			}
+			if !f.CreatedAfter.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected created_after %v", f.CreatedAfter)
+			}
+			if !slices.Equal(f.Labels, []string{"ui", "urgent"}) || !slices.Equal(f.Components, []string{"Backend"}) {
+				t.Errorf("unexpected labels or components %+v", f)
+			}
+
+			var resp struct {
+				Issues []types.Issue `json:"issues"`
+				Total  int           `json:"total"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Total != len(resp.Issues) {
+				t.Errorf("expected a total of %d, got %d", len(resp.Issues), resp.Total)
+			}
+		})
+
+		t.Run("should fail on an invalid filter", func(t *testing.T) {
+			for _, query := range []string{"sort=rank", "limit=0", "limit=1000", "sprint=next", "parent=0", "updated_before=yesterday", "cursor=garbage"} {
+				testRequest(t, handler, http.MethodGet, "/issues/PRJ?"+query, nil, http.StatusBadRequest)
+			}
+		})
+
+		t.Run("should allow viewers to list issues", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/issues/VIEW", nil, http.StatusOK)
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/issues/OTHER", nil, http.StatusForbidden)
+		})
+	})
+
+	t.Run("Get Children", func(t *testing.T) {
+		var epicID int
+		for _, issue := range issueStore.issues {
+			if issue.Summary == "Checkout Epic" {
+				epicID = issue.ID
+			}
+		}
+
+		t.Run("should list the children with their progress", func(t *testing.T) {
+			rr := testRequest(t, handler, http.MethodGet, fmt.Sprintf("/issues/%d/children", epicID), nil, http.StatusOK)
+
+			var resp struct {
+				Children []types.Issue       `json:"children"`
+				Progress types.IssueProgress `json:"progress"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Children) != 1 || resp.Children[0].Summary != "Checkout Story" {
+				t.Errorf("unexpected children %+v", resp.Children)
+			}
+			if resp.Progress.Total != 1 || resp.Progress.Todo != 1 || resp.Progress.PercentDone != 0 {
+				t.Errorf("unexpected progress %+v", resp.Progress)
+			}
+		})
+
+		t.Run("should roll up the progress", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, fmt.Sprintf("/issues/%d/progress", epicID), nil, http.StatusOK)
+			testRequest(t, handler, http.MethodGet, "/issues/99/progress", nil, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Get Overdue Issues", func(t *testing.T) {
+		t.Run("should list overdue issues by due date", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/issues/PRJ/overdue?priority=P0,P1", nil, http.StatusOK)
+
+			f := issueStore.lastFilter
+			if !f.Overdue || f.Sort != "due" || !slices.Equal(f.Priorities, []string{"P0", "P1"}) {
+				t.Errorf("unexpected filter %+v", f)
+			}
+		})
+
+		t.Run("should keep a requested sort", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/issues/PRJ/overdue?sort=-severity", nil, http.StatusOK)
+
+			if f := issueStore.lastFilter; !f.Overdue || f.Sort != "-severity" {
+				t.Errorf("unexpected filter %+v", f)
+			}
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/issues/OTHER/overdue", nil, http.StatusForbidden)
+		})
+	})
+
+	t.Run("Get Board", func(t *testing.T) {
+		t.Run("should age issues after a week by default", func(t *testing.T) {
+			rr := testRequest(t, handler, http.MethodGet, "/board/PRJ", nil, http.StatusOK)
+
+			if issueStore.lastAgingAfter != 7*24*time.Hour {
+				t.Errorf("expected issues to age after 7 days, got %v", issueStore.lastAgingAfter)
+			}
+
+			var resp struct {
+				Board types.Board `json:"board"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Board.ProjectKey != "PRJ" {
+				t.Errorf("unexpected board %+v", resp.Board)
+			}
+		})
+
+		t.Run("should take the aging days from the query", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/board/PRJ?aging_days=3", nil, http.StatusOK)
+
+			if issueStore.lastAgingAfter != 3*24*time.Hour {
+				t.Errorf("expected issues to age after 3 days, got %v", issueStore.lastAgingAfter)
+			}
+		})
+
+		t.Run("should fail on invalid aging days", func(t *testing.T) {
+			for _, days := range []string{"0", "-1", "week", "1000"} {
+				testRequest(t, handler, http.MethodGet, "/board/PRJ?aging_days="+days, nil, http.StatusBadRequest)
+			}
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/board/OTHER", nil, http.StatusForbidden)
+		})
 	})
 
 	t.Run("Get Average Cycle Time", func(t *testing.T) {
 		t.Run("should return average cycle time for a valid project", func(t *testing.T) {
 			testRequest(t, handler, http.MethodGet, "/cycle-time/PRJ", nil, http.StatusOK)
 		})
+
+		t.Run("should filter by label and component", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/cycle-time/PRJ?label=urgent&component=Backend", nil, http.StatusOK)
+
+			f := issueStore.lastFilter
+			if !slices.Equal(f.Labels, []string{"urgent"}) || !slices.Equal(f.Components, []string{"Backend"}) {
+				t.Errorf("unexpected filter %+v", f)
+			}
+		})
+
+		t.Run("should leave out sub-tasks", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/cycle-time/PRJ?exclude_type=sub-task", nil, http.StatusOK)
+
+			if f := issueStore.lastFilter; !slices.Equal(f.ExcludeTypes, []string{"sub-task"}) {
+				t.Errorf("unexpected filter %+v", f)
+			}
+		})
 	})
 
 	t.Run("Get Weekly Throughput", func(t *testing.T) {
@@ -119,7 +870,13 @@ func TestIssueServiceHandlers(t *testing.T) {
 }
 
 // testRequest - Helper function to perform HTTP requests and check response
-func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) {
+func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+	return testRequestWithHeaders(t, handler, method, path, nil, payload, expectedStatus)
+}
+
+// testRequestWithHeaders - Helper function to perform HTTP requests with extra headers and check response
+func testRequestWithHeaders(t testing.TB, handler *Handler, method, path string, headers map[string]string, payload any, expectedStatus int) *httptest.ResponseRecorder {
 	t.Helper()
 
 	// Marshal the payload into JSON
@@ -134,6 +891,10 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 		t.Fatal(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
 
 	// Create a response recorder to capture the response
 	rr := httptest.NewRecorder()
@@ -143,7 +904,13 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 	router.HandleFunc("/createIssue", handler.handleCreateIssue).Methods("POST")
 	router.HandleFunc("/issue/{id}", handler.handleGetIssueById).Methods("GET")
 	router.HandleFunc("/updateIssue/{id}", handler.handleUpdateIssue).Methods("PUT")
+	router.HandleFunc("/issues/{id}", handler.handlePatchIssue).Methods("PATCH")
+	router.HandleFunc("/issues/{id}/history", handler.handleGetIssueHistory).Methods("GET")
+	router.HandleFunc("/issues/{id}/children", handler.handleGetChildren).Methods("GET")
+	router.HandleFunc("/issues/{id}/progress", handler.handleGetProgress).Methods("GET")
 	router.HandleFunc("/issues/{key}", handler.handleGetIssuesByProject).Methods("GET")
+	router.HandleFunc("/issues/{key}/overdue", handler.handleGetOverdueIssues).Methods("GET")
+	router.HandleFunc("/board/{project_key}", handler.handleGetBoard).Methods("GET")
 	router.HandleFunc("/cycle-time/{project_key}", handler.handleGetAverageCycleTime).Methods("GET")
 	router.HandleFunc("/throughput/{project_key}", handler.handleGetWeeklyThroughput).Methods("GET")
 	router.ServeHTTP(rr, req)
@@ -155,11 +922,28 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 	if expectedStatus != rr.Code {
 		fmt.Printf("Response Body: %s\n", rr.Body.String())
 	}
+
+	return rr
+}
+
+// mockTransitions - Workflow used by the mock store, resolved issues can only be reopened
+var mockTransitions = map[string][]string{
+	"open":        {"in_progress", "resolved"},
+	"in_progress": {"open", "resolved"},
+	"resolved":    {"open"},
 }
 
 // mockIssueStore - Mock implementation of the issue store
 type mockIssueStore struct {
-	issues map[int]types.Issue
+	issues     map[int]types.Issue
+	history    []types.IssueChange
+	lastFilter types.IssueFilter
+	// lastAgingAfter is the aging threshold of the last board
+	lastAgingAfter time.Duration
+	// blockers are the open issues blocking an issue
+	blockers map[int][]string
+	// pointScale is the point scale of every project
+	pointScale []float64
 }
 
 func newMockIssueStore() *mockIssueStore {
@@ -168,20 +952,25 @@ func newMockIssueStore() *mockIssueStore {
 	}
 }
 
-func (m *mockIssueStore) CreateIssue(issue types.Issue) error {
+func (m *mockIssueStore) CreateIssue(issue types.Issue, actorID int) (int, error) {
 	// Simulate conflict if the issue already exists based on Summary and ProjectKey
 	for _, existingIssue := range m.issues {
 		if existingIssue.Summary == issue.Summary && existingIssue.ProjectKey == issue.ProjectKey {
-			return fmt.Errorf("issue already exists")
+			return 0, fmt.Errorf("issue already exists")
 		}
 	}
 
+	if err := CheckPoints(m.pointScale, issue.StoryPoints); err != nil {
+		return 0, err
+	}
+
 	if issue.ID == 0 {
 		issue.ID = len(m.issues) + 1
 	}
+	issue.Version = 1
 
 	m.issues[issue.ID] = issue
-	return nil
+	return issue.ID, nil
 }
 
 func (m *mockIssueStore) GetIssueByID(id int) (*types.Issue, error) {
@@ -192,28 +981,262 @@ func (m *mockIssueStore) GetIssueByID(id int) (*types.Issue, error) {
 	return &issue, nil
 }
 
-func (m *mockIssueStore) GetIssuesByProject(projectKey string) ([]types.Issue, error) {
-	var result []types.Issue
+func (m *mockIssueStore) GetIssuesByProject(projectKey string, filter types.IssueFilter) (*types.IssuePage, error) {
+	m.lastFilter = filter
+	page := &types.IssuePage{Issues: []types.Issue{}}
 	for _, issue := range m.issues {
-		if issue.ProjectKey == projectKey {
-			result = append(result, issue)
+		if issue.ProjectKey == projectKey && (len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, issue.Status)) {
+			page.Issues = append(page.Issues, issue)
 		}
 	}
-	return result, nil
+	page.Total = len(page.Issues)
+	return page, nil
 }
 
-func (m *mockIssueStore) UpdateIssue(issue types.Issue) error {
-	if _, exists := m.issues[issue.ID]; !exists {
+func (m *mockIssueStore) UpdateIssue(issue types.Issue, actorID int) error {
+	current, exists := m.issues[issue.ID]
+	if !exists {
 		return fmt.Errorf("issue not found")
 	}
+	if issue.Version != current.Version {
+		return fmt.Errorf("%w, version %d is not the current version %d", ErrVersionConflict, issue.Version, current.Version)
+	}
+	if _, known := mockTransitions[issue.Status]; !known {
+		return fmt.Errorf("%w %s", ErrUnknownStatus, issue.Status)
+	}
+	if issue.Status != current.Status && !slices.Contains(mockTransitions[current.Status], issue.Status) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, current.Status, issue.Status)
+	}
+	if issue.Status == "resolved" && current.Status != "resolved" && len(m.blockers[issue.ID]) > 0 {
+		return fmt.Errorf("%w, it is blocked by %v", ErrOpenBlockers, m.blockers[issue.ID])
+	}
+	if err := CheckPoints(m.pointScale, issue.StoryPoints); err != nil {
+		return err
+	}
+	for _, change := range diffIssues(current, issue) {
+		change.ID = len(m.history) + 1
+		change.ActorID = actorID
+		m.history = append(m.history, change)
+	}
+	issue.Version++
 	m.issues[issue.ID] = issue
 	return nil
 }
 
-func (m *mockIssueStore) GetAverageCycleTime(projectKey string) (time.Duration, error) {
+func (m *mockIssueStore) GetIssueHistory(issueID int) ([]types.IssueChange, error) {
+	var result []types.IssueChange
+	for _, change := range m.history {
+		if change.IssueID == issueID {
+			result = append(result, change)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockIssueStore) GetAverageCycleTime(projectKey string, filter types.IssueFilter) (time.Duration, error) {
+	m.lastFilter = filter
 	return time.Duration(0), nil
 }
 
-func (m *mockIssueStore) GetWeeklyThroughput(projectKey string) (map[string]int, error) {
+func (m *mockIssueStore) GetWeeklyThroughput(projectKey string, filter types.IssueFilter) (map[string]int, error) {
+	m.lastFilter = filter
+	return nil, nil
+}
+
+func (m *mockIssueStore) GetChildren(issueID int) ([]types.Issue, error) {
+	children := []types.Issue{}
+	for _, issue := range m.issues {
+		if issue.ParentID == issueID {
+			children = append(children, issue)
+		}
+	}
+	return children, nil
+}
+
+func (m *mockIssueStore) GetProgress(issueID int) (*types.IssueProgress, error) {
+	progress := &types.IssueProgress{IssueID: issueID}
+	for _, issue := range m.issues {
+		if issue.ParentID != issueID {
+			continue
+		}
+		progress.Total++
+		switch issue.Status {
+		case "resolved":
+			progress.Done++
+		case "in_progress":
+			progress.InProgress++
+		default:
+			progress.Todo++
+		}
+	}
+	if progress.Total > 0 {
+		progress.PercentDone = progress.Done * 100 / progress.Total
+	}
+	return progress, nil
+}
+
+func (m *mockIssueStore) GetBoard(projectKey string, agingAfter time.Duration) (*types.Board, error) {
+	m.lastAgingAfter = agingAfter
+	return &types.Board{ProjectKey: projectKey, Columns: []types.BoardColumn{}, Aging: []types.BoardCard{}}, nil
+}
+
+// mockComponentStore - Mock implementation of the component store
+type mockComponentStore struct {
+	components []types.Component
+}
+
+func (m *mockComponentStore) GetComponents(projectKey string) ([]types.Component, error) {
+	var components []types.Component
+	for _, c := range m.components {
+		if c.ProjectKey == projectKey {
+			components = append(components, c)
+		}
+	}
+	return components, nil
+}
+
+func (m *mockComponentStore) CreateComponent(component types.Component) (int, error) {
+	return 0, nil
+}
+
+func (m *mockComponentStore) UpdateComponent(component types.Component) error {
+	return nil
+}
+
+func (m *mockComponentStore) DeleteComponent(projectKey string, id int) error {
+	return nil
+}
+
+// mockSearchIndex - Mock implementation of the search index, records the indexed issues
+type mockSearchIndex struct {
+	indexed []int
+}
+
+func (m *mockSearchIndex) IndexIssue(issueID int) error {
+	m.indexed = append(m.indexed, issueID)
+	return nil
+}
+
+func (m *mockSearchIndex) SearchText(userID int, query types.TextQuery) (*types.TextSearchPage, error) {
+	return &types.TextSearchPage{}, nil
+}
+
+// mockRoleStore - Mock implementation of the project assignment store
+type mockRoleStore struct {
+	roles map[string]string
+	// members maps a project ID to the IDs of its assigned users
+	members map[int][]int
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
+
+func (m *mockRoleStore) AssignUserToProject(projectID int, userID int, role string) error {
+	return nil
+}
+
+func (m *mockRoleStore) RemoveUserFromProject(projectID int, userID int) error {
+	return nil
+}
+
+func (m *mockRoleStore) GetUsersForProject(projectID int) ([]types.User, error) {
+	return nil, nil
+}
+
+func (m *mockRoleStore) GetAllUsers() ([]types.User, error) {
+	return nil, nil
+}
+
+func (m *mockRoleStore) IsUserAssignedToProject(projectID int, userID int) (bool, error) {
+	for _, id := range m.members[projectID] {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRoleStore) GetProjectKey(projectID int) (string, error) {
+	return "", nil
+}
+
+// mockProjectStore - Mock implementation of the project store
+type mockProjectStore struct {
+	projects map[string]types.Project
+}
+
+func (m *mockProjectStore) GetProjectByKey(key string) (*types.Project, error) {
+	p, ok := m.projects[key]
+	if !ok {
+		return nil, fmt.Errorf("project not found")
+	}
+	return &p, nil
+}
+
+func (m *mockProjectStore) GetProjects() ([]types.Project, error) {
 	return nil, nil
 }
+
+func (m *mockProjectStore) CreateProject(types.Project) error {
+	return nil
+}
+
+func (m *mockProjectStore) UpdateWIPLimit(projectKey string, wipLimit int) error {
+	return nil
+}
+
+func (m *mockProjectStore) UpdateRequire2FA(projectKey string, required bool) error {
+	return nil
+}
+
+func (m *mockProjectStore) UpdatePointScale(projectKey string, scale []float64) error {
+	return nil
+}
+
+// mockUserStore - Mock implementation of the user store
+type mockUserStore struct {
+	users []types.User
+}
+
+func (m *mockUserStore) GetUserByEmail(email string) (*types.User, error) {
+	for _, u := range m.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (m *mockUserStore) GetUserByID(id int) (*types.User, error) {
+	for _, u := range m.users {
+		if u.ID == id {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (m *mockUserStore) CreateUser(types.User) error {
+	return nil
+}
+
+func (m *mockUserStore) MarkEmailVerified(userID int) error {
+	return nil
+}
+
+func (m *mockUserStore) UpdatePassword(userID int, hashedPassword string) error {
+	return nil
+}
+
+func (m *mockUserStore) UpdateProfile(userID int, firstName, lastName, email string) error {
+	return nil
+}
+
+func (m *mockUserStore) SetUserActive(userID int, active bool) error {
+	return nil
+}
@@ -4,11 +4,23 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
+// Errors caused by the requested change rather than the database, the
+// handler reports them to the client.
+var (
+	ErrUnknownStatus     = errors.New("unknown status")
+	ErrInvalidTransition = errors.New("transition not allowed")
+	ErrWIPLimitReached   = errors.New("WIP limit reached")
+	ErrInvalidSprint     = errors.New("invalid sprint")
+	ErrVersionConflict   = errors.New("issue was changed by someone else")
+)
+
 type Store struct {
 	db *sql.DB
 }
@@ -17,61 +29,231 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) CreateIssue(issue types.Issue) error {
-	var issueCount int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM issues WHERE project_key = ?", issue.ProjectKey).Scan(&issueCount)
+// CreateIssue takes the next number from the project's sequence. The project
+// row stays locked until the issue is inserted, so concurrent creates can't
+// hand out the same key, and the sequence never goes back so keys of deleted
+// or moved issues are not reused.
+func (s *Store) CreateIssue(issue types.Issue, actorID int) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var issueNumber int
+	err = tx.QueryRow("SELECT next_issue_number FROM projects WHERE project_key = ? FOR UPDATE", issue.ProjectKey).Scan(&issueNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("project %s not found", issue.ProjectKey)
+		}
+		return 0, fmt.Errorf("failed to get next issue number: %v", err)
+	}
+
+	status, category, err := lookupStatus(tx, issue.ProjectKey, issue.Status)
 	if err != nil {
-		return fmt.Errorf("failed to get issue count: %v", err)
+		return 0, err
+	}
+	if category == types.StatusCategoryInProgress {
+		if err := checkWIPLimit(tx, issue.ProjectKey); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := checkSprint(tx, issue.ProjectKey, issue.SprintID); err != nil {
+		return 0, err
 	}
 
-	issueNumber := issueCount + 1
-	issueKey := fmt.Sprintf("%s-%03d", issue.ProjectKey, issueNumber)
+	if err := checkParent(tx, issue); err != nil {
+		return 0, err
+	}
+
+	if err := checkPoints(tx, issue.ProjectKey, issue.StoryPoints); err != nil {
+		return 0, err
+	}
+
+	issueKey := fmt.Sprintf("%s-%d", issue.ProjectKey, issueNumber)
 
-	_, err = s.db.Exec("INSERT INTO issues (`key`, summary, description, project_key, reporter, assignee, status, issueType) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		issueKey, issue.Summary, issue.Description, issue.ProjectKey, issue.Reporter, issue.Assignee, issue.Status, issue.IssueType)
+	res, err := tx.Exec("INSERT INTO issues (`key`, summary, description, project_key, reporter, assignee, reporter_id, assignee_id, status, issueType, sprint_id, parent_id, derive_status, priority, severity, due_date, story_points, original_estimate, remaining_estimate, started_at, finished_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, IF(?, NOW(), NULL), IF(?, NOW(), NULL))",
+		issueKey, issue.Summary, issue.Description, issue.ProjectKey, issue.Reporter, issue.Assignee, nullableID(issue.ReporterID), nullableID(issue.AssigneeID), status, issue.IssueType, nullableID(issue.SprintID),
+		nullableID(issue.ParentID), issue.DeriveStatus, priorityOrDefault(issue.Priority), issue.Severity, nullableDate(issue.DueDate),
+		nullablePoints(issue.StoryPoints), nullableEstimate(issue.OriginalEstimate), nullableEstimate(issue.RemainingEstimate),
+		category == types.StatusCategoryInProgress, category == types.StatusCategoryDone)
 	if err != nil {
-		return fmt.Errorf("failed to insert issue: %v", err)
+		return 0, fmt.Errorf("failed to insert issue: %v", err)
 	}
 
-	_, err = s.db.Exec("UPDATE projects SET issue_count = issue_count + 1 WHERE project_key = ?", issue.ProjectKey)
+	issueID, err := res.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("failed to increment issue count: %v", err)
+		return 0, fmt.Errorf("failed to get issue id: %v", err)
 	}
 
-	return nil
+	if err := setLabels(tx, int(issueID), issue.ProjectKey, issue.Labels); err != nil {
+		return 0, err
+	}
+	if err := setComponents(tx, int(issueID), issue.ProjectKey, issue.Components); err != nil {
+		return 0, err
+	}
+
+	// A new child can move an epic that derives its status back to work
+	if issue.ParentID != 0 {
+		if err := updateDerivedStatus(tx, issue.ParentID, actorID); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE projects SET next_issue_number = next_issue_number + 1, issue_count = issue_count + 1 WHERE project_key = ?", issue.ProjectKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment issue count: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return int(issueID), nil
 }
 
-func (s *Store) UpdateIssue(issue types.Issue) error {
-	var currentStatus string
-	err := s.db.QueryRow("SELECT status FROM issues WHERE id = ?", issue.ID).Scan(&currentStatus)
+// UpdateIssue locks the issue while it compares the update to the stored
+// row, so concurrent updates each record the values they replaced. The
+// update has to carry the current version, which it then increments.
+func (s *Store) UpdateIssue(issue types.Issue, actorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	current := types.Issue{ID: issue.ID}
+	var reporterID, assigneeID, sprintID, parentID sql.NullInt64
+	var dueDate sql.NullTime
+	var points sql.NullFloat64
+	var originalEstimate, remainingEstimate sql.NullInt64
+	err = tx.QueryRow("SELECT summary, description, project_key, reporter, assignee, reporter_id, assignee_id, status, issueType, sprint_id, parent_id, derive_status, priority, severity, due_date, story_points, original_estimate, remaining_estimate, version FROM issues WHERE id = ? FOR UPDATE", issue.ID).Scan(
+		&current.Summary,
+		&current.Description,
+		&current.ProjectKey,
+		&current.Reporter,
+		&current.Assignee,
+		&reporterID,
+		&assigneeID,
+		&current.Status,
+		&current.IssueType,
+		&sprintID,
+		&parentID,
+		&current.DeriveStatus,
+		&current.Priority,
+		&current.Severity,
+		&dueDate,
+		&points,
+		&originalEstimate,
+		&remainingEstimate,
+		&current.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to fetch current issue: %v", err)
+	}
+	current.ReporterID = int(reporterID.Int64)
+	current.AssigneeID = int(assigneeID.Int64)
+	current.SprintID = int(sprintID.Int64)
+	current.ParentID = int(parentID.Int64)
+	current.DueDate = formatDate(dueDate)
+	current.StoryPoints = pointsValue(points)
+	current.OriginalEstimate = estimateValue(originalEstimate)
+	current.RemainingEstimate = estimateValue(remainingEstimate)
+	issue.Priority = priorityOrDefault(issue.Priority)
+
+	var labels, components sql.NullString
+	err = tx.QueryRow("SELECT "+issueLabels+", "+issueComponents+" FROM issues i WHERE i.id = ?", issue.ID).Scan(&labels, &components)
 	if err != nil {
-		return fmt.Errorf("failed to fetch current issue status: %v", err)
+		return fmt.Errorf("failed to fetch current labels and components: %v", err)
+	}
+	if current.Labels, err = issueNames(labels); err != nil {
+		return fmt.Errorf("failed to read labels: %v", err)
+	}
+	if current.Components, err = issueNames(components); err != nil {
+		return fmt.Errorf("failed to read components: %v", err)
+	}
+
+	if issue.Version != current.Version {
+		return fmt.Errorf("%w, version %d is not the current version %d", ErrVersionConflict, issue.Version, current.Version)
+	}
+
+	if err := checkSprint(tx, issue.ProjectKey, issue.SprintID); err != nil {
+		return err
+	}
+
+	if err := checkParent(tx, issue); err != nil {
+		return err
+	}
+	if issue.IssueType != current.IssueType || issue.ProjectKey != current.ProjectKey {
+		if err := checkChildren(tx, issue); err != nil {
+			return err
+		}
 	}
 
-	// Enforce WIP limit when moving to in_progress
-	if issue.Status == "in_progress" && currentStatus != "in_progress" {
-		var wipLimit int
-		var inProgressCount int
+	// Points are kept when the scale of the project changes, until they are
+	// changed themselves or the issue moves
+	if pointsString(issue.StoryPoints) != pointsString(current.StoryPoints) || issue.ProjectKey != current.ProjectKey {
+		if err := checkPoints(tx, issue.ProjectKey, issue.StoryPoints); err != nil {
+			return err
+		}
+	}
+
+	// Statuses and their categories come from the workflow of the project,
+	// within a project only the transitions it lists are allowed
+	status, category, err := lookupStatus(tx, issue.ProjectKey, issue.Status)
+	if err != nil {
+		return err
+	}
 
-		err = s.db.QueryRow("SELECT wip_limit FROM projects WHERE project_key = ?", issue.ProjectKey).Scan(&wipLimit)
+	// An epic that derives its status follows its children, whatever the
+	// update asks for and whatever the workflow allows
+	derived := false
+	if issue.IssueType == types.IssueTypeEpic && issue.DeriveStatus {
+		derivedName, derivedCategory, err := derivedStatus(tx, issue.ID, issue.ProjectKey, status, category)
 		if err != nil {
-			return fmt.Errorf("failed to fetch WIP limit: %v", err)
+			return err
 		}
+		derived = derivedName != status
+		status, category = derivedName, derivedCategory
+	}
+	issue.Status = status
+
+	_, currentCategory, err := lookupStatus(tx, current.ProjectKey, current.Status)
+	if err != nil && !errors.Is(err, ErrUnknownStatus) {
+		return err
+	}
 
-		err = s.db.QueryRow("SELECT COUNT(*) FROM issues WHERE project_key = ? AND status = 'in_progress'", issue.ProjectKey).Scan(&inProgressCount)
+	if !derived && issue.ProjectKey == current.ProjectKey && !strings.EqualFold(issue.Status, current.Status) {
+		var allowed bool
+		err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM workflow_transitions WHERE project_key = ? AND from_status = ? AND to_status = ?)",
+			issue.ProjectKey, current.Status, issue.Status).Scan(&allowed)
 		if err != nil {
-			return fmt.Errorf("failed to fetch in-progress issues count: %v", err)
+			return fmt.Errorf("failed to check transition: %v", err)
+		}
+		if !allowed {
+			return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, current.Status, issue.Status)
+		}
+	}
+
+	// Issues can't be finished before the issues blocking them
+	if category == types.StatusCategoryDone && currentCategory != types.StatusCategoryDone {
+		if err := checkBlockers(tx, issue.ID); err != nil {
+			return err
 		}
+	}
 
-		if inProgressCount >= wipLimit {
-			return fmt.Errorf("too many issues in progress, the WIP limit is %d", wipLimit)
+	startsWork := category == types.StatusCategoryInProgress && currentCategory != types.StatusCategoryInProgress
+	if !derived && category == types.StatusCategoryInProgress && (startsWork || issue.ProjectKey != current.ProjectKey) {
+		if err := checkWIPLimit(tx, issue.ProjectKey); err != nil {
+			return err
 		}
 	}
 
 	// Prepare dynamic update for timestamps
 	query := `
 		UPDATE issues 
-		SET summary = ?, description = ?, project_key = ?, reporter = ?, assignee = ?, status = ?, issueType = ?, updatedAt = NOW()`
+		SET summary = ?, description = ?, project_key = ?, reporter = ?, assignee = ?, reporter_id = ?, assignee_id = ?, status = ?, issueType = ?, sprint_id = ?, parent_id = ?, derive_status = ?, priority = ?, severity = ?, due_date = ?, story_points = ?, original_estimate = ?, remaining_estimate = ?, version = version + 1, updatedAt = NOW()`
 
 	args := []interface{}{
 		issue.Summary,
@@ -79,36 +261,223 @@ func (s *Store) UpdateIssue(issue types.Issue) error {
 		issue.ProjectKey,
 		issue.Reporter,
 		issue.Assignee,
+		nullableID(issue.ReporterID),
+		nullableID(issue.AssigneeID),
 		issue.Status,
 		issue.IssueType,
+		nullableID(issue.SprintID),
+		nullableID(issue.ParentID),
+		issue.DeriveStatus,
+		issue.Priority,
+		issue.Severity,
+		nullableDate(issue.DueDate),
+		nullablePoints(issue.StoryPoints),
+		nullableEstimate(issue.OriginalEstimate),
+		nullableEstimate(issue.RemainingEstimate),
 	}
 
-	// Add started_at if moving to in_progress
-	if issue.Status == "in_progress" && currentStatus != "in_progress" {
+	// Add started_at if moving into an in progress status
+	if startsWork {
 		query += `, started_at = NOW()`
 	}
 
-	// Add finished_at if moving to done
-	if issue.Status == "resolved" && currentStatus != "resolved" {
+	// Add finished_at if moving into a done status, reopened issues are no
+	// longer finished
+	if category == types.StatusCategoryDone && currentCategory != types.StatusCategoryDone {
 		query += `, finished_at = NOW()`
+	} else if category != types.StatusCategoryDone && currentCategory == types.StatusCategoryDone {
+		query += `, finished_at = NULL`
 	}
 
 	query += ` WHERE id = ?`
 	args = append(args, issue.ID)
 
-	_, err = s.db.Exec(query, args...)
+	_, err = tx.Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update issue: %v", err)
 	}
 
+	// Labels and components belong to the project, so they are set again
+	// when the issue moves
+	if err := setLabels(tx, issue.ID, issue.ProjectKey, issue.Labels); err != nil {
+		return err
+	}
+	if err := setComponents(tx, issue.ID, issue.ProjectKey, issue.Components); err != nil {
+		return err
+	}
+
+	for _, change := range diffIssues(current, issue) {
+		_, err := tx.Exec("INSERT INTO issue_history (issue_id, actor_id, field, old_value, new_value) VALUES (?, ?, ?, ?, ?)",
+			issue.ID, nullableID(actorID), change.Field, change.OldValue, change.NewValue)
+		if err != nil {
+			return fmt.Errorf("failed to record issue history: %v", err)
+		}
+	}
+
+	// Epics deriving their status follow the children they gain and lose
+	if issue.Status != current.Status || issue.ParentID != current.ParentID {
+		parents := []int{issue.ParentID}
+		if current.ParentID != issue.ParentID {
+			parents = append(parents, current.ParentID)
+		}
+		for _, parentID := range parents {
+			if parentID == 0 {
+				continue
+			}
+			if err := updateDerivedStatus(tx, parentID, actorID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Epics held back by this issue can be done once it is
+	if category == types.StatusCategoryDone && currentCategory != types.StatusCategoryDone {
+		if err := updateBlockedEpics(tx, issue.ID, actorID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
 	return nil
 }
 
-func (s *Store) GetIssueByID(id int) (*types.Issue, error) {
+// lookupStatus returns the status as named by the workflow of the project
+// and its category. The status row is share locked, so the workflow can't
+// drop it before the issue is saved.
+func lookupStatus(tx *sql.Tx, projectKey, status string) (string, string, error) {
+	var name, category string
+	err := tx.QueryRow("SELECT name, category FROM workflow_statuses WHERE project_key = ? AND name = ? FOR SHARE", projectKey, status).Scan(&name, &category)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", fmt.Errorf("%w %s in project %s", ErrUnknownStatus, status, projectKey)
+		}
+		return "", "", fmt.Errorf("failed to fetch status: %v", err)
+	}
+	return name, category, nil
+}
+
+// checkSprint makes sure the sprint of an issue belongs to its project, no
+// sprint is always fine.
+func checkSprint(tx *sql.Tx, projectKey string, sprintID int) error {
+	if sprintID == 0 {
+		return nil
+	}
+
+	var sprintProjectKey string
+	err := tx.QueryRow("SELECT project_key FROM sprints WHERE id = ?", sprintID).Scan(&sprintProjectKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w, sprint %d not found", ErrInvalidSprint, sprintID)
+		}
+		return fmt.Errorf("failed to fetch sprint: %v", err)
+	}
+	if sprintProjectKey != projectKey {
+		return fmt.Errorf("%w, sprint %d belongs to project %s", ErrInvalidSprint, sprintID, sprintProjectKey)
+	}
+	return nil
+}
+
+// checkWIPLimit fails when the project already has as many issues in
+// progress as its WIP limit allows.
+func checkWIPLimit(tx *sql.Tx, projectKey string) error {
+	var wipLimit int
+	var inProgressCount int
+
+	err := tx.QueryRow("SELECT wip_limit FROM projects WHERE project_key = ?", projectKey).Scan(&wipLimit)
+	if err != nil {
+		return fmt.Errorf("failed to fetch WIP limit: %v", err)
+	}
+
+	// Epics deriving their status are in progress because their children
+	// are, so only the children count
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM issues i
+		JOIN workflow_statuses s ON s.project_key = i.project_key AND s.name = i.status
+		WHERE i.project_key = ? AND s.category = ? AND NOT (i.issueType = ? AND i.derive_status)`,
+		projectKey, types.StatusCategoryInProgress, types.IssueTypeEpic).Scan(&inProgressCount)
+	if err != nil {
+		return fmt.Errorf("failed to fetch in-progress issues count: %v", err)
+	}
+
+	if inProgressCount >= wipLimit {
+		return fmt.Errorf("%w, too many issues in progress, the WIP limit is %d", ErrWIPLimitReached, wipLimit)
+	}
+	return nil
+}
+
+func (s *Store) GetIssueHistory(issueID int) ([]types.IssueChange, error) {
+	rows, err := s.db.Query(`
+		SELECT h.id, h.issue_id, i.key, h.actor_id, u.firstName, u.lastName, u.email,
+			h.field, COALESCE(h.old_value, ''), COALESCE(h.new_value, ''), h.changed_at
+		FROM issue_history h
+		JOIN issues i ON i.id = h.issue_id
+		LEFT JOIN users u ON u.id = h.actor_id
+		WHERE h.issue_id = ?
+		ORDER BY h.changed_at, h.id`, issueID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issue history: %v", err)
+	}
+	defer rows.Close()
+
+	var changes []types.IssueChange
+	for rows.Next() {
+		var c types.IssueChange
+		var actor nullUser
+		err := rows.Scan(&c.ID, &c.IssueID, &c.IssueKey, &actor.ID, &actor.FirstName, &actor.LastName, &actor.Email,
+			&c.Field, &c.OldValue, &c.NewValue, &c.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan issue history row: %v", err)
+		}
+		c.Actor = actor.summary()
+		if c.Actor != nil {
+			c.ActorID = c.Actor.ID
+		}
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return changes, nil
+}
+
+// issueColumns selects an issue together with its reporter and assignee,
+// the emails come from the users so they follow renames.
+const issueColumns = "i.id, i.`key`, i.summary, i.description, i.project_key, " +
+	"COALESCE(r.email, i.reporter), COALESCE(a.email, i.assignee), " +
+	"i.status, i.issueType, COALESCE(i.sprint_id, 0), COALESCE(i.parent_id, 0), i.derive_status, i.priority, i.severity, i.due_date, i.story_points, i.original_estimate, i.remaining_estimate, i.version, i.createdAt, i.updatedAt, i.started_at, i.finished_at, " +
+	"r.id, r.firstName, r.lastName, r.email, " +
+	"a.id, a.firstName, a.lastName, a.email, " +
+	issueLabels + ", " + issueComponents + " " + issueFrom
+
+// issueLabels and issueComponents aggregate the names of an issue's labels
+// and components into a JSON array, NULL when it has none.
+const (
+	issueLabels     = "(SELECT JSON_ARRAYAGG(l.name) FROM issue_labels il JOIN labels l ON l.id = il.label_id WHERE il.issue_id = i.id)"
+	issueComponents = "(SELECT JSON_ARRAYAGG(c.name) FROM issue_components ic JOIN components c ON c.id = ic.component_id WHERE ic.issue_id = i.id)"
+)
+
+const issueFrom = "FROM issues i " +
+	"LEFT JOIN users r ON r.id = i.reporter_id " +
+	"LEFT JOIN users a ON a.id = i.assignee_id"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanIssue(row scanner) (*types.Issue, error) {
 	i := &types.Issue{}
+	var reporter, assignee nullUser
+	var labels, components sql.NullString
+	var dueDate sql.NullTime
+	var points sql.NullFloat64
+	var originalEstimate, remainingEstimate sql.NullInt64
 
-	// Query to get the issue details, including started_at and finished_at
-	err := s.db.QueryRow("SELECT id, `key`, summary, description, project_key, reporter, assignee, status, issueType, createdAt, updatedAt, started_at, finished_at FROM issues WHERE id = ?", id).Scan(
+	err := row.Scan(
 		&i.ID,
 		&i.Key,
 		&i.Summary,
@@ -118,19 +487,48 @@ func (s *Store) GetIssueByID(id int) (*types.Issue, error) {
 		&i.Assignee,
 		&i.Status,
 		&i.IssueType,
+		&i.SprintID,
+		&i.ParentID,
+		&i.DeriveStatus,
+		&i.Priority,
+		&i.Severity,
+		&dueDate,
+		&points,
+		&originalEstimate,
+		&remainingEstimate,
+		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.StartedAt,
 		&i.FinishedAt,
+		&reporter.ID, &reporter.FirstName, &reporter.LastName, &reporter.Email,
+		&assignee.ID, &assignee.FirstName, &assignee.LastName, &assignee.Email,
+		&labels, &components,
 	)
-
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("issue with ID %d not found", id)
-		}
 		return nil, err
 	}
 
+	if i.Labels, err = issueNames(labels); err != nil {
+		return nil, err
+	}
+	if i.Components, err = issueNames(components); err != nil {
+		return nil, err
+	}
+
+	i.DueDate = formatDate(dueDate)
+	i.StoryPoints = pointsValue(points)
+	i.OriginalEstimate = estimateValue(originalEstimate)
+	i.RemainingEstimate = estimateValue(remainingEstimate)
+	i.ReporterUser = reporter.summary()
+	i.AssigneeUser = assignee.summary()
+	if i.ReporterUser != nil {
+		i.ReporterID = i.ReporterUser.ID
+	}
+	if i.AssigneeUser != nil {
+		i.AssigneeID = i.AssigneeUser.ID
+	}
+
 	// Calculate cycle time if both started_at and finished_at are available
 	if i.StartedAt.Valid && i.FinishedAt.Valid {
 		duration := i.FinishedAt.Time.Sub(i.StartedAt.Time)
@@ -140,58 +538,114 @@ func (s *Store) GetIssueByID(id int) (*types.Issue, error) {
 	return i, nil
 }
 
-func (s *Store) GetIssuesByProject(projectKey string) ([]types.Issue, error) {
-	rows, err := s.db.Query("SELECT id, `key`, summary, description, project_key, reporter, assignee, status, issueType, updatedAt, started_at, finished_at FROM issues WHERE project_key=?", projectKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query issues: %v", err)
+// nullUser holds the columns of a left joined user.
+type nullUser struct {
+	ID        sql.NullInt64
+	FirstName sql.NullString
+	LastName  sql.NullString
+	Email     sql.NullString
+}
+
+func (u nullUser) summary() *types.UserSummary {
+	if !u.ID.Valid {
+		return nil
 	}
-	defer rows.Close()
+	return &types.UserSummary{
+		ID:        int(u.ID.Int64),
+		FirstName: u.FirstName.String,
+		LastName:  u.LastName.String,
+		Email:     u.Email.String,
+	}
+}
 
-	var issues []types.Issue
+// nullableID stores a missing user as NULL rather than a dangling id.
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
 
-	for rows.Next() {
-		var i types.Issue
-		err := rows.Scan(
-			&i.ID,
-			&i.Key,
-			&i.Summary,
-			&i.Description,
-			&i.ProjectKey,
-			&i.Reporter,
-			&i.Assignee,
-			&i.Status,
-			&i.IssueType,
-			&i.UpdatedAt,
-			&i.StartedAt,
-			&i.FinishedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan issue row: %v", err)
-		}
-		if i.StartedAt.Valid && i.FinishedAt.Valid {
-			duration := i.FinishedAt.Time.Sub(i.StartedAt.Time)
-			i.CycleTime = duration.String()
+// nullableDate stores a missing due date as NULL, dates are validated by
+// the handler.
+func nullableDate(date string) any {
+	if date == "" {
+		return nil
+	}
+	return date
+}
+
+func formatDate(date sql.NullTime) string {
+	if !date.Valid {
+		return ""
+	}
+	return date.Time.Format(time.DateOnly)
+}
+
+func priorityOrDefault(priority string) string {
+	if priority == "" {
+		return DefaultPriority
+	}
+	return priority
+}
+
+func (s *Store) GetIssueByID(id int) (*types.Issue, error) {
+	i, err := scanIssue(s.db.QueryRow("SELECT "+issueColumns+" WHERE i.id = ?", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("issue with ID %d not found", id)
 		}
-		issues = append(issues, i)
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	if i.Links, err = GetLinks(s.db, i.ID); err != nil {
+		return nil, err
 	}
 
-	return issues, nil
+	return i, nil
 }
 
-func (s *Store) GetAverageCycleTime(projectKey string) (time.Duration, error) {
-	query := `
-		SELECT started_at, finished_at 
-		FROM issues 
-		WHERE project_key = ? 
-		AND started_at IS NOT NULL 
-		AND finished_at IS NOT NULL
-	`
+func (s *Store) GetIssuesByProject(projectKey string, filter types.IssueFilter) (*types.IssuePage, error) {
+	return ListIssues(s.db, "i.project_key = ?", []any{projectKey}, filter)
+}
+
+func (s *Store) GetChildren(issueID int) ([]types.Issue, error) {
+	return queryIssues(s.db, "SELECT "+issueColumns+" WHERE i.parent_id = ? ORDER BY i.id", []any{issueID})
+}
 
-	rows, err := s.db.Query(query, projectKey)
+func (s *Store) GetProgress(issueID int) (*types.IssueProgress, error) {
+	counts, total, err := childCategories(s.db, issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &types.IssueProgress{
+		IssueID:    issueID,
+		Total:      total,
+		InProgress: counts[types.StatusCategoryInProgress],
+		Done:       counts[types.StatusCategoryDone],
+	}
+	progress.Todo = total - progress.InProgress - progress.Done
+	if total > 0 {
+		progress.PercentDone = progress.Done * 100 / total
+	}
+
+	points, err := SumPoints(s.db, "i.parent_id = ?", []any{issueID})
+	if err != nil {
+		return nil, err
+	}
+	progress.Points = *points
+	return progress, nil
+}
+
+func (s *Store) GetAverageCycleTime(projectKey string, filter types.IssueFilter) (time.Duration, error) {
+	conds, args := filterConditions(filter)
+	query := "SELECT i.started_at, i.finished_at " + issueFrom + `
+		WHERE i.project_key = ?
+		AND i.started_at IS NOT NULL
+		AND i.finished_at IS NOT NULL` + andConditions(conds)
+
+	rows, err := s.db.Query(query, append([]any{projectKey}, args...)...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch issue cycle times: %v", err)
 	}
@@ -217,20 +671,21 @@ func (s *Store) GetAverageCycleTime(projectKey string) (time.Duration, error) {
 	return average, nil
 }
 
-func (s *Store) GetWeeklyThroughput(projectKey string) (map[string]int, error) {
+func (s *Store) GetWeeklyThroughput(projectKey string, filter types.IssueFilter) (map[string]int, error) {
+	conds, args := filterConditions(filter)
 	query := `
 		SELECT 
-			DATE_FORMAT(finished_at, '%Y-%u') AS week,
+			DATE_FORMAT(i.finished_at, '%Y-%u') AS week,
 			COUNT(*) AS completed
-		FROM issues
+		` + issueFrom + `
 		WHERE 
-			project_key = ? 
-			AND finished_at IS NOT NULL
+			i.project_key = ? 
+			AND i.finished_at IS NOT NULL` + andConditions(conds) + `
 		GROUP BY week
 		ORDER BY week ASC
 	`
 
-	rows, err := s.db.Query(query, projectKey)
+	rows, err := s.db.Query(query, append([]any{projectKey}, args...)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch weekly throughput: %v", err)
 	}
@@ -252,3 +707,103 @@ func (s *Store) GetWeeklyThroughput(projectKey string) (map[string]int, error) {
 
 	return throughput, nil
 }
+
+// inCategory holds for issues whose status is in the category bound to it.
+const inCategory = "EXISTS (SELECT 1 FROM workflow_statuses s WHERE s.project_key = i.project_key AND s.name = i.status AND s.category = ?)"
+
+// boardDoneWindow is how long finished issues stay on the board.
+const boardDoneWindow = 14 * 24 * time.Hour
+
+// GetBoard puts every open issue of the project and those finished in the
+// last two weeks in the column of its status. An issue has been in its
+// status since the last status or project change, or since it was created.
+func (s *Store) GetBoard(projectKey string, agingAfter time.Duration) (*types.Board, error) {
+	board := &types.Board{ProjectKey: projectKey, AgingDays: int(agingAfter / (24 * time.Hour)), Columns: []types.BoardColumn{}, Aging: []types.BoardCard{}}
+
+	rows, err := s.db.Query("SELECT name, category FROM workflow_statuses WHERE project_key = ? ORDER BY position, name", projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch statuses: %v", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]int)
+	for rows.Next() {
+		column := types.BoardColumn{Cards: []types.BoardCard{}}
+		if err := rows.Scan(&column.Status, &column.Category); err != nil {
+			return nil, fmt.Errorf("failed to scan status: %v", err)
+		}
+		columns[strings.ToLower(column.Status)] = len(board.Columns)
+		board.Columns = append(board.Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating statuses: %v", err)
+	}
+
+	now := time.Now()
+	issues, err := queryIssues(s.db, "SELECT "+issueColumns+" WHERE i.project_key = ? AND (NOT "+inCategory+" OR i.finished_at >= ?) ORDER BY i.priority, i.createdAt, i.id",
+		[]any{projectKey, types.StatusCategoryDone, now.Add(-boardDoneWindow)})
+	if err != nil {
+		return nil, err
+	}
+
+	since, err := s.statusChanges(projectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, issue := range issues {
+		index, ok := columns[strings.ToLower(issue.Status)]
+		if !ok {
+			continue
+		}
+		column := &board.Columns[index]
+
+		card := types.BoardCard{Issue: issue, InStatusSince: issue.CreatedAt}
+		if changed, ok := since[issue.ID]; ok {
+			card.InStatusSince = changed
+		}
+		inStatus := now.Sub(card.InStatusSince)
+		card.DaysInStatus = int(inStatus / (24 * time.Hour))
+		card.Aging = IsUrgent(issue.Priority) && column.Category != types.StatusCategoryDone && inStatus > agingAfter
+
+		column.Cards = append(column.Cards, card)
+		if card.Aging {
+			board.Aging = append(board.Aging, card)
+		}
+	}
+
+	sort.SliceStable(board.Aging, func(a, b int) bool {
+		return board.Aging[a].InStatusSince.Before(board.Aging[b].InStatusSince)
+	})
+
+	return board, nil
+}
+
+// statusChanges returns when the status of each issue of the project last
+// changed, moving to another project counts as a change.
+func (s *Store) statusChanges(projectKey string) (map[int]time.Time, error) {
+	rows, err := s.db.Query(`
+		SELECT h.issue_id, MAX(h.changed_at)
+		FROM issue_history h
+		JOIN issues i ON i.id = h.issue_id
+		WHERE i.project_key = ? AND h.field IN ('status', 'project')
+		GROUP BY h.issue_id`, projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch status changes: %v", err)
+	}
+	defer rows.Close()
+
+	changes := make(map[int]time.Time)
+	for rows.Next() {
+		var issueID int
+		var changedAt time.Time
+		if err := rows.Scan(&issueID, &changedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %v", err)
+		}
+		changes[issueID] = changedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating status changes: %v", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,157 @@
+package label
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type Handler struct {
+	store types.LabelStore
+	roles types.RoleStore
+}
+
+func NewHandler(store types.LabelStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, roles: roles}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/projects/{key}/labels", h.handleGetLabels).Methods("GET")
+	router.HandleFunc("/projects/{key}/labels/merge", h.handleMergeLabels).Methods("POST")
+	router.HandleFunc("/projects/{key}/labels/{name}", h.handleRenameLabel).Methods("PATCH")
+	router.HandleFunc("/projects/{key}/labels/{name}", h.handleDeleteLabel).Methods("DELETE")
+}
+
+// handleGetLabels lists the labels of the project with how many issues
+// carry each.
+func (h *Handler) handleGetLabels(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	labels, err := h.store.GetLabels(projectKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"project_key": projectKey,
+		"labels":      labels,
+	})
+}
+
+func (h *Handler) handleRenameLabel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageLabels) {
+		return
+	}
+
+	var payload types.LabelRenamePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	newName, err := issue.NormalizeLabel(payload.Name)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.RenameLabel(projectKey, vars["name"], newName, auth.GetUserIDFromContext(r.Context())); err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Label renamed successfully",
+		"name":    newName,
+	})
+}
+
+// handleMergeLabels moves the issues of the listed labels to the label named
+// into, which is created when the project doesn't have it yet.
+func (h *Handler) handleMergeLabels(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageLabels) {
+		return
+	}
+
+	var payload types.LabelMergePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	into, err := issue.NormalizeLabel(payload.Into)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	labels, err := issue.NormalizeLabels(payload.Labels)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.MergeLabels(projectKey, labels, into, auth.GetUserIDFromContext(r.Context())); err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Labels merged successfully",
+		"name":    into,
+	})
+}
+
+func (h *Handler) handleDeleteLabel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectKey := vars["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageLabels) {
+		return
+	}
+
+	if err := h.store.DeleteLabel(projectKey, vars["name"], auth.GetUserIDFromContext(r.Context())); err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Label deleted successfully",
+	})
+}
+
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrLabelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrLabelExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,206 @@
+package label
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestLabelServiceHandlers(t *testing.T) {
+	store := &mockLabelStore{issues: map[string][]int{
+		"bug":    {1, 2},
+		"defect": {2, 3},
+		"ui":     {4},
+	}}
+	roles := &mockRoleStore{roles: map[int]map[string]string{
+		1: {"PRJ": "maintainer"},
+		2: {"PRJ": "member"},
+	}}
+	handler := NewHandler(store, roles)
+
+	t.Run("Get Labels", func(t *testing.T) {
+		rr := testRequest(t, handler, 2, http.MethodGet, "/projects/PRJ/labels", nil, http.StatusOK)
+
+		var resp struct {
+			Labels []types.Label `json:"labels"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Labels) != 3 || resp.Labels[0].Name != "bug" || resp.Labels[0].IssueCount != 2 {
+			t.Errorf("unexpected labels %+v", resp.Labels)
+		}
+	})
+
+	t.Run("Rename Label", func(t *testing.T) {
+		t.Run("should rename the label", func(t *testing.T) {
+			store.actorID = 0
+			testRequest(t, handler, 1, http.MethodPatch, "/projects/PRJ/labels/ui", types.LabelRenamePayload{Name: "frontend"}, http.StatusOK)
+
+			if _, ok := store.issues["frontend"]; !ok {
+				t.Errorf("expected the label to be renamed, got %v", store.issues)
+			}
+			if store.actorID != 1 {
+				t.Errorf("expected the rename to be recorded for user 1, got %d", store.actorID)
+			}
+		})
+
+		t.Run("should refuse the name of another label", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPatch, "/projects/PRJ/labels/defect", types.LabelRenamePayload{Name: "bug"}, http.StatusConflict)
+		})
+
+		t.Run("should refuse an invalid name", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPatch, "/projects/PRJ/labels/bug", types.LabelRenamePayload{Name: "two words"}, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if the label does not exist", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPatch, "/projects/PRJ/labels/missing", types.LabelRenamePayload{Name: "found"}, http.StatusNotFound)
+		})
+
+		t.Run("should be forbidden for members", func(t *testing.T) {
+			testRequest(t, handler, 2, http.MethodPatch, "/projects/PRJ/labels/bug", types.LabelRenamePayload{Name: "bugs"}, http.StatusForbidden)
+		})
+	})
+
+	t.Run("Merge Labels", func(t *testing.T) {
+		t.Run("should move the issues into one label", func(t *testing.T) {
+			store.actorID = 0
+			payload := types.LabelMergePayload{Labels: []string{"bug", "defect"}, Into: "bug"}
+			testRequest(t, handler, 1, http.MethodPost, "/projects/PRJ/labels/merge", payload, http.StatusOK)
+
+			if _, ok := store.issues["defect"]; ok {
+				t.Error("expected the merged label to be deleted")
+			}
+			if !slices.Equal(store.issues["bug"], []int{1, 2, 3}) {
+				t.Errorf("unexpected issues %v", store.issues["bug"])
+			}
+			if store.actorID != 1 {
+				t.Errorf("expected the merge to be recorded for user 1, got %d", store.actorID)
+			}
+		})
+
+		t.Run("should fail without labels", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/projects/PRJ/labels/merge", types.LabelMergePayload{Into: "bug"}, http.StatusBadRequest)
+		})
+	})
+
+	t.Run("Delete Label", func(t *testing.T) {
+		testRequest(t, handler, 1, http.MethodDelete, "/projects/PRJ/labels/frontend", nil, http.StatusOK)
+		testRequest(t, handler, 1, http.MethodDelete, "/projects/PRJ/labels/frontend", nil, http.StatusNotFound)
+	})
+}
+
+// testRequest - Helper function to perform HTTP requests as a user and check the response
+func testRequest(t testing.TB, handler *Handler, userID int, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body []byte
+	if payload != nil {
+		marshalled, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = marshalled
+	}
+
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, userID))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+		fmt.Printf("Response Body: %s\n", rr.Body.String())
+	}
+
+	return rr
+}
+
+// mockLabelStore - Mock implementation of the label store, the issues of each label of project PRJ
+// and the user behind the last change
+type mockLabelStore struct {
+	issues  map[string][]int
+	actorID int
+}
+
+func (m *mockLabelStore) GetLabels(projectKey string) ([]types.Label, error) {
+	labels := []types.Label{}
+	for name, issues := range m.issues {
+		labels = append(labels, types.Label{ProjectKey: projectKey, Name: name, IssueCount: len(issues)})
+	}
+	slices.SortFunc(labels, func(a, b types.Label) int { return strings.Compare(a.Name, b.Name) })
+	return labels, nil
+}
+
+func (m *mockLabelStore) RenameLabel(projectKey, name, newName string, actorID int) error {
+	issues, ok := m.issues[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+	}
+	if _, taken := m.issues[newName]; taken && newName != name {
+		return fmt.Errorf("%w: %s", ErrLabelExists, newName)
+	}
+	delete(m.issues, name)
+	m.issues[newName] = issues
+	m.actorID = actorID
+	return nil
+}
+
+func (m *mockLabelStore) MergeLabels(projectKey string, names []string, into string, actorID int) error {
+	merged := m.issues[into]
+	for _, name := range names {
+		issues, ok := m.issues[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+		}
+		for _, id := range issues {
+			if !slices.Contains(merged, id) {
+				merged = append(merged, id)
+			}
+		}
+		delete(m.issues, name)
+	}
+	slices.Sort(merged)
+	m.issues[into] = merged
+	m.actorID = actorID
+	return nil
+}
+
+func (m *mockLabelStore) DeleteLabel(projectKey, name string, actorID int) error {
+	if _, ok := m.issues[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+	}
+	delete(m.issues, name)
+	m.actorID = actorID
+	return nil
+}
+
+// mockRoleStore - Mock implementation of the role store, roles per user and project
+type mockRoleStore struct {
+	roles map[int]map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[userID][projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
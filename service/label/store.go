@@ -0,0 +1,322 @@
+package label
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+var (
+	ErrLabelNotFound = errors.New("label not found")
+	ErrLabelExists   = errors.New("label already exists")
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetLabels(projectKey string) ([]types.Label, error) {
+	rows, err := s.db.Query(`
+        SELECT l.id, l.project_key, l.name, COUNT(il.issue_id)
+        FROM labels l
+        LEFT JOIN issue_labels il ON il.label_id = l.id
+        WHERE l.project_key = ?
+        GROUP BY l.id, l.project_key, l.name
+        ORDER BY l.name
+    `, projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query labels: %v", err)
+	}
+	defer rows.Close()
+
+	labels := []types.Label{}
+	for rows.Next() {
+		var l types.Label
+		if err := rows.Scan(&l.ID, &l.ProjectKey, &l.Name, &l.IssueCount); err != nil {
+			return nil, fmt.Errorf("failed to scan label row: %v", err)
+		}
+		labels = append(labels, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return labels, nil
+}
+
+// RenameLabel fails when another label already has the new name, those are
+// merged instead. Changing only the case of a name is a rename.
+func (s *Store) RenameLabel(projectKey, name, newName string, actorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	id, err := lockLabel(tx, projectKey, name)
+	if err != nil {
+		return err
+	}
+
+	existingID, err := lockLabel(tx, projectKey, newName)
+	if err != nil && !errors.Is(err, ErrLabelNotFound) {
+		return err
+	}
+	if err == nil && existingID != id {
+		return fmt.Errorf("%w: %s, merge the labels instead", ErrLabelExists, newName)
+	}
+
+	before, err := labelIssues(tx, []any{id})
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE labels SET name = ? WHERE id = ?", newName, id); err != nil {
+		return fmt.Errorf("failed to rename label: %v", err)
+	}
+
+	if err := touchIssues(tx, before, actorID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) MergeLabels(projectKey string, names []string, into string, actorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var sourceIDs []any
+	for _, name := range names {
+		id, err := lockLabel(tx, projectKey, name)
+		if err != nil {
+			return err
+		}
+		sourceIDs = append(sourceIDs, id)
+	}
+
+	res, err := tx.Exec("INSERT INTO labels (project_key, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)", projectKey, into)
+	if err != nil {
+		return fmt.Errorf("failed to create label: %v", err)
+	}
+	targetID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get label id: %v", err)
+	}
+
+	// The target may be one of the merged labels, it stays
+	var merged []any
+	for _, id := range sourceIDs {
+		if int64(id.(int)) != targetID {
+			merged = append(merged, id)
+		}
+	}
+	if len(merged) == 0 {
+		return tx.Commit()
+	}
+	list := placeholders(len(merged))
+
+	before, err := labelIssues(tx, merged)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT IGNORE INTO issue_labels (issue_id, label_id) SELECT issue_id, ? FROM issue_labels WHERE label_id IN ("+list+")",
+		append([]any{targetID}, merged...)...)
+	if err != nil {
+		return fmt.Errorf("failed to move issues to label %s: %v", into, err)
+	}
+
+	// Deleting the labels drops their issue links as well
+	if _, err := tx.Exec("DELETE FROM labels WHERE id IN ("+list+")", merged...); err != nil {
+		return fmt.Errorf("failed to delete merged labels: %v", err)
+	}
+
+	if err := touchIssues(tx, before, actorID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// DeleteLabel removes the label from the project and all its issues.
+func (s *Store) DeleteLabel(projectKey, name string, actorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	id, err := lockLabel(tx, projectKey, name)
+	if err != nil {
+		return err
+	}
+
+	before, err := labelIssues(tx, []any{id})
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM labels WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete label: %v", err)
+	}
+
+	if err := touchIssues(tx, before, actorID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+func lockLabel(tx *sql.Tx, projectKey, name string) (int, error) {
+	var id int
+	err := tx.QueryRow("SELECT id FROM labels WHERE project_key = ? AND name = ? FOR UPDATE", projectKey, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch label: %v", err)
+	}
+	return id, nil
+}
+
+// labelIssues locks the issues carrying any of the labels and returns their
+// labels before the change, for touchIssues.
+func labelIssues(tx *sql.Tx, labelIDs []any) (map[int]string, error) {
+	rows, err := tx.Query("SELECT DISTINCT i.id FROM issues i JOIN issue_labels il ON il.issue_id = i.id WHERE il.label_id IN ("+placeholders(len(labelIDs))+") FOR UPDATE", labelIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock labelled issues: %v", err)
+	}
+	defer rows.Close()
+
+	var issueIDs []any
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan issue id: %v", err)
+		}
+		issueIDs = append(issueIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return issueLabels(tx, issueIDs)
+}
+
+// issueLabels returns the labels of each issue the way the issue history
+// records them, sorted and joined.
+func issueLabels(tx *sql.Tx, issueIDs []any) (map[int]string, error) {
+	labels := map[int]string{}
+	if len(issueIDs) == 0 {
+		return labels, nil
+	}
+
+	rows, err := tx.Query("SELECT il.issue_id, l.name FROM issue_labels il JOIN labels l ON l.id = il.label_id WHERE il.issue_id IN ("+placeholders(len(issueIDs))+")", issueIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issue labels: %v", err)
+	}
+	defer rows.Close()
+
+	names := map[int][]string{}
+	for _, id := range issueIDs {
+		names[id.(int)] = nil
+	}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan issue label: %v", err)
+		}
+		names[id] = append(names[id], name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	for id, list := range names {
+		sort.Slice(list, func(a, b int) bool {
+			return strings.ToLower(list[a]) < strings.ToLower(list[b])
+		})
+		labels[id] = strings.Join(list, ", ")
+	}
+	return labels, nil
+}
+
+// touchIssues bumps the version of the issues whose labels changed since
+// before, so stale edits of them conflict, and records the change in their
+// history.
+func touchIssues(tx *sql.Tx, before map[int]string, actorID int) error {
+	if len(before) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(before))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var issueIDs []any
+	for _, id := range ids {
+		issueIDs = append(issueIDs, id)
+	}
+	after, err := issueLabels(tx, issueIDs)
+	if err != nil {
+		return err
+	}
+
+	var changed []any
+	for _, id := range issueIDs {
+		old, updated := before[id.(int)], after[id.(int)]
+		if old == updated {
+			continue
+		}
+		changed = append(changed, id)
+
+		_, err := tx.Exec("INSERT INTO issue_history (issue_id, actor_id, field, old_value, new_value) VALUES (?, ?, 'labels', ?, ?)",
+			id, nullableID(actorID), old, updated)
+		if err != nil {
+			return fmt.Errorf("failed to record issue history: %v", err)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec("UPDATE issues SET version = version + 1, updatedAt = NOW() WHERE id IN ("+placeholders(len(changed))+")", changed...); err != nil {
+		return fmt.Errorf("failed to update labelled issues: %v", err)
+	}
+	return nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
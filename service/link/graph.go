@@ -0,0 +1,118 @@
+package link
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// FindCycles returns the groups of issues that block each other, directly
+// or through other issues. Only blocks links are dependencies, each group
+// lists its issue ids in ascending order and groups are ordered by their
+// first id.
+func FindCycles(edges []types.GraphEdge) [][]int {
+	next := make(map[int][]int)
+	var nodes []int
+	for _, e := range edges {
+		if e.Type != types.LinkBlocks {
+			continue
+		}
+		for _, id := range []int{e.From, e.To} {
+			if _, ok := next[id]; !ok {
+				next[id] = nil
+				nodes = append(nodes, id)
+			}
+		}
+		next[e.From] = append(next[e.From], e.To)
+	}
+	sort.Ints(nodes)
+
+	// Tarjan's algorithm, every strongly connected component of more than
+	// one issue is a cycle
+	t := tarjan{next: next, index: make(map[int]int), low: make(map[int]int), onStack: make(map[int]bool)}
+	for _, id := range nodes {
+		if _, visited := t.index[id]; !visited {
+			t.visit(id)
+		}
+	}
+
+	sort.Slice(t.cycles, func(a, b int) bool {
+		return t.cycles[a][0] < t.cycles[b][0]
+	})
+	return t.cycles
+}
+
+type tarjan struct {
+	next    map[int][]int
+	index   map[int]int
+	low     map[int]int
+	onStack map[int]bool
+	stack   []int
+	cycles  [][]int
+}
+
+func (t *tarjan) visit(id int) {
+	t.index[id] = len(t.index)
+	t.low[id] = t.index[id]
+	t.stack = append(t.stack, id)
+	t.onStack[id] = true
+
+	for _, to := range t.next[id] {
+		if _, visited := t.index[to]; !visited {
+			t.visit(to)
+			t.low[id] = min(t.low[id], t.low[to])
+		} else if t.onStack[to] {
+			t.low[id] = min(t.low[id], t.index[to])
+		}
+	}
+
+	if t.low[id] != t.index[id] {
+		return
+	}
+
+	var component []int
+	for {
+		top := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[top] = false
+		component = append(component, top)
+		if top == id {
+			break
+		}
+	}
+	if len(component) > 1 {
+		sort.Ints(component)
+		t.cycles = append(t.cycles, component)
+	}
+}
+
+// visibleGraph leaves out the issues the user can't see, with their edges
+// and the cycles they are part of. Linked issues may belong to other
+// projects of a scope.
+func visibleGraph(graph *types.DependencyGraph, canView func(projectKey string) (bool, error)) error {
+	hidden := make(map[int]bool)
+	hiddenKeys := make(map[string]bool)
+	nodes := []types.LinkedIssue{}
+	for _, node := range graph.Nodes {
+		ok, err := canView(node.ProjectKey)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			hidden[node.ID] = true
+			hiddenKeys[node.Key] = true
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	graph.Nodes = nodes
+
+	graph.Edges = slices.DeleteFunc(graph.Edges, func(e types.GraphEdge) bool {
+		return hidden[e.From] || hidden[e.To]
+	})
+	graph.Cycles = slices.DeleteFunc(graph.Cycles, func(cycle []string) bool {
+		return slices.ContainsFunc(cycle, func(key string) bool { return hiddenKeys[key] })
+	})
+	return nil
+}
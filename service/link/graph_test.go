@@ -0,0 +1,62 @@
+package link
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestFindCycles(t *testing.T) {
+	t.Run("should find every group of issues blocking each other", func(t *testing.T) {
+		edges := []types.GraphEdge{
+			{From: 1, To: 2, Type: types.LinkBlocks},
+			{From: 2, To: 3, Type: types.LinkBlocks},
+			{From: 3, To: 1, Type: types.LinkBlocks},
+			{From: 3, To: 4, Type: types.LinkBlocks},
+			{From: 5, To: 6, Type: types.LinkBlocks},
+			{From: 6, To: 5, Type: types.LinkBlocks},
+		}
+		if cycles := FindCycles(edges); !reflect.DeepEqual(cycles, [][]int{{1, 2, 3}, {5, 6}}) {
+			t.Errorf("unexpected cycles %v", cycles)
+		}
+	})
+
+	t.Run("should ignore links that are not dependencies", func(t *testing.T) {
+		edges := []types.GraphEdge{
+			{From: 1, To: 2, Type: types.LinkBlocks},
+			{From: 2, To: 1, Type: types.LinkRelates},
+			{From: 2, To: 3, Type: types.LinkDuplicates},
+			{From: 3, To: 2, Type: types.LinkClones},
+		}
+		if cycles := FindCycles(edges); len(cycles) != 0 {
+			t.Errorf("expected no cycles, got %v", cycles)
+		}
+	})
+}
+
+func TestVisibleGraph(t *testing.T) {
+	t.Run("should leave out hidden issues with their edges and cycles", func(t *testing.T) {
+		graph := &types.DependencyGraph{
+			Nodes: []types.LinkedIssue{
+				{ID: 1, Key: "PRJ-1", ProjectKey: "PRJ"},
+				{ID: 2, Key: "PRJ-2", ProjectKey: "PRJ"},
+				{ID: 3, Key: "HR-1", ProjectKey: "HR"},
+			},
+			Edges: []types.GraphEdge{
+				{From: 1, To: 2, Type: types.LinkBlocks},
+				{From: 2, To: 3, Type: types.LinkBlocks},
+				{From: 3, To: 1, Type: types.LinkBlocks},
+			},
+			Cycles: [][]string{{"PRJ-1", "PRJ-2", "HR-1"}},
+		}
+		canView := func(projectKey string) (bool, error) { return projectKey == "PRJ", nil }
+
+		if err := visibleGraph(graph, canView); err != nil {
+			t.Fatal(err)
+		}
+		if len(graph.Nodes) != 2 || !reflect.DeepEqual(graph.Edges, []types.GraphEdge{{From: 1, To: 2, Type: types.LinkBlocks}}) || len(graph.Cycles) != 0 {
+			t.Errorf("unexpected graph %+v", graph)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package link
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type Handler struct {
+	store  types.LinkStore
+	issues types.IssueStore
+	roles  types.RoleStore
+}
+
+func NewHandler(store types.LinkStore, issues types.IssueStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, issues: issues, roles: roles}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/issues/{id}/links", h.handleGetLinks).Methods("GET")
+	router.HandleFunc("/issues/{id}/links", h.handleCreateLink).Methods("POST")
+	router.HandleFunc("/issues/{id}/links/{link_id}", h.handleDeleteLink).Methods("DELETE")
+	router.HandleFunc("/projects/{key}/dependency-graph", h.handleGetDependencyGraph).Methods("GET")
+}
+
+func (h *Handler) handleGetLinks(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.getIssue(w, r, auth.PermViewIssues)
+	if !ok {
+		return
+	}
+
+	links, err := h.visibleLinks(r, issue.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"issue_id": issue.ID,
+		"key":      issue.Key,
+		"links":    links,
+	})
+}
+
+// handleCreateLink links the issue to the issue named in the payload, which
+// the user has to be able to see.
+func (h *Handler) handleCreateLink(w http.ResponseWriter, r *http.Request) {
+	issue, ok := h.getIssue(w, r, auth.PermEditIssue)
+	if !ok {
+		return
+	}
+
+	var payload types.LinkPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	linked, err := h.store.GetLinkedIssue(strings.TrimSpace(payload.Issue))
+	if err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, linked.ProjectKey, auth.PermViewIssues) {
+		return
+	}
+
+	if linked.ID == issue.ID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("an issue can't be linked to itself"))
+		return
+	}
+
+	id, err := h.store.CreateLink(issue.ID, linked.ID, payload.Type, auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	links, err := h.visibleLinks(r, issue.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]any{
+		"message": "Link created successfully",
+		"id":      id,
+		"links":   links,
+	})
+}
+
+func (h *Handler) handleDeleteLink(w http.ResponseWriter, r *http.Request) {
+	linkID, err := strconv.Atoi(mux.Vars(r)["link_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid link ID"))
+		return
+	}
+
+	issue, ok := h.getIssue(w, r, auth.PermEditIssue)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteLink(issue.ID, linkID); err != nil {
+		utils.WriteError(w, storeErrorStatus(err), err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Link deleted successfully",
+	})
+}
+
+func (h *Handler) handleGetDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	graph, err := h.store.GetDependencyGraph(projectKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := visibleGraph(graph, auth.ProjectViewer(h.roles, auth.GetUserIDFromContext(r.Context()))); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, graph)
+}
+
+// visibleLinks returns the links of the issue to issues the user can see.
+func (h *Handler) visibleLinks(r *http.Request, issueID int) ([]types.IssueLink, error) {
+	links, err := h.store.GetLinks(issueID)
+	if err != nil {
+		return nil, err
+	}
+	return issue.VisibleLinks(links, auth.ProjectViewer(h.roles, auth.GetUserIDFromContext(r.Context())))
+}
+
+// getIssue fetches the issue of the route and checks the user has the
+// permission on its project.
+func (h *Handler) getIssue(w http.ResponseWriter, r *http.Request, perm auth.Permission) (*types.Issue, bool) {
+	issueID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid issue ID"))
+		return nil, false
+	}
+
+	issue, err := h.issues.GetIssueByID(issueID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("issue not found"))
+		return nil, false
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, issue.ProjectKey, perm) {
+		return nil, false
+	}
+	return issue, true
+}
+
+// storeErrorStatus reports issues that can't be linked as bad requests and
+// links that already exist as conflicts.
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrLinkNotAllowed), errors.Is(err, ErrIssueNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrLinkExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrLinkNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,321 @@
+package link
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestLinkServiceHandlers(t *testing.T) {
+	store := &mockLinkStore{
+		issues: []types.LinkedIssue{
+			{ID: 1, Key: "PRJ-1", ProjectKey: "PRJ", Status: "open", StatusCategory: "todo"},
+			{ID: 2, Key: "PRJ-2", ProjectKey: "PRJ", Status: "open", StatusCategory: "todo"},
+			{ID: 3, Key: "API-1", ProjectKey: "API", Status: "open", StatusCategory: "todo"},
+			{ID: 4, Key: "OPS-1", ProjectKey: "OPS", Status: "open", StatusCategory: "todo"},
+			{ID: 5, Key: "HR-1", ProjectKey: "HR", Status: "open", StatusCategory: "todo"},
+		},
+		scopes: map[string]bool{"PRJ": true, "API": true},
+	}
+	issues := &mockIssueStore{store: store}
+	roles := &mockRoleStore{roles: map[int]map[string]string{
+		1: {"PRJ": "member", "API": "viewer", "OPS": "member"},
+		2: {"PRJ": "viewer"},
+	}}
+	handler := NewHandler(store, issues, roles)
+
+	t.Run("Create Link", func(t *testing.T) {
+		t.Run("should link issues from both sides", func(t *testing.T) {
+			rr := testRequest(t, handler, 1, http.MethodPost, "/issues/1/links", types.LinkPayload{Type: "blocks", Issue: "PRJ-2"}, http.StatusCreated)
+
+			var resp struct {
+				Links []types.IssueLink `json:"links"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Links) != 1 || resp.Links[0].Description != "blocks" || resp.Links[0].Issue.Key != "PRJ-2" {
+				t.Errorf("unexpected links %+v", resp.Links)
+			}
+
+			links, _ := store.GetLinks(2)
+			if len(links) != 1 || links[0].Description != "is blocked by" || links[0].Issue.Key != "PRJ-1" {
+				t.Errorf("unexpected links of the blocked issue %+v", links)
+			}
+		})
+
+		t.Run("should link issues of projects in the same scope", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/links", types.LinkPayload{Type: "relates", Issue: "API-1"}, http.StatusCreated)
+		})
+
+		t.Run("should fail across projects without a shared scope", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/links", types.LinkPayload{Type: "relates", Issue: "OPS-1"}, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if the link exists", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/links", types.LinkPayload{Type: "blocks", Issue: "PRJ-2"}, http.StatusConflict)
+		})
+
+		t.Run("should fail on an invalid payload", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/links", types.LinkPayload{Type: "causes", Issue: "PRJ-2"}, http.StatusBadRequest)
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/links", types.LinkPayload{Type: "blocks", Issue: "PRJ-1"}, http.StatusBadRequest)
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/links", types.LinkPayload{Type: "blocks", Issue: "PRJ-99"}, http.StatusBadRequest)
+		})
+
+		t.Run("should be forbidden if the linked issue can't be seen", func(t *testing.T) {
+			testRequest(t, handler, 1, http.MethodPost, "/issues/1/links", types.LinkPayload{Type: "relates", Issue: "HR-1"}, http.StatusForbidden)
+		})
+
+		t.Run("should be forbidden for viewers", func(t *testing.T) {
+			testRequest(t, handler, 2, http.MethodPost, "/issues/2/links", types.LinkPayload{Type: "clones", Issue: "PRJ-1"}, http.StatusForbidden)
+		})
+	})
+
+	t.Run("Get Links", func(t *testing.T) {
+		getLinks := func(t *testing.T, userID int) []types.IssueLink {
+			rr := testRequest(t, handler, userID, http.MethodGet, "/issues/1/links", nil, http.StatusOK)
+
+			var resp struct {
+				Links []types.IssueLink `json:"links"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			return resp.Links
+		}
+
+		t.Run("should list the links", func(t *testing.T) {
+			if links := getLinks(t, 1); len(links) != 2 {
+				t.Errorf("expected two links, got %+v", links)
+			}
+		})
+
+		t.Run("should leave out issues of projects the user can't see", func(t *testing.T) {
+			if links := getLinks(t, 2); len(links) != 1 || links[0].Issue.Key != "PRJ-2" {
+				t.Errorf("expected only the link to PRJ-2, got %+v", links)
+			}
+		})
+	})
+
+	t.Run("Get Dependency Graph", func(t *testing.T) {
+		testRequest(t, handler, 1, http.MethodPost, "/issues/2/links", types.LinkPayload{Type: "blocks", Issue: "PRJ-1"}, http.StatusCreated)
+
+		getGraph := func(t *testing.T, userID int) types.DependencyGraph {
+			rr := testRequest(t, handler, userID, http.MethodGet, "/projects/PRJ/dependency-graph", nil, http.StatusOK)
+
+			var graph types.DependencyGraph
+			if err := json.NewDecoder(rr.Body).Decode(&graph); err != nil {
+				t.Fatal(err)
+			}
+			return graph
+		}
+
+		graph := getGraph(t, 1)
+		if len(graph.Nodes) != 3 || len(graph.Edges) != 3 || len(graph.Cycles) != 1 || len(graph.Cycles[0]) != 2 {
+			t.Errorf("unexpected graph %+v", graph)
+		}
+
+		// API-1 is linked, but user 2 can't see the API project
+		graph = getGraph(t, 2)
+		if len(graph.Nodes) != 2 || len(graph.Edges) != 2 || len(graph.Cycles) != 1 {
+			t.Errorf("expected the graph without API-1, got %+v", graph)
+		}
+		for _, node := range graph.Nodes {
+			if node.ProjectKey == "API" {
+				t.Errorf("expected API-1 to be left out, got %+v", node)
+			}
+		}
+
+		testRequest(t, handler, 2, http.MethodGet, "/projects/OPS/dependency-graph", nil, http.StatusForbidden)
+	})
+
+	t.Run("Delete Link", func(t *testing.T) {
+		links, _ := store.GetLinks(2)
+		testRequest(t, handler, 1, http.MethodDelete, fmt.Sprintf("/issues/2/links/%d", links[0].ID), nil, http.StatusOK)
+
+		if links, _ := store.GetLinks(1); len(links) != 2 {
+			t.Errorf("expected the link to be gone from both sides, got %+v", links)
+		}
+
+		testRequest(t, handler, 1, http.MethodDelete, fmt.Sprintf("/issues/2/links/%d", links[0].ID), nil, http.StatusNotFound)
+		testRequest(t, handler, 2, http.MethodDelete, "/issues/1/links/1", nil, http.StatusForbidden)
+	})
+}
+
+// testRequest - Helper function to perform HTTP requests as a user and check the response
+func testRequest(t testing.TB, handler *Handler, userID int, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body []byte
+	if payload != nil {
+		marshalled, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = marshalled
+	}
+
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, userID))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+		fmt.Printf("Response Body: %s\n", rr.Body.String())
+	}
+
+	return rr
+}
+
+// mockLinkStore - Mock implementation of the link store, links are kept from both sides
+type mockLinkStore struct {
+	issues []types.LinkedIssue
+	// scopes holds the projects sharing a scope
+	scopes map[string]bool
+	links  []mockLink
+	nextID int
+}
+
+type mockLink struct {
+	id, issueID, linkedIssueID int
+	linkType, direction        string
+}
+
+func (m *mockLinkStore) issue(id int) *types.LinkedIssue {
+	for n := range m.issues {
+		if m.issues[n].ID == id {
+			return &m.issues[n]
+		}
+	}
+	return nil
+}
+
+func (m *mockLinkStore) GetLinks(issueID int) ([]types.IssueLink, error) {
+	links := []types.IssueLink{}
+	for _, l := range m.links {
+		if l.issueID == issueID {
+			links = append(links, types.IssueLink{
+				ID:          l.id,
+				Type:        l.linkType,
+				Direction:   l.direction,
+				Description: issue.LinkDescription(l.linkType, l.direction),
+				Issue:       *m.issue(l.linkedIssueID),
+				CreatedAt:   time.Now(),
+			})
+		}
+	}
+	return links, nil
+}
+
+func (m *mockLinkStore) GetLinkedIssue(key string) (*types.LinkedIssue, error) {
+	for _, i := range m.issues {
+		if i.Key == key {
+			return &i, nil
+		}
+	}
+	return nil, fmt.Errorf("%w, %s", ErrIssueNotFound, key)
+}
+
+func (m *mockLinkStore) CreateLink(issueID, linkedIssueID int, linkType string, actorID int) (int, error) {
+	from, to := m.issue(issueID), m.issue(linkedIssueID)
+	if from.ProjectKey != to.ProjectKey && !(m.scopes[from.ProjectKey] && m.scopes[to.ProjectKey]) {
+		return 0, fmt.Errorf("%w, projects %s and %s share no scope", ErrLinkNotAllowed, from.ProjectKey, to.ProjectKey)
+	}
+	for _, l := range m.links {
+		if l.issueID == issueID && l.linkedIssueID == linkedIssueID && l.linkType == linkType && l.direction == "outward" {
+			return 0, ErrLinkExists
+		}
+	}
+	m.links = append(m.links,
+		mockLink{id: m.nextID + 1, issueID: issueID, linkedIssueID: linkedIssueID, linkType: linkType, direction: "outward"},
+		mockLink{id: m.nextID + 2, issueID: linkedIssueID, linkedIssueID: issueID, linkType: linkType, direction: "inward"})
+	m.nextID += 2
+	return m.nextID - 1, nil
+}
+
+func (m *mockLinkStore) DeleteLink(issueID, linkID int) error {
+	for _, l := range m.links {
+		if l.id == linkID && l.issueID == issueID {
+			var kept []mockLink
+			for _, other := range m.links {
+				mirrored := other.issueID == l.linkedIssueID && other.linkedIssueID == l.issueID && other.linkType == l.linkType && other.direction != l.direction
+				if other.id != linkID && !mirrored {
+					kept = append(kept, other)
+				}
+			}
+			m.links = kept
+			return nil
+		}
+	}
+	return fmt.Errorf("%w, issue %d has no link %d", ErrLinkNotFound, issueID, linkID)
+}
+
+func (m *mockLinkStore) GetDependencyGraph(projectKey string) (*types.DependencyGraph, error) {
+	graph := &types.DependencyGraph{ProjectKey: projectKey, Nodes: []types.LinkedIssue{}, Edges: []types.GraphEdge{}, Cycles: [][]string{}}
+	for _, l := range m.links {
+		if l.direction == "outward" && (m.issue(l.issueID).ProjectKey == projectKey || m.issue(l.linkedIssueID).ProjectKey == projectKey) {
+			graph.Edges = append(graph.Edges, types.GraphEdge{From: l.issueID, To: l.linkedIssueID, Type: l.linkType})
+		}
+	}
+	for _, i := range m.issues {
+		for _, e := range graph.Edges {
+			if e.From == i.ID || e.To == i.ID {
+				graph.Nodes = append(graph.Nodes, i)
+				break
+			}
+		}
+	}
+	for _, cycle := range FindCycles(graph.Edges) {
+		var keys []string
+		for _, id := range cycle {
+			keys = append(keys, m.issue(id).Key)
+		}
+		graph.Cycles = append(graph.Cycles, keys)
+	}
+	return graph, nil
+}
+
+// mockIssueStore - Mock implementation of the issue store, backed by the issues of the link store
+type mockIssueStore struct {
+	types.IssueStore
+	store *mockLinkStore
+}
+
+func (m *mockIssueStore) GetIssueByID(id int) (*types.Issue, error) {
+	i := m.store.issue(id)
+	if i == nil {
+		return nil, fmt.Errorf("issue not found")
+	}
+	return &types.Issue{ID: i.ID, Key: i.Key, ProjectKey: i.ProjectKey, Status: i.Status}, nil
+}
+
+// mockRoleStore - Mock implementation of the role store, roles per user and project
+type mockRoleStore struct {
+	roles map[int]map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[userID][projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,232 @@
+package link
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+var (
+	ErrLinkExists     = errors.New("link already exists")
+	ErrLinkNotFound   = errors.New("link not found")
+	ErrLinkNotAllowed = errors.New("link not allowed")
+	ErrIssueNotFound  = errors.New("issue not found")
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetLinks(issueID int) ([]types.IssueLink, error) {
+	return issue.GetLinks(s.db, issueID)
+}
+
+func (s *Store) GetLinkedIssue(key string) (*types.LinkedIssue, error) {
+	i, err := issue.ScanLinkedIssue(s.db.QueryRow("SELECT "+issue.LinkedIssueColumns+" FROM issues i "+issue.LinkedIssueStatus+" WHERE i.`key` = ?", key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w, %s", ErrIssueNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to fetch issue %s: %v", key, err)
+	}
+	return &i, nil
+}
+
+// CreateLink locks both issues, so neither can move to a project outside
+// the scope while it is linked.
+func (s *Store) CreateLink(issueID, linkedIssueID int, linkType string, actorID int) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	projects := make(map[int]string)
+	rows, err := tx.Query("SELECT id, project_key FROM issues WHERE id IN (?, ?) FOR SHARE", issueID, linkedIssueID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch issues: %v", err)
+	}
+	for rows.Next() {
+		var id int
+		var projectKey string
+		if err := rows.Scan(&id, &projectKey); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan issue: %v", err)
+		}
+		projects[id] = projectKey
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error after iterating issues: %v", err)
+	}
+	for _, id := range []int{issueID, linkedIssueID} {
+		if _, ok := projects[id]; !ok {
+			return 0, fmt.Errorf("%w, issue %d", ErrIssueNotFound, id)
+		}
+	}
+
+	if projects[issueID] != projects[linkedIssueID] {
+		var shared bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM project_scope a JOIN project_scope b ON b.scope_id = a.scope_id WHERE a.project_key = ? AND b.project_key = ?)",
+			projects[issueID], projects[linkedIssueID]).Scan(&shared)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check scopes: %v", err)
+		}
+		if !shared {
+			return 0, fmt.Errorf("%w, projects %s and %s share no scope", ErrLinkNotAllowed, projects[issueID], projects[linkedIssueID])
+		}
+	}
+
+	// Links that read the same from both sides exist once, whichever side
+	// they were created from
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM issue_links WHERE issue_id = ? AND linked_issue_id = ? AND link_type = ? AND (direction = 'outward' OR link_type = ?))",
+		issueID, linkedIssueID, linkType, types.LinkRelates).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check link: %v", err)
+	}
+	if exists {
+		return 0, ErrLinkExists
+	}
+
+	res, err := tx.Exec("INSERT INTO issue_links (issue_id, linked_issue_id, link_type, direction, created_by) VALUES (?, ?, ?, 'outward', ?)",
+		issueID, linkedIssueID, linkType, nullableID(actorID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert link: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get link id: %v", err)
+	}
+
+	_, err = tx.Exec("INSERT INTO issue_links (issue_id, linked_issue_id, link_type, direction, created_by) VALUES (?, ?, ?, 'inward', ?)",
+		linkedIssueID, issueID, linkType, nullableID(actorID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert link: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) DeleteLink(issueID, linkID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var linkedIssueID int
+	var linkType, direction string
+	err = tx.QueryRow("SELECT linked_issue_id, link_type, direction FROM issue_links WHERE id = ? AND issue_id = ? FOR UPDATE", linkID, issueID).Scan(&linkedIssueID, &linkType, &direction)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w, issue %d has no link %d", ErrLinkNotFound, issueID, linkID)
+		}
+		return fmt.Errorf("failed to fetch link: %v", err)
+	}
+
+	mirrored := "outward"
+	if direction == "outward" {
+		mirrored = "inward"
+	}
+	_, err = tx.Exec("DELETE FROM issue_links WHERE (id = ?) OR (issue_id = ? AND linked_issue_id = ? AND link_type = ? AND direction = ?)",
+		linkID, linkedIssueID, issueID, linkType, mirrored)
+	if err != nil {
+		return fmt.Errorf("failed to delete link: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// GetDependencyGraph returns the links touching issues of the project, an
+// issue without links is not part of the graph.
+func (s *Store) GetDependencyGraph(projectKey string) (*types.DependencyGraph, error) {
+	graph := &types.DependencyGraph{ProjectKey: projectKey, Nodes: []types.LinkedIssue{}, Edges: []types.GraphEdge{}, Cycles: [][]string{}}
+
+	rows, err := s.db.Query(`
+		SELECT l.issue_id, l.linked_issue_id, l.link_type
+		FROM issue_links l
+		JOIN issues a ON a.id = l.issue_id
+		JOIN issues b ON b.id = l.linked_issue_id
+		WHERE l.direction = 'outward' AND (a.project_key = ? OR b.project_key = ?)
+		ORDER BY l.id`, projectKey, projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []any
+	seen := make(map[int]bool)
+	for rows.Next() {
+		var e types.GraphEdge
+		if err := rows.Scan(&e.From, &e.To, &e.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan link: %v", err)
+		}
+		graph.Edges = append(graph.Edges, e)
+		for _, id := range []int{e.From, e.To} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating links: %v", err)
+	}
+
+	if len(ids) == 0 {
+		return graph, nil
+	}
+
+	nodes, err := s.db.Query("SELECT "+issue.LinkedIssueColumns+" FROM issues i "+issue.LinkedIssueStatus+
+		" WHERE i.id IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")+") ORDER BY i.id", ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query linked issues: %v", err)
+	}
+	defer nodes.Close()
+
+	keys := make(map[int]string)
+	for nodes.Next() {
+		node, err := issue.ScanLinkedIssue(nodes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan linked issue: %v", err)
+		}
+		graph.Nodes = append(graph.Nodes, node)
+		keys[node.ID] = node.Key
+	}
+	if err := nodes.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating linked issues: %v", err)
+	}
+
+	for _, cycle := range FindCycles(graph.Edges) {
+		names := make([]string, len(cycle))
+		for i, id := range cycle {
+			names[i] = keys[id]
+		}
+		graph.Cycles = append(graph.Cycles, names)
+	}
+
+	return graph, nil
+}
+
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// New returns the mailer selected by config.Envs.MailDriver.
+func New() (types.Mailer, error) {
+	switch config.Envs.MailDriver {
+	case "smtp":
+		return NewSMTPMailer(config.Envs.SMTPHost, config.Envs.SMTPPort, config.Envs.SMTPUsername, config.Envs.SMTPPassword, config.Envs.MailFrom), nil
+	case "log", "":
+		return NewLogMailer(config.Envs.MailLogDir, config.Envs.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", config.Envs.MailDriver)
+	}
+}
+
+// SMTPMailer delivers emails through an SMTP server.
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	if err := smtp.SendMail(m.addr, auth, m.from, []string{to}, buildMessage(m.from, to, subject, body)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %v", to, err)
+	}
+	return nil
+}
+
+// LogMailer is meant for local development. It writes every email to a file
+// in dir, or to the application log when dir is empty.
+type LogMailer struct {
+	dir  string
+	from string
+}
+
+func NewLogMailer(dir, from string) *LogMailer {
+	return &LogMailer{dir: dir, from: from}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+func (m *LogMailer) Send(to, subject, body string) error {
+	msg := buildMessage(m.from, to, subject, body)
+
+	if m.dir == "" {
+		log.Printf("email to %s:\n%s", to, msg)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %v", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(to, "_"))
+	if err := os.WriteFile(filepath.Join(m.dir, name), msg, 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %v", err)
+	}
+	return nil
+}
+
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return []byte(b.String())
+}
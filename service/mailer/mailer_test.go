@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLogMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := NewLogMailer(dir, "no-reply@example.com")
+
+	if err := m.Send("user@example.com", "Hello", "Body text"); err != nil {
+		t.Fatalf("error sending email: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 email file, got %d", len(entries))
+	}
+
+	content, err := os.ReadFile(dir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"To: user@example.com", "Subject: Hello", "Body text"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected email to contain %q", want)
+		}
+	}
+}
@@ -6,22 +6,27 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 	"github.com/maximis3d/issue-tracking-system/utils"
 )
 
 type Handler struct {
 	store types.ProjectStore
+	roles types.RoleStore
 }
 
-func NewHandler(store types.ProjectStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.ProjectStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, roles: roles}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/projects", h.handleGetProjects).Methods("GET")
 	router.HandleFunc("/projects/{key}", h.handleGetProjectByKey).Methods("GET")
 	router.HandleFunc("/projects", h.handleCreateProject).Methods("POST")
+	router.HandleFunc("/projects/{key}/wip-limit", h.handleUpdateWIPLimit).Methods("PUT")
+	router.HandleFunc("/projects/{key}/require-2fa", h.handleUpdateRequire2FA).Methods("PUT")
+	router.HandleFunc("/projects/{key}/point-scale", h.handleUpdatePointScale).Methods("PUT")
 }
 
 func (h *Handler) handleGetProjects(w http.ResponseWriter, r *http.Request) {
@@ -82,3 +87,100 @@ func (h *Handler) handleCreateProject(w http.ResponseWriter, r *http.Request) {
 	})
 
 }
+
+func (h *Handler) handleUpdateWIPLimit(w http.ResponseWriter, r *http.Request) {
+	var payload types.WIPLimitPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	if _, err := h.store.GetProjectByKey(key); err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, key, auth.PermChangeWIPLimit) {
+		return
+	}
+
+	if err := h.store.UpdateWIPLimit(key, payload.WIPLimit); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "WIP limit updated successfully",
+	})
+}
+
+// handleUpdateRequire2FA lets the project lead require two-factor
+// authentication from every member of the project.
+func (h *Handler) handleUpdateRequire2FA(w http.ResponseWriter, r *http.Request) {
+	var payload types.Require2FAPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	if _, err := h.store.GetProjectByKey(key); err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, key, auth.PermManageSecurity) {
+		return
+	}
+
+	if err := h.store.UpdateRequire2FA(key, *payload.Required); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Two-factor requirement updated successfully",
+	})
+}
+
+// handleUpdatePointScale sets the story points issues of the project can be
+// given, an empty scale allows any.
+func (h *Handler) handleUpdatePointScale(w http.ResponseWriter, r *http.Request) {
+	var payload types.PointScalePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	if _, err := h.store.GetProjectByKey(key); err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("project not found"))
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, key, auth.PermManageSprints) {
+		return
+	}
+
+	if err := h.store.UpdatePointScale(key, payload.Scale); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Point scale updated successfully",
+	})
+}
@@ -2,6 +2,7 @@ package project
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,12 +10,14 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
 func TestProjectHandlers(t *testing.T) {
 	projectStore := newMockProjectStore()
-	handler := NewHandler(projectStore)
+	roles := &mockRoleStore{roles: map[string]string{"MNT": "maintainer", "MEM": "member", "LED": "lead"}, require2FA: map[string]bool{}}
+	handler := NewHandler(projectStore, roles)
 
 	t.Run("Get Projects", func(t *testing.T) {
 		t.Run("should return empty list if no projects exist", func(t *testing.T) {
@@ -54,6 +57,91 @@ func TestProjectHandlers(t *testing.T) {
 			testRequest(t, handler, http.MethodPost, "/projects", payload, http.StatusBadRequest)
 		})
 	})
+
+	t.Run("Update WIP Limit", func(t *testing.T) {
+		projectStore.CreateProject(types.Project{Name: "MNT", ProjectKey: "MNT", WIPLimit: 3})
+		projectStore.CreateProject(types.Project{Name: "MEM", ProjectKey: "MEM", WIPLimit: 3})
+
+		t.Run("should update the limit for maintainers", func(t *testing.T) {
+			payload := types.WIPLimitPayload{WIPLimit: 5}
+			testRequest(t, handler, http.MethodPut, "/projects/MNT/wip-limit", payload, http.StatusOK)
+		})
+
+		t.Run("should be forbidden for members", func(t *testing.T) {
+			payload := types.WIPLimitPayload{WIPLimit: 5}
+			testRequest(t, handler, http.MethodPut, "/projects/MEM/wip-limit", payload, http.StatusForbidden)
+		})
+
+		t.Run("should fail if payload is invalid", func(t *testing.T) {
+			payload := types.WIPLimitPayload{WIPLimit: 0}
+			testRequest(t, handler, http.MethodPut, "/projects/MNT/wip-limit", payload, http.StatusBadRequest)
+		})
+	})
+
+	t.Run("Update Point Scale", func(t *testing.T) {
+		t.Run("should update the scale for maintainers", func(t *testing.T) {
+			payload := types.PointScalePayload{Scale: []float64{1, 2, 4, 8}}
+			testRequest(t, handler, http.MethodPut, "/projects/MNT/point-scale", payload, http.StatusOK)
+
+			if scale := projectStore.projects["MNT"].PointScale; len(scale) != 4 {
+				t.Errorf("unexpected scale %v", scale)
+			}
+		})
+
+		t.Run("should allow an empty scale", func(t *testing.T) {
+			payload := types.PointScalePayload{Scale: []float64{}}
+			testRequest(t, handler, http.MethodPut, "/projects/MNT/point-scale", payload, http.StatusOK)
+		})
+
+		t.Run("should be forbidden for members", func(t *testing.T) {
+			payload := types.PointScalePayload{Scale: []float64{1, 2, 3}}
+			testRequest(t, handler, http.MethodPut, "/projects/MEM/point-scale", payload, http.StatusForbidden)
+		})
+
+		t.Run("should fail on negative points", func(t *testing.T) {
+			payload := types.PointScalePayload{Scale: []float64{-1, 1, 2}}
+			testRequest(t, handler, http.MethodPut, "/projects/MNT/point-scale", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should return 404 if project does not exist", func(t *testing.T) {
+			payload := types.PointScalePayload{Scale: []float64{1, 2, 3}}
+			testRequest(t, handler, http.MethodPut, "/projects/Unknown/point-scale", payload, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Require 2FA", func(t *testing.T) {
+		projectStore.CreateProject(types.Project{Name: "LED", ProjectKey: "LED", WIPLimit: 3})
+		enabled := true
+
+		t.Run("should be forbidden for maintainers", func(t *testing.T) {
+			payload := types.Require2FAPayload{Required: &enabled}
+			testRequest(t, handler, http.MethodPut, "/projects/MNT/require-2fa", payload, http.StatusForbidden)
+		})
+
+		t.Run("should fail if required is missing", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPut, "/projects/LED/require-2fa", map[string]string{}, http.StatusBadRequest)
+		})
+
+		t.Run("should let the lead require 2FA", func(t *testing.T) {
+			payload := types.Require2FAPayload{Required: &enabled}
+			testRequest(t, handler, http.MethodPut, "/projects/LED/require-2fa", payload, http.StatusOK)
+
+			if !projectStore.projects["LED"].Require2FA {
+				t.Error("expected project to require 2FA")
+			}
+		})
+
+		t.Run("should block members without 2FA", func(t *testing.T) {
+			roles.require2FA["LED"] = true
+
+			payload := types.WIPLimitPayload{WIPLimit: 5}
+			testRequest(t, handler, http.MethodPut, "/projects/LED/wip-limit", payload, http.StatusForbidden)
+
+			// The lead can still turn the requirement off again
+			disabled := false
+			testRequest(t, handler, http.MethodPut, "/projects/LED/require-2fa", types.Require2FAPayload{Required: &disabled}, http.StatusOK)
+		})
+	})
 }
 
 func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) {
@@ -75,6 +163,7 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 		t.Fatal(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
 
 	rr := httptest.NewRecorder()
 	router := mux.NewRouter()
@@ -124,3 +213,47 @@ func (m *mockProjectStore) CreateProject(project types.Project) error {
 	m.projects[project.Name] = project
 	return nil
 }
+
+// mockRoleStore - Mock implementation of the role store, the user never has 2FA enabled
+type mockRoleStore struct {
+	roles      map[string]string
+	require2FA map[string]bool
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return !m.require2FA[projectKey], nil
+}
+
+func (m *mockProjectStore) UpdateWIPLimit(projectKey string, wipLimit int) error {
+	project, exists := m.projects[projectKey]
+	if !exists {
+		return fmt.Errorf("project not found")
+	}
+	project.WIPLimit = wipLimit
+	m.projects[projectKey] = project
+	return nil
+}
+
+func (m *mockProjectStore) UpdatePointScale(projectKey string, scale []float64) error {
+	project, exists := m.projects[projectKey]
+	if !exists {
+		return fmt.Errorf("project not found")
+	}
+	project.PointScale = scale
+	m.projects[projectKey] = project
+	return nil
+}
+
+func (m *mockProjectStore) UpdateRequire2FA(projectKey string, required bool) error {
+	project, exists := m.projects[projectKey]
+	if !exists {
+		return fmt.Errorf("project not found")
+	}
+	project.Require2FA = required
+	m.projects[projectKey] = project
+	return nil
+}
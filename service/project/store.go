@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/service/workflow"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
@@ -16,7 +18,7 @@ func NewStore(db *sql.DB) *Store {
 }
 
 func (s *Store) GetProjects() ([]types.Project, error) {
-	rows, err := s.db.Query("SELECT id, project_key, name, description, project_lead, issue_count from projects")
+	rows, err := s.db.Query("SELECT id, project_key, name, description, project_lead, issue_count, require_2fa, point_scale from projects")
 
 	if err != nil {
 		return nil, err
@@ -42,6 +44,7 @@ func (s *Store) GetProjects() ([]types.Project, error) {
 
 func scanRowsIntoProjects(rows *sql.Rows) (types.Project, error) {
 	var project types.Project
+	var pointScale string
 
 	err := rows.Scan(
 		&project.ID,
@@ -50,18 +53,24 @@ func scanRowsIntoProjects(rows *sql.Rows) (types.Project, error) {
 		&project.Description,
 		&project.ProjectLead,
 		&project.IssueCount,
+		&project.Require2FA,
+		&pointScale,
 	)
 	if err != nil {
 		return types.Project{}, err
 	}
+	if project.PointScale, err = issue.ParsePointScale(pointScale); err != nil {
+		return types.Project{}, err
+	}
 	return project, nil
 }
 
 func (s *Store) GetProjectByKey(key string) (*types.Project, error) {
 	project := new(types.Project)
+	var pointScale string
 
 	err := s.db.QueryRow(`
-        SELECT id, project_key, name, description, project_lead, issue_count, created_at
+        SELECT id, project_key, name, description, project_lead, issue_count, require_2fa, point_scale, created_at
         FROM projects
         WHERE project_key = ?`, key).
 		Scan(
@@ -71,6 +80,8 @@ func (s *Store) GetProjectByKey(key string) (*types.Project, error) {
 			&project.Description,
 			&project.ProjectLead,
 			&project.IssueCount,
+			&project.Require2FA,
+			&pointScale,
 			&project.CreatedAt,
 		)
 
@@ -81,6 +92,10 @@ func (s *Store) GetProjectByKey(key string) (*types.Project, error) {
 		return nil, err
 	}
 
+	if project.PointScale, err = issue.ParsePointScale(pointScale); err != nil {
+		return nil, err
+	}
+
 	return project, nil
 }
 
@@ -89,13 +104,28 @@ func (s *Store) CreateProject(project types.Project) error {
 		return fmt.Errorf("project lead with id %d does not exist", project.ProjectLead)
 	}
 
-	_, err := s.db.Exec(`
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
         INSERT INTO projects (project_key, name, description, project_lead, wip_limit) VALUES (?, ?, ?, ?, ?)`,
 		project.ProjectKey, project.Name, project.Description, project.ProjectLead, project.WIPLimit,
 	)
 	if err != nil {
 		return fmt.Errorf("error inserting project: %w", err)
 	}
+
+	// New projects start with the default workflow
+	if err := workflow.InsertWorkflow(tx, workflow.Default(project.ProjectKey)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
@@ -107,3 +137,29 @@ func projectLeadExists(db *sql.DB, userID int) bool {
 	}
 	return exists
 }
+
+func (s *Store) UpdateWIPLimit(projectKey string, wipLimit int) error {
+	_, err := s.db.Exec("UPDATE projects SET wip_limit = ? WHERE project_key = ?", wipLimit, projectKey)
+	if err != nil {
+		return fmt.Errorf("error updating wip limit: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) UpdateRequire2FA(projectKey string, required bool) error {
+	_, err := s.db.Exec("UPDATE projects SET require_2fa = ? WHERE project_key = ?", required, projectKey)
+	if err != nil {
+		return fmt.Errorf("error updating two-factor requirement: %w", err)
+	}
+	return nil
+}
+
+// UpdatePointScale only affects points given from now on, issues keep the
+// points they have until those are changed.
+func (s *Store) UpdatePointScale(projectKey string, scale []float64) error {
+	_, err := s.db.Exec("UPDATE projects SET point_scale = ? WHERE project_key = ?", issue.FormatPointScale(scale), projectKey)
+	if err != nil {
+		return fmt.Errorf("error updating point scale: %w", err)
+	}
+	return nil
+}
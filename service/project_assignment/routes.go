@@ -6,16 +6,18 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 	"github.com/maximis3d/issue-tracking-system/utils"
 )
 
 type Handler struct {
 	store types.ProjectAssignmentStore
+	roles types.RoleStore
 }
 
-func NewHandler(store types.ProjectAssignmentStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.ProjectAssignmentStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, roles: roles}
 }
 
 // RegisterRoutes registers all routes related to project assignments.
@@ -49,7 +51,16 @@ func (h *Handler) handleAssignUser(w http.ResponseWriter, r *http.Request) {
 	// Optional: Get the role from query params or request body
 	role := r.URL.Query().Get("role")
 	if role == "" {
-		role = "member" // Default to "member"
+		role = auth.RoleMember // Default to "member"
+	}
+
+	if !auth.IsValidRole(role) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid role %q, expected one of viewer, member, maintainer or lead", role))
+		return
+	}
+
+	if !h.requirePermission(w, r, projectID, auth.PermManageMembers) {
+		return
 	}
 
 	// Attempt to assign user to the project
@@ -74,6 +85,10 @@ func (h *Handler) handleGetAssignedUsers(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !h.requirePermission(w, r, projectID, auth.PermViewIssues) {
+		return
+	}
+
 	// Get all users assigned to the project
 	users, err := h.store.GetUsersForProject(projectID)
 	if err != nil {
@@ -119,6 +134,10 @@ func (h *Handler) handleRemoveUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.requirePermission(w, r, projectID, auth.PermManageMembers) {
+		return
+	}
+
 	// Check if the user is assigned to the project
 	assigned, err := h.store.IsUserAssignedToProject(projectID, userID)
 	if err != nil {
@@ -141,3 +160,14 @@ func (h *Handler) handleRemoveUser(w http.ResponseWriter, r *http.Request) {
 	// Success response
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "User removed from project successfully"})
 }
+
+// requirePermission checks the permission on the project with the given id,
+// which has to exist.
+func (h *Handler) requirePermission(w http.ResponseWriter, r *http.Request, projectID int, perm auth.Permission) bool {
+	projectKey, err := h.store.GetProjectKey(projectID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return false
+	}
+	return auth.RequireProjectPermission(w, r, h.roles, projectKey, perm)
+}
@@ -2,18 +2,22 @@ package projectassignment
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
 func TestProjectAssignmentHandlers(t *testing.T) {
 	store := newMockProjectAssignmentStore()
-	handler := NewHandler(store)
+	roles := &mockRoleStore{roles: map[string]string{"PRJ": "lead", "MEM": "member"}}
+	handler := NewHandler(store, roles)
 
 	t.Run("Assign User", func(t *testing.T) {
 		t.Run("should assign user to project", func(t *testing.T) {
@@ -27,6 +31,22 @@ func TestProjectAssignmentHandlers(t *testing.T) {
 		t.Run("should fail with invalid userID", func(t *testing.T) {
 			testRequest(t, handler, http.MethodPost, "/projects-assignment/1/assign/xyz", nil, http.StatusBadRequest)
 		})
+
+		t.Run("should fail with unknown role", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/projects-assignment/1/assign/3?role=owner", nil, http.StatusBadRequest)
+		})
+
+		t.Run("should be forbidden for members", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/projects-assignment/2/assign/1?role=lead", nil, http.StatusForbidden)
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/projects-assignment/3/assign/1?role=lead", nil, http.StatusForbidden)
+		})
+
+		t.Run("should return 404 if project does not exist", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/projects-assignment/99/assign/2", nil, http.StatusNotFound)
+		})
 	})
 
 	t.Run("Get Assigned Users", func(t *testing.T) {
@@ -37,6 +57,10 @@ func TestProjectAssignmentHandlers(t *testing.T) {
 		t.Run("should fail with invalid projectID", func(t *testing.T) {
 			testRequest(t, handler, http.MethodGet, "/projects-assignment/invalid/assigned-users", nil, http.StatusBadRequest)
 		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/projects-assignment/3/assigned-users", nil, http.StatusForbidden)
+		})
 	})
 
 	t.Run("Get All Users", func(t *testing.T) {
@@ -51,6 +75,14 @@ func TestProjectAssignmentHandlers(t *testing.T) {
 		t.Run("should return 404 if user not assigned", func(t *testing.T) {
 			testRequest(t, handler, http.MethodDelete, "/projects-assignment/1/remove/999", nil, http.StatusNotFound)
 		})
+
+		t.Run("should be forbidden for members", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/projects-assignment/2/remove/2", nil, http.StatusForbidden)
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/projects-assignment/3/remove/2", nil, http.StatusForbidden)
+		})
 	})
 }
 
@@ -73,6 +105,7 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 		t.Fatal(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
 
 	rr := httptest.NewRecorder()
 	router := mux.NewRouter()
@@ -89,6 +122,7 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 type mockProjectAssignmentStore struct {
 	assignments map[int][]types.User
 	users       []types.User
+	projectKeys map[int]string
 }
 
 func newMockProjectAssignmentStore() *mockProjectAssignmentStore {
@@ -102,6 +136,7 @@ func newMockProjectAssignmentStore() *mockProjectAssignmentStore {
 			{ID: 2, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"},
 			{ID: 3, FirstName: "John", LastName: "Smith", Email: "john@example.com"},
 		},
+		projectKeys: map[int]string{1: "PRJ", 2: "MEM", 3: "OUT"},
 	}
 }
 
@@ -143,3 +178,32 @@ func (m *mockProjectAssignmentStore) IsUserAssignedToProject(projectID int, user
 	}
 	return false, nil
 }
+
+func (m *mockProjectAssignmentStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return "", nil
+}
+
+func (m *mockProjectAssignmentStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
+
+func (m *mockProjectAssignmentStore) GetProjectKey(projectID int) (string, error) {
+	projectKey, ok := m.projectKeys[projectID]
+	if !ok {
+		return "", fmt.Errorf("project with ID %d not found", projectID)
+	}
+	return projectKey, nil
+}
+
+// mockRoleStore - Mock implementation of the role store
+type mockRoleStore struct {
+	roles map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
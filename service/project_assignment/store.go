@@ -2,6 +2,7 @@ package projectassignment
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/maximis3d/issue-tracking-system/types"
 )
@@ -34,13 +35,13 @@ func (s *Store) RemoveUserFromProject(projectID int, userID int) error {
 	return err
 }
 
-// GetUsersForProject - Get all users assigned to a project
+// GetUsersForProject - Get all active users assigned to a project, deactivated users can't be picked as assignees
 func (s *Store) GetUsersForProject(projectID int) ([]types.User, error) {
 	query := `
-        SELECT u.id, u.firstName, u.lastName, u.email, u.createdAt
+        SELECT u.id, u.firstName, u.lastName, u.email, u.is_active, u.createdAt
         FROM users u
         JOIN project_assignments pa ON pa.user_id = u.id
-        WHERE pa.project_id = ?
+        WHERE pa.project_id = ? AND u.is_active = TRUE
     `
 	rows, err := s.db.Query(query, projectID)
 	if err != nil {
@@ -51,7 +52,7 @@ func (s *Store) GetUsersForProject(projectID int) ([]types.User, error) {
 	var users []types.User
 	for rows.Next() {
 		var u types.User
-		if err := rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.CreatedAt); err != nil {
+		if err := rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.IsActive, &u.CreatedAt); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -61,7 +62,7 @@ func (s *Store) GetUsersForProject(projectID int) ([]types.User, error) {
 
 func (s *Store) GetAllUsers() ([]types.User, error) {
 	query := `
-		SELECT id, firstName, lastName, email
+		SELECT id, firstName, lastName, email, is_active
 		FROM users
 	`
 	rows, err := s.db.Query(query)
@@ -72,7 +73,7 @@ func (s *Store) GetAllUsers() ([]types.User, error) {
 	var users []types.User
 	for rows.Next() {
 		var u types.User
-		if err := rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email); err != nil {
+		if err := rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.IsActive); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -96,3 +97,80 @@ func (s *Store) IsUserAssignedToProject(projectID int, userID int) (bool, error)
 
 	return count > 0, nil
 }
+
+// GetProjectKey - Get the key of a project by its id
+func (s *Store) GetProjectKey(projectID int) (string, error) {
+	var projectKey string
+	err := s.db.QueryRow("SELECT project_key FROM projects WHERE id = ?", projectID).Scan(&projectKey)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project with ID %d not found", projectID)
+	}
+	if err != nil {
+		return "", err
+	}
+	return projectKey, nil
+}
+
+// GetUserRole - Get the role a user holds on a project, the project lead is always "lead"
+func (s *Store) GetUserRole(projectKey string, userID int) (string, error) {
+	query := `
+        SELECT p.project_lead, pa.role
+        FROM projects p
+        LEFT JOIN project_assignments pa ON pa.project_id = p.id AND pa.user_id = ?
+        WHERE p.project_key = ?
+    `
+	var projectLead sql.NullInt64
+	var role sql.NullString
+	err := s.db.QueryRow(query, userID, projectKey).Scan(&projectLead, &role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if projectLead.Valid && int(projectLead.Int64) == userID {
+		return "lead", nil
+	}
+
+	return role.String, nil
+}
+
+// MeetsTwoFactorRequirement - Check the user has two-factor enabled when the project requires it
+func (s *Store) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	query := `
+        SELECT NOT p.require_2fa OR COALESCE(u.totp_enabled, FALSE)
+        FROM projects p
+        LEFT JOIN users u ON u.id = ?
+        WHERE p.project_key = ?
+    `
+	var ok bool
+	err := s.db.QueryRow(query, userID, projectKey).Scan(&ok)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// SetProjectRole - Set the role of a user on a project by key, an empty role removes the user
+func (s *Store) SetProjectRole(projectKey string, userID int, role string) error {
+	if role == "" {
+		_, err := s.db.Exec(`
+            DELETE pa FROM project_assignments pa
+            JOIN projects p ON p.id = pa.project_id
+            WHERE p.project_key = ? AND pa.user_id = ?
+        `, projectKey, userID)
+		return err
+	}
+
+	_, err := s.db.Exec(`
+        INSERT INTO project_assignments (project_id, user_id, role, assigned_at)
+        SELECT id, ?, ?, NOW() FROM projects WHERE project_key = ?
+        ON DUPLICATE KEY UPDATE role = VALUES(role)
+    `, userID, role, projectKey)
+	return err
+}
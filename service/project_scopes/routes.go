@@ -7,16 +7,19 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/service/issue"
 	"github.com/maximis3d/issue-tracking-system/types"
 	"github.com/maximis3d/issue-tracking-system/utils"
 )
 
 type Handler struct {
 	store types.ScopeStore
+	roles types.RoleStore
 }
 
-func NewHandler(store types.ScopeStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.ScopeStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, roles: roles}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
@@ -24,6 +27,8 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/scopes/{id}", h.handleAddProject).Methods("POST")
 	router.HandleFunc("/scopes/{id}", h.handleRemoveProjects).Methods("DELETE")
 	router.HandleFunc("/scopes/issues/{id}", h.handleGetIssuesByScope).Methods("GET")
+	router.HandleFunc("/scopes/issues/{id}/overdue", h.handleGetOverdueIssuesByScope).Methods("GET")
+	router.HandleFunc("/scopes/{id}/points", h.handleGetPointTotals).Methods("GET")
 	router.HandleFunc("/scopes/details/{id}", h.handleGetScopeDetails).Methods("GET")
 	router.HandleFunc("/scopes", h.handleGetAllScopeDetails).Methods("GET")
 
@@ -41,6 +46,13 @@ func (h *Handler) handleCreateScope(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
 		return
 	}
+
+	for _, projectKey := range scope.Projects {
+		if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageScopes) {
+			return
+		}
+	}
+
 	// Call the Store's CreateScope method to insert the new scope
 	err := h.store.CreateScope(scope)
 	if err != nil {
@@ -76,6 +88,10 @@ func (h *Handler) handleAddProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.RequireProjectPermission(w, r, h.roles, payload.ProjectKey, auth.PermManageScopes) {
+		return
+	}
+
 	err = h.store.AddProjectToScope(scopeID, payload.ProjectKey)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to add project to scope: %v", err))
@@ -96,7 +112,17 @@ func (h *Handler) handleGetIssuesByScope(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	issues, err := h.store.GetIssuesByScope(scopeId)
+	if !h.viewScope(w, r, scopeId) {
+		return
+	}
+
+	filter, err := issue.ParseFilter(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.store.GetIssuesByScope(scopeId, filter)
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("cannot retrieve issues: %v", err))
@@ -104,8 +130,68 @@ func (h *Handler) handleGetIssuesByScope(w http.ResponseWriter, r *http.Request)
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]any{
-		"message": "Issues successfully retrieved",
-		"issues":  issues,
+		"message":     "Issues successfully retrieved",
+		"issues":      page.Issues,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// handleGetOverdueIssuesByScope lists the issues of the scope's projects
+// past their due date that are not done, the longest overdue first.
+func (h *Handler) handleGetOverdueIssuesByScope(w http.ResponseWriter, r *http.Request) {
+	scopeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid scope ID: %v", err))
+		return
+	}
+
+	if !h.viewScope(w, r, scopeID) {
+		return
+	}
+
+	filter, err := issue.ParseOverdueFilter(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.store.GetIssuesByScope(scopeID, filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("cannot retrieve overdue issues: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"message":     "Overdue issues successfully retrieved",
+		"issues":      page.Issues,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// handleGetPointTotals adds up the story points and estimates of the issues
+// of the scope's projects.
+func (h *Handler) handleGetPointTotals(w http.ResponseWriter, r *http.Request) {
+	scopeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid scope ID: %v", err))
+		return
+	}
+
+	if !h.viewScope(w, r, scopeID) {
+		return
+	}
+
+	totals, err := h.store.GetPointTotals(scopeID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("cannot retrieve point totals: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"scope_id": scopeID,
+		"points":   totals,
 	})
 }
 
@@ -125,9 +211,24 @@ func (h *Handler) handleGetScopeDetails(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Projects the user can't view are left out, and so are scopes the user
+	// can't view any project of
+	projects, err := h.visibleProjects(r, scope.Projects)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(projects) == 0 && len(scope.Projects) > 0 {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("you can't view any project of scope %d", scopeID))
+		return
+	}
+	scope.Projects = projects
+
 	utils.WriteJSON(w, http.StatusOK, scope)
 }
 
+// handleGetAllScopeDetails lists the scopes with the projects the user can
+// view, leaving out scopes the user can't view any project of.
 func (h *Handler) handleGetAllScopeDetails(w http.ResponseWriter, r *http.Request) {
 	scopes, err := h.store.GetAllScopeDetails()
 
@@ -135,12 +236,60 @@ func (h *Handler) handleGetAllScopeDetails(w http.ResponseWriter, r *http.Reques
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve scopes: %v", err))
 		return
 	}
+
+	visible := []types.Scope{}
+	for _, scope := range scopes {
+		projects, err := h.visibleProjects(r, scope.Projects)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if len(projects) == 0 && len(scope.Projects) > 0 {
+			continue
+		}
+		scope.Projects = projects
+		visible = append(visible, scope)
+	}
+
 	utils.WriteJSON(w, http.StatusOK, map[string]any{
 		"message": "Scopes successfully retrieved",
-		"scopes":  scopes,
+		"scopes":  visible,
 	})
 }
 
+// viewScope checks that the user can view the issues of every project in
+// the scope, as the issues of a scope come from all of them.
+func (h *Handler) viewScope(w http.ResponseWriter, r *http.Request, scopeID int) bool {
+	scope, err := h.store.GetScopeDetails(scopeID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("scope not found"))
+		return false
+	}
+
+	for _, projectKey := range scope.Projects {
+		if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermViewIssues) {
+			return false
+		}
+	}
+	return true
+}
+
+// visibleProjects returns the projects the user can view the issues of.
+func (h *Handler) visibleProjects(r *http.Request, projectKeys []string) ([]string, error) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	visible := []string{}
+	for _, projectKey := range projectKeys {
+		ok, err := auth.HasProjectPermission(h.roles, projectKey, userID, auth.PermViewIssues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check project permissions: %v", err)
+		}
+		if ok {
+			visible = append(visible, projectKey)
+		}
+	}
+	return visible, nil
+}
+
 func (h *Handler) handleRemoveProjects(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		ProjectKeys []string `json:"project_keys" validate:"required,min=1"`
@@ -165,6 +314,12 @@ func (h *Handler) handleRemoveProjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, projectKey := range payload.ProjectKeys {
+		if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageScopes) {
+			return
+		}
+	}
+
 	for _, projectKey := range payload.ProjectKeys {
 		err = h.store.RemoveProjectFromScope(scopeID, projectKey)
 		if err != nil {
@@ -2,19 +2,22 @@ package projectscopes
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
 func TestProjectScopeHandlers(t *testing.T) {
 	store := newMockScopeStore()
-	handler := NewHandler(store)
+	handler := NewHandler(store, &mockRoleStore{roles: map[string]string{"PROJ1": "maintainer", "PROJ2": "member"}})
 
 	t.Run("Create Scope", func(t *testing.T) {
 		t.Run("should create scope", func(t *testing.T) {
@@ -37,6 +40,11 @@ func TestProjectScopeHandlers(t *testing.T) {
 			body := map[string]string{"project_key": "PROJ1"}
 			testRequest(t, handler, http.MethodPost, "/scopes/abc", body, http.StatusBadRequest)
 		})
+
+		t.Run("should be forbidden without maintainer role", func(t *testing.T) {
+			body := map[string]string{"project_key": "PROJ2"}
+			testRequest(t, handler, http.MethodPost, "/scopes/1", body, http.StatusForbidden)
+		})
 	})
 
 	t.Run("Remove Projects from Scope", func(t *testing.T) {
@@ -56,6 +64,22 @@ func TestProjectScopeHandlers(t *testing.T) {
 			testRequest(t, handler, http.MethodGet, "/scopes/details/1", nil, http.StatusOK)
 		})
 
+		t.Run("should leave out projects the user can't view", func(t *testing.T) {
+			rr := testRequest(t, handler, http.MethodGet, "/scopes/details/2", nil, http.StatusOK)
+
+			var scope types.Scope
+			if err := json.NewDecoder(rr.Body).Decode(&scope); err != nil {
+				t.Fatal(err)
+			}
+			if len(scope.Projects) != 1 || scope.Projects[0] != "PROJ1" {
+				t.Errorf("expected only PROJ1, got %v", scope.Projects)
+			}
+		})
+
+		t.Run("should be forbidden without any viewable project", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/details/3", nil, http.StatusForbidden)
+		})
+
 		t.Run("should return 400 on invalid scopeID", func(t *testing.T) {
 			testRequest(t, handler, http.MethodGet, "/scopes/details/abc", nil, http.StatusBadRequest)
 		})
@@ -69,16 +93,75 @@ func TestProjectScopeHandlers(t *testing.T) {
 		t.Run("should return 400 on invalid scopeID", func(t *testing.T) {
 			testRequest(t, handler, http.MethodGet, "/scopes/issues/abc", nil, http.StatusBadRequest)
 		})
+
+		t.Run("should return 400 on an invalid filter", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/issues/1?sort=rank", nil, http.StatusBadRequest)
+		})
+
+		t.Run("should be forbidden with a project the user can't view", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/issues/2", nil, http.StatusForbidden)
+		})
+
+		t.Run("should return 404 if scope does not exist", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/issues/99", nil, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Get Overdue Issues by Scope", func(t *testing.T) {
+		t.Run("should return overdue issues for scope", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/issues/1/overdue?sort=-priority", nil, http.StatusOK)
+		})
+
+		t.Run("should return 400 on invalid scopeID", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/issues/abc/overdue", nil, http.StatusBadRequest)
+		})
+
+		t.Run("should return 400 on an invalid filter", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/issues/1/overdue?overdue=maybe", nil, http.StatusBadRequest)
+		})
+
+		t.Run("should be forbidden with a project the user can't view", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/issues/2/overdue", nil, http.StatusForbidden)
+		})
+	})
+
+	t.Run("Get Point Totals by Scope", func(t *testing.T) {
+		t.Run("should return the point totals for scope", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/1/points", nil, http.StatusOK)
+		})
+
+		t.Run("should return 400 on invalid scopeID", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/abc/points", nil, http.StatusBadRequest)
+		})
+
+		t.Run("should be forbidden with a project the user can't view", func(t *testing.T) {
+			testRequest(t, handler, http.MethodGet, "/scopes/2/points", nil, http.StatusForbidden)
+		})
 	})
 
 	t.Run("Get All Scopes", func(t *testing.T) {
-		t.Run("should return all scopes", func(t *testing.T) {
-			testRequest(t, handler, http.MethodGet, "/scopes", nil, http.StatusOK)
+		t.Run("should return the scopes the user can view", func(t *testing.T) {
+			rr := testRequest(t, handler, http.MethodGet, "/scopes", nil, http.StatusOK)
+
+			var resp struct {
+				Scopes []types.Scope `json:"scopes"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			for _, scope := range resp.Scopes {
+				if scope.ID == 3 {
+					t.Errorf("expected scope 3 to be left out, got %+v", scope)
+				}
+				if slices.Contains(scope.Projects, "PROJ3") {
+					t.Errorf("expected PROJ3 to be left out, got %+v", scope)
+				}
+			}
 		})
 	})
 }
 
-func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) {
+func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
 	t.Helper()
 
 	var body *bytes.Buffer
@@ -97,6 +180,7 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 		t.Fatal(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
 
 	rr := httptest.NewRecorder()
 	router := mux.NewRouter()
@@ -106,6 +190,7 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 	if rr.Code != expectedStatus {
 		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
 	}
+	return rr
 }
 
 // -------------------- MOCK STORE --------------------
@@ -117,7 +202,9 @@ type mockScopeStore struct {
 func newMockScopeStore() *mockScopeStore {
 	return &mockScopeStore{
 		scopes: map[int]types.Scope{
-			1: {ID: 1, Name: "Scope A"},
+			1: {ID: 1, Name: "Scope A", Projects: []string{"PROJ1", "PROJ2"}},
+			2: {ID: 2, Name: "Scope B", Projects: []string{"PROJ1", "PROJ3"}},
+			3: {ID: 3, Name: "Scope C", Projects: []string{"PROJ3"}},
 		},
 	}
 }
@@ -147,9 +234,14 @@ func (m *mockScopeStore) GetScopeDetails(scopeID int) (*types.Scope, error) {
 	return &scope, nil
 }
 
-func (m *mockScopeStore) GetIssuesByScope(scopeID int) ([]types.Issue, error) {
+func (m *mockScopeStore) GetIssuesByScope(scopeID int, filter types.IssueFilter) (*types.IssuePage, error) {
 	// Simulate retrieving issues
-	return []types.Issue{}, nil
+	return &types.IssuePage{Issues: []types.Issue{}}, nil
+}
+
+func (m *mockScopeStore) GetPointTotals(scopeID int) (*types.PointTotals, error) {
+	// Simulate adding up the points
+	return &types.PointTotals{}, nil
 }
 
 func (m *mockScopeStore) GetAllScopeDetails() ([]types.Scope, error) {
@@ -160,3 +252,16 @@ func (m *mockScopeStore) GetAllScopeDetails() ([]types.Scope, error) {
 	}
 	return allScopes, nil
 }
+
+// mockRoleStore - Mock implementation of the role store
+type mockRoleStore struct {
+	roles map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/maximis3d/issue-tracking-system/service/issue"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
@@ -63,32 +64,15 @@ func (s *Store) AddProjectToScope(scopeID int, projectKey string) error {
 	return nil
 }
 
-func (s *Store) GetIssuesByScope(scopeID int) ([]types.Issue, error) {
-	rows, err := s.db.Query(`
-		SELECT i.id, i.key, i.summary, i.description, i.project_key, i.reporter, i.assignee, i.status, i.issueType, i.updatedAt
-		FROM issues i
-		JOIN project_scope sp ON sp.project_key = i.project_key
-		WHERE sp.scope_id = ?`, scopeID)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve issues: %v", err)
-	}
-	defer rows.Close()
-
-	var issues []types.Issue
-	for rows.Next() {
-		var issue types.Issue
-		if err := rows.Scan(&issue.ID, &issue.Key, &issue.Summary, &issue.Description, &issue.ProjectKey, &issue.Reporter, &issue.Assignee, &issue.Status, &issue.IssueType, &issue.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan issue: %v", err)
-		}
-		issues = append(issues, issue)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %v", err)
-	}
+func (s *Store) GetIssuesByScope(scopeID int, filter types.IssueFilter) (*types.IssuePage, error) {
+	return issue.ListIssues(s.db, "i.project_key IN (SELECT project_key FROM project_scope WHERE scope_id = ?)", []any{scopeID}, filter)
+}
 
-	return issues, nil
+// GetPointTotals leaves out sub-tasks, their points are part of those of
+// their parents, and epics, whose points are those of their children.
+func (s *Store) GetPointTotals(scopeID int) (*types.PointTotals, error) {
+	return issue.SumPoints(s.db, "i.project_key IN (SELECT project_key FROM project_scope WHERE scope_id = ?) AND i.issueType NOT IN (?, ?)",
+		[]any{scopeID, types.IssueTypeSubTask, types.IssueTypeEpic})
 }
 
 func (s *Store) GetScopeDetails(scopeId int) (*types.Scope, error) {
@@ -127,11 +111,10 @@ func (s *Store) GetScopeDetails(scopeId int) (*types.Scope, error) {
 func (s *Store) GetAllScopeDetails() ([]types.Scope, error) {
 	query := `SELECT id, name, description, created_at from scopes`
 	rows, err := s.db.Query(query)
-	defer rows.Close()
-
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve scopes: %v", err)
 	}
+	defer rows.Close()
 
 	var scopes []types.Scope
 
@@ -142,6 +125,33 @@ func (s *Store) GetAllScopeDetails() ([]types.Scope, error) {
 		}
 		scopes = append(scopes, scope)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to retrieve scopes: %v", err)
+	}
+
+	// Get the projects of every scope
+	projectRows, err := s.db.Query("SELECT scope_id, project_key FROM project_scope ORDER BY scope_id, project_key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve associated projects: %v", err)
+	}
+	defer projectRows.Close()
+
+	projects := make(map[int][]string)
+	for projectRows.Next() {
+		var scopeID int
+		var projectKey string
+		if err := projectRows.Scan(&scopeID, &projectKey); err != nil {
+			return nil, fmt.Errorf("failed to scan project key: %v", err)
+		}
+		projects[scopeID] = append(projects[scopeID], projectKey)
+	}
+	if err := projectRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to retrieve associated projects: %v", err)
+	}
+
+	for i := range scopes {
+		scopes[i].Projects = projects[scopes[i].ID]
+	}
 	return scopes, nil
 }
 
@@ -0,0 +1,354 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// Context is what functions in a query resolve against.
+type Context struct {
+	UserID int
+	Now    time.Time
+}
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindText
+	kindDate
+	kindUser
+	kindSprint
+	kindCategory
+)
+
+// field maps a query field to SQL over the issues table aliased i, joined
+// with its reporter r and assignee a. Users have an id column for
+// currentUser() and EMPTY, and are otherwise matched by email.
+type field struct {
+	kind     fieldKind
+	columns  []string
+	idColumn string
+}
+
+var fields = map[string]field{
+	"project":        {kind: kindString, columns: []string{"i.project_key"}},
+	"key":            {kind: kindString, columns: []string{"i.`key`"}},
+	"status":         {kind: kindString, columns: []string{"i.status"}},
+	"type":           {kind: kindString, columns: []string{"i.issueType"}},
+	"issuetype":      {kind: kindString, columns: []string{"i.issueType"}},
+	"statuscategory": {kind: kindCategory},
+	"summary":        {kind: kindText, columns: []string{"i.summary"}},
+	"description":    {kind: kindText, columns: []string{"i.description"}},
+	"text":           {kind: kindText, columns: []string{"i.summary", "i.description"}},
+	"assignee":       {kind: kindUser, columns: []string{"COALESCE(a.email, i.assignee)"}, idColumn: "i.assignee_id"},
+	"reporter":       {kind: kindUser, columns: []string{"COALESCE(r.email, i.reporter)"}, idColumn: "i.reporter_id"},
+	"sprint":         {kind: kindSprint, columns: []string{"i.sprint_id"}},
+	"created":        {kind: kindDate, columns: []string{"i.createdAt"}},
+	"updated":        {kind: kindDate, columns: []string{"i.updatedAt"}},
+	"priority":       {kind: kindString, columns: []string{"i.priority"}},
+	"severity":       {kind: kindString, columns: []string{"i.severity"}},
+	"due":            {kind: kindDate, columns: []string{"i.due_date"}},
+}
+
+// operators lists what each kind of field can be compared with.
+var operators = map[fieldKind][]string{
+	kindString:   {"=", "!=", "IN", "NOT IN"},
+	kindCategory: {"=", "!=", "IN", "NOT IN"},
+	kindText:     {"~", "!~"},
+	kindDate:     {"<", "<=", ">", ">="},
+	kindUser:     {"=", "!=", "IN", "NOT IN", "IS", "IS NOT"},
+	kindSprint:   {"=", "!=", "IN", "NOT IN", "IS", "IS NOT"},
+}
+
+var orderColumns = map[string]string{
+	"key":      "i.id",
+	"created":  "i.createdAt",
+	"updated":  "i.updatedAt",
+	"summary":  "i.summary",
+	"status":   "i.status",
+	"type":     "i.issueType",
+	"project":  "i.project_key",
+	"assignee": "COALESCE(a.email, i.assignee)",
+	"reporter": "COALESCE(r.email, i.reporter)",
+	"priority": "i.priority",
+	"severity": issue.SeveritySort,
+	"due":      issue.DueDateSort,
+}
+
+var statusCategories = map[string]bool{
+	types.StatusCategoryTodo:       true,
+	types.StatusCategoryInProgress: true,
+	types.StatusCategoryDone:       true,
+}
+
+// Compile turns a parsed query into a WHERE and ORDER BY clause. Every value
+// is bound as an argument and fields map to fixed columns, so nothing from
+// the query ends up in the SQL text. Errors are *SyntaxError.
+func Compile(q *Query, ctx Context) (types.IssueQuery, error) {
+	c := &compiler{ctx: ctx}
+
+	where := "TRUE"
+	if q.Where != nil {
+		var err error
+		if where, err = c.expr(q.Where); err != nil {
+			return types.IssueQuery{}, err
+		}
+	}
+
+	var order []string
+	for _, term := range q.OrderBy {
+		column, ok := orderColumns[term.Field]
+		if !ok {
+			return types.IssueQuery{}, errorAt(term.Pos, "cannot order by %s", term.Field)
+		}
+		direction := "ASC"
+		if term.Desc {
+			direction = "DESC"
+		}
+		order = append(order, column+" "+direction)
+	}
+	if len(order) == 0 {
+		order = append(order, "i.createdAt ASC")
+	}
+	order = append(order, "i.id ASC")
+
+	return types.IssueQuery{Where: where, Args: c.args, OrderBy: strings.Join(order, ", ")}, nil
+}
+
+type compiler struct {
+	ctx  Context
+	args []any
+}
+
+func (c *compiler) expr(e Expr) (string, error) {
+	switch e := e.(type) {
+	case *BinaryExpr:
+		left, err := c.expr(e.Left)
+		if err != nil {
+			return "", err
+		}
+		right, err := c.expr(e.Right)
+		if err != nil {
+			return "", err
+		}
+		return "(" + left + " " + e.Op + " " + right + ")", nil
+	case *NotExpr:
+		x, err := c.expr(e.X)
+		if err != nil {
+			return "", err
+		}
+		// Rows where the condition is unknown, like a missing sprint, match
+		return "NOT COALESCE(" + x + ", FALSE)", nil
+	case *Clause:
+		return c.clause(e)
+	}
+	return "", fmt.Errorf("unknown expression %T", e)
+}
+
+func (c *compiler) clause(cl *Clause) (string, error) {
+	f, ok := fields[cl.Field]
+	if !ok {
+		return "", errorAt(cl.FieldPos, "unknown field %s", cl.Field)
+	}
+
+	allowed := false
+	for _, op := range operators[f.kind] {
+		allowed = allowed || op == cl.Op
+	}
+	if !allowed {
+		return "", errorAt(cl.OpPos, "operator %s can't be used with %s, use one of %s", cl.Op, cl.Field, strings.Join(operators[f.kind], ", "))
+	}
+
+	switch cl.Op {
+	case "IS", "IS NOT":
+		column := f.idColumn
+		if column == "" {
+			column = f.columns[0]
+		}
+		return column + " " + cl.Op + " NULL", nil
+	case "~", "!~":
+		return c.text(cl, f)
+	case "<", "<=", ">", ">=":
+		t, err := c.time(cl.Values[0])
+		if err != nil {
+			return "", err
+		}
+		c.args = append(c.args, t)
+		return f.columns[0] + " " + cl.Op + " ?", nil
+	}
+
+	match, err := c.match(cl, f)
+	if err != nil {
+		return "", err
+	}
+	if cl.Op == "!=" || cl.Op == "NOT IN" {
+		return "NOT COALESCE(" + match + ", FALSE)", nil
+	}
+	return match, nil
+}
+
+// match compiles the values of an equality or IN clause into a condition
+// that holds when the field equals any of them.
+func (c *compiler) match(cl *Clause, f field) (string, error) {
+	var conds, literals []string
+	for _, v := range cl.Values {
+		if v.Func != "" {
+			cond, err := c.function(cl, f, v)
+			if err != nil {
+				return "", err
+			}
+			conds = append(conds, cond)
+			continue
+		}
+
+		switch f.kind {
+		case kindSprint:
+			id, err := strconv.Atoi(v.Text)
+			if err != nil {
+				return "", errorAt(v.Pos, "sprint must be a sprint id, got %q", v.Text)
+			}
+			c.args = append(c.args, id)
+		case kindCategory:
+			category := strings.ToLower(v.Text)
+			if !statusCategories[category] {
+				return "", errorAt(v.Pos, "unknown status category %q, use todo, in_progress or done", v.Text)
+			}
+			c.args = append(c.args, category)
+		default:
+			c.args = append(c.args, v.Text)
+		}
+		literals = append(literals, "?")
+	}
+
+	if len(literals) > 0 {
+		list := strings.Join(literals, ", ")
+		if f.kind == kindCategory {
+			conds = append([]string{"i.status IN (SELECT ws.name FROM workflow_statuses ws WHERE ws.project_key = i.project_key AND ws.category IN (" + list + "))"}, conds...)
+		} else {
+			conds = append([]string{f.columns[0] + " IN (" + list + ")"}, conds...)
+		}
+	}
+
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", nil
+}
+
+func (c *compiler) function(cl *Clause, f field, v Value) (string, error) {
+	switch {
+	case v.Func == "currentuser" && f.kind == kindUser:
+		if err := checkArgs(v, 0); err != nil {
+			return "", err
+		}
+		c.args = append(c.args, c.ctx.UserID)
+		return f.idColumn + " = ?", nil
+	case v.Func == "opensprints" && f.kind == kindSprint:
+		if err := checkArgs(v, 0); err != nil {
+			return "", err
+		}
+		c.args = append(c.args, c.ctx.Now, c.ctx.Now)
+		return "i.sprint_id IN (SELECT id FROM sprints WHERE start_date <= DATE(?) AND end_date >= DATE(?))", nil
+	case v.Func == "closedsprints" && f.kind == kindSprint:
+		if err := checkArgs(v, 0); err != nil {
+			return "", err
+		}
+		c.args = append(c.args, c.ctx.Now)
+		return "i.sprint_id IN (SELECT id FROM sprints WHERE end_date < DATE(?))", nil
+	case v.Func == "scope" && cl.Field == "project":
+		if err := checkArgs(v, 1); err != nil {
+			return "", err
+		}
+		id, err := strconv.Atoi(v.Args[0].Text)
+		if err != nil || v.Args[0].Func != "" {
+			return "", errorAt(v.Args[0].Pos, "scope() takes a scope id")
+		}
+		c.args = append(c.args, id)
+		return "i.project_key IN (SELECT project_key FROM project_scope WHERE scope_id = ?)", nil
+	case v.Func == "currentuser" || v.Func == "opensprints" || v.Func == "closedsprints" || v.Func == "scope" || v.Func == "now":
+		return "", errorAt(v.Pos, "%s() can't be used with %s", v.Func, cl.Field)
+	}
+	return "", errorAt(v.Pos, "unknown function %s()", v.Func)
+}
+
+func (c *compiler) text(cl *Clause, f field) (string, error) {
+	v := cl.Values[0]
+	if v.Func != "" {
+		return "", errorAt(v.Pos, "%s takes text, not a function", cl.Field)
+	}
+
+	pattern := "%" + likeEscaper.Replace(v.Text) + "%"
+	var conds []string
+	for _, column := range f.columns {
+		conds = append(conds, column+" LIKE ?")
+		c.args = append(c.args, pattern)
+	}
+
+	cond := "(" + strings.Join(conds, " OR ") + ")"
+	if cl.Op == "!~" {
+		return "NOT " + cond, nil
+	}
+	return cond, nil
+}
+
+// time reads a date, an RFC 3339 time, now() or a time relative to now such
+// as -7d, -2w or 12h.
+func (c *compiler) time(v Value) (time.Time, error) {
+	if v.Func != "" {
+		if v.Func != "now" {
+			return time.Time{}, errorAt(v.Pos, "%s() is not a time", v.Func)
+		}
+		if err := checkArgs(v, 0); err != nil {
+			return time.Time{}, err
+		}
+		return c.ctx.Now, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, v.Text); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, v.Text, c.ctx.Now.Location()); err == nil {
+		return t, nil
+	}
+	if d, ok := relativeDuration(v.Text); ok {
+		return c.ctx.Now.Add(d), nil
+	}
+	return time.Time{}, errorAt(v.Pos, "invalid time %q, use a date like 2025-06-01 or a relative time like -7d", v.Text)
+}
+
+var durationUnits = map[byte]time.Duration{
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+func relativeDuration(text string) (time.Duration, bool) {
+	if len(text) < 2 {
+		return 0, false
+	}
+	unit, ok := durationUnits[text[len(text)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(text[:len(text)-1])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+func checkArgs(v Value, n int) error {
+	if len(v.Args) != n {
+		return errorAt(v.Pos, "%s() takes %d argument(s), got %d", v.Func, n, len(v.Args))
+	}
+	return nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
@@ -0,0 +1,238 @@
+package search
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// snippetLength is roughly how many characters of a field a highlight shows.
+const snippetLength = 160
+
+// Index keeps one search document per issue with its summary, description
+// and live comments under MySQL FULLTEXT indexes. InnoDB skips stopwords and
+// words shorter than innodb_ft_min_token_size, 3 by default.
+type Index struct {
+	db *sql.DB
+}
+
+func NewIndex(db *sql.DB) *Index {
+	return &Index{db: db}
+}
+
+func (x *Index) IndexIssue(issueID int) error {
+	var summary, description string
+	err := x.db.QueryRow("SELECT summary, description FROM issues WHERE id = ?", issueID).Scan(&summary, &description)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = x.db.Exec("DELETE FROM search_documents WHERE issue_id = ?", issueID)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get issue: %v", err)
+	}
+
+	rows, err := x.db.Query("SELECT body FROM comments WHERE issue_id = ? AND deleted_at IS NULL ORDER BY id", issueID)
+	if err != nil {
+		return fmt.Errorf("failed to get comments: %v", err)
+	}
+	defer rows.Close()
+
+	var comments []string
+	for rows.Next() {
+		var body string
+		if err := rows.Scan(&body); err != nil {
+			return fmt.Errorf("failed to scan comment: %v", err)
+		}
+		comments = append(comments, body)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	_, err = x.db.Exec(`
+        INSERT INTO search_documents (issue_id, summary, description, comments) VALUES (?, ?, ?, ?)
+        ON DUPLICATE KEY UPDATE summary = VALUES(summary), description = VALUES(description), comments = VALUES(comments)
+    `, issueID, summary, description, strings.Join(comments, "\n"))
+	if err != nil {
+		return fmt.Errorf("failed to index issue: %v", err)
+	}
+	return nil
+}
+
+// SearchText ranks matches in the summary three times higher than those in
+// the description or comments.
+func (x *Index) SearchText(userID int, query types.TextQuery) (*types.TextSearchPage, error) {
+	terms := searchTerms(query.Text)
+	page := &types.TextSearchPage{Hits: []types.TextSearchHit{}}
+	if len(terms) == 0 {
+		return page, nil
+	}
+	against := booleanQuery(terms)
+
+	where := "MATCH(d.summary, d.description, d.comments) AGAINST (? IN BOOLEAN MODE) AND " + visibleProjects
+	args := []any{against, userID}
+	if query.Project != "" {
+		where += " AND i.project_key = ?"
+		args = append(args, query.Project)
+	}
+	if query.ScopeID != 0 {
+		where += " AND i.project_key IN (SELECT project_key FROM project_scope WHERE scope_id = ?)"
+		args = append(args, query.ScopeID)
+	}
+
+	from := " FROM search_documents d JOIN issues i ON i.id = d.issue_id WHERE " + where
+	if err := x.db.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count matches: %v", err)
+	}
+
+	rows, err := x.db.Query(`
+        SELECT d.issue_id, d.summary, d.description, d.comments,
+            3 * MATCH(d.summary) AGAINST (? IN BOOLEAN MODE)
+            + MATCH(d.description) AGAINST (? IN BOOLEAN MODE)
+            + MATCH(d.comments) AGAINST (? IN BOOLEAN MODE) AS score`+from+
+		fmt.Sprintf(" ORDER BY score DESC, d.issue_id DESC LIMIT %d OFFSET %d", query.Limit, query.Offset),
+		append([]any{against, against, against}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search issues: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []any
+	for rows.Next() {
+		var id int
+		var summary, description, comments string
+		var hit types.TextSearchHit
+		if err := rows.Scan(&id, &summary, &description, &comments, &hit.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan match: %v", err)
+		}
+		hit.Issue.ID = id
+		hit.Highlights = highlights(terms, map[string]string{
+			"summary":     summary,
+			"description": description,
+			"comments":    comments,
+		})
+		page.Hits = append(page.Hits, hit)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+	if len(ids) == 0 {
+		return page, nil
+	}
+
+	list := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	issues, err := issue.QueryIssues(x.db, types.IssueQuery{
+		Where:   "i.id IN (" + list + ")",
+		Args:    ids,
+		OrderBy: "i.id",
+	}, len(ids), 0)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]types.Issue, len(issues.Issues))
+	for _, i := range issues.Issues {
+		byID[i.ID] = i
+	}
+	for n := range page.Hits {
+		page.Hits[n].Issue = byID[page.Hits[n].Issue.ID]
+	}
+
+	return page, nil
+}
+
+// searchTerms splits text into lower case words, dropping everything else so
+// no boolean mode operator gets through.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// booleanQuery requires every term, each matching as a prefix.
+func booleanQuery(terms []string) string {
+	var sb strings.Builder
+	for n, term := range terms {
+		if n > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString("+" + term + "*")
+	}
+	return sb.String()
+}
+
+// highlights returns a snippet of each field containing a term.
+func highlights(terms []string, fields map[string]string) map[string]string {
+	result := make(map[string]string)
+	for name, text := range fields {
+		if snippet, ok := highlight(text, terms); ok {
+			result[name] = snippet
+		}
+	}
+	return result
+}
+
+// highlight cuts a snippet of text around the first word starting with one
+// of the terms, escapes it and wraps every such word in <mark>.
+func highlight(text string, terms []string) (string, bool) {
+	runes := []rune(text)
+
+	type span struct{ start, end int }
+	var matches []span
+	for start := 0; start < len(runes); {
+		if !isTermRune(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && isTermRune(runes[end]) {
+			end++
+		}
+		word := strings.ToLower(string(runes[start:end]))
+		for _, term := range terms {
+			if strings.HasPrefix(word, term) {
+				matches = append(matches, span{start, end})
+				break
+			}
+		}
+		start = end
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	from := max(0, matches[0].start-snippetLength/4)
+	for from > 0 && from < matches[0].start && isTermRune(runes[from-1]) {
+		from++
+	}
+	to := min(len(runes), from+snippetLength)
+
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("…")
+	}
+	pos := from
+	for _, m := range matches {
+		if m.start < from || m.end > to {
+			continue
+		}
+		sb.WriteString(html.EscapeString(string(runes[pos:m.start])))
+		sb.WriteString("<mark>" + html.EscapeString(string(runes[m.start:m.end])) + "</mark>")
+		pos = m.end
+	}
+	sb.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		sb.WriteString("…")
+	}
+	return sb.String(), true
+}
+
+func isTermRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
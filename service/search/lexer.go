@@ -0,0 +1,128 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// token is a lexeme of the query, Pos is its byte offset in the query.
+type token struct {
+	Kind tokenKind
+	Text string
+	Pos  int
+}
+
+// SyntaxError points at the position in the query where parsing or
+// compiling failed.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+func errorAt(pos int, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// tokenize splits the query into tokens. Strings are single or double quoted
+// with backslash escapes, words may contain letters, digits, '-', '_', '.',
+// '@' and '+' so keys and emails need no quotes.
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for pos < len(query) {
+		c := query[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+		case c == '(':
+			tokens = append(tokens, token{Kind: tokenLParen, Text: "(", Pos: pos})
+			pos++
+		case c == ')':
+			tokens = append(tokens, token{Kind: tokenRParen, Text: ")", Pos: pos})
+			pos++
+		case c == ',':
+			tokens = append(tokens, token{Kind: tokenComma, Text: ",", Pos: pos})
+			pos++
+		case c == '"' || c == '\'':
+			text, end, err := readString(query, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{Kind: tokenString, Text: text, Pos: pos})
+			pos = end
+		case strings.ContainsRune("=!~<>", rune(c)):
+			op := string(c)
+			if pos+1 < len(query) && (query[pos+1] == '=' || (c == '!' && query[pos+1] == '~')) {
+				op = query[pos : pos+2]
+			}
+			if op == "!" {
+				return nil, errorAt(pos, "unexpected '!', did you mean != or !~")
+			}
+			tokens = append(tokens, token{Kind: tokenOperator, Text: op, Pos: pos})
+			pos += len(op)
+		case isWordByte(c):
+			start := pos
+			for pos < len(query) && isWordByte(query[pos]) {
+				pos++
+			}
+			text := query[start:pos]
+			kind := tokenIdent
+			if isNumber(text) {
+				kind = tokenNumber
+			}
+			tokens = append(tokens, token{Kind: kind, Text: text, Pos: start})
+		default:
+			return nil, errorAt(pos, "unexpected character %q", rune(c))
+		}
+	}
+	return append(tokens, token{Kind: tokenEOF, Pos: len(query)}), nil
+}
+
+func readString(query string, start int) (string, int, error) {
+	quote := query[start]
+	var sb strings.Builder
+	for pos := start + 1; pos < len(query); pos++ {
+		c := query[pos]
+		switch {
+		case c == '\\' && pos+1 < len(query):
+			pos++
+			sb.WriteByte(query[pos])
+		case c == quote:
+			return sb.String(), pos + 1, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, errorAt(start, "unterminated string")
+}
+
+func isWordByte(c byte) bool {
+	return c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || strings.IndexByte("-_.@+", c) >= 0
+}
+
+func isNumber(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] < '0' || text[i] > '9' {
+			return false
+		}
+	}
+	return text != ""
+}
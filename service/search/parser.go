@@ -0,0 +1,332 @@
+package search
+
+import "strings"
+
+// Query is a parsed search. Where is nil when the query only orders.
+//
+//	query   := [ or ] [ ORDER BY field [ASC|DESC] { "," field [ASC|DESC] } ]
+//	or      := and { OR and }
+//	and     := not { AND not }
+//	not     := NOT not | "(" or ")" | clause
+//	clause  := field op value
+//	         | field [NOT] IN ( "(" value { "," value } ")" | function )
+//	         | field IS [NOT] EMPTY
+//	op      := "=" | "!=" | "~" | "!~" | "<" | "<=" | ">" | ">="
+//	value   := string | number | word | function
+//	function:= word "(" [ value { "," value } ] ")"
+//
+// Keywords are case insensitive, quoted strings are never keywords.
+type Query struct {
+	Where   Expr
+	OrderBy []OrderTerm
+}
+
+type OrderTerm struct {
+	Field string
+	Desc  bool
+	Pos   int
+}
+
+type Expr interface {
+	expr()
+}
+
+// BinaryExpr joins two expressions with AND or OR.
+type BinaryExpr struct {
+	Op          string
+	Left, Right Expr
+}
+
+type NotExpr struct {
+	X Expr
+}
+
+// Clause compares a field, Op is one of the operators, IN, NOT IN, IS or
+// IS NOT.
+type Clause struct {
+	Field    string
+	FieldPos int
+	Op       string
+	OpPos    int
+	Values   []Value
+}
+
+// Value is a literal, or a function call when Func is set.
+type Value struct {
+	Text string
+	Func string
+	Args []Value
+	Pos  int
+}
+
+func (*BinaryExpr) expr() {}
+func (*NotExpr) expr()    {}
+func (*Clause) expr()     {}
+
+var keywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IN": true, "IS": true,
+	"EMPTY": true, "NULL": true, "ORDER": true, "BY": true,
+}
+
+// Parse parses a query, errors are *SyntaxError.
+func Parse(query string) (*Query, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	q := &Query{}
+
+	if p.peek().Kind != tokenEOF && !p.isKeyword("ORDER") {
+		if q.Where, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isKeyword("ORDER") {
+		p.next()
+		if !p.isKeyword("BY") {
+			return nil, errorAt(p.peek().Pos, "expected BY after ORDER")
+		}
+		p.next()
+		for {
+			field := p.next()
+			if field.Kind != tokenIdent || keywords[strings.ToUpper(field.Text)] {
+				return nil, errorAt(field.Pos, "expected a field to order by, got %s", describe(field))
+			}
+			term := OrderTerm{Field: strings.ToLower(field.Text), Pos: field.Pos}
+			if p.isKeyword("DESC") {
+				term.Desc = true
+				p.next()
+			} else if p.isKeyword("ASC") {
+				p.next()
+			}
+			q.OrderBy = append(q.OrderBy, term)
+
+			if p.peek().Kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if t := p.peek(); t.Kind != tokenEOF {
+		return nil, errorAt(t.Pos, "unexpected %s, expected AND, OR or ORDER BY", describe(t))
+	}
+
+	return q, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.Kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.Kind == tokenIdent && strings.EqualFold(t.Text, keyword)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{X: x}, nil
+	}
+
+	if p.peek().Kind == tokenLParen {
+		open := p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().Kind != tokenRParen {
+			return nil, errorAt(p.peek().Pos, "expected ) to close the ( at position %d", open.Pos)
+		}
+		p.next()
+		return x, nil
+	}
+
+	return p.parseClause()
+}
+
+func (p *parser) parseClause() (Expr, error) {
+	field := p.next()
+	if field.Kind != tokenIdent || keywords[strings.ToUpper(field.Text)] {
+		return nil, errorAt(field.Pos, "expected a field, got %s", describe(field))
+	}
+	c := &Clause{Field: strings.ToLower(field.Text), FieldPos: field.Pos}
+
+	op := p.peek()
+	c.OpPos = op.Pos
+	switch {
+	case op.Kind == tokenOperator:
+		p.next()
+		c.Op = op.Text
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		c.Values = []Value{v}
+	case p.isKeyword("IN"):
+		p.next()
+		c.Op = "IN"
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		c.Values = values
+	case p.isKeyword("NOT"):
+		p.next()
+		if !p.isKeyword("IN") {
+			return nil, errorAt(p.peek().Pos, "expected IN after NOT")
+		}
+		p.next()
+		c.Op = "NOT IN"
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		c.Values = values
+	case p.isKeyword("IS"):
+		p.next()
+		c.Op = "IS"
+		if p.isKeyword("NOT") {
+			p.next()
+			c.Op = "IS NOT"
+		}
+		if !p.isKeyword("EMPTY") && !p.isKeyword("NULL") {
+			return nil, errorAt(p.peek().Pos, "expected EMPTY after %s", c.Op)
+		}
+		p.next()
+	default:
+		return nil, errorAt(op.Pos, "expected an operator after %s, got %s", field.Text, describe(op))
+	}
+
+	return c, nil
+}
+
+// parseList reads a parenthesised list of values, or a single function that
+// stands for a list like openSprints().
+func (p *parser) parseList() ([]Value, error) {
+	if p.peek().Kind != tokenLParen {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if v.Func == "" {
+			return nil, errorAt(v.Pos, "expected ( or a function after IN")
+		}
+		return []Value{v}, nil
+	}
+	p.next()
+
+	var values []Value
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		t := p.next()
+		if t.Kind == tokenRParen {
+			return values, nil
+		}
+		if t.Kind != tokenComma {
+			return nil, errorAt(t.Pos, "expected , or ) in the list, got %s", describe(t))
+		}
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	t := p.next()
+	switch {
+	case t.Kind == tokenString || t.Kind == tokenNumber:
+		return Value{Text: t.Text, Pos: t.Pos}, nil
+	case t.Kind == tokenIdent && !keywords[strings.ToUpper(t.Text)]:
+		if p.peek().Kind != tokenLParen {
+			return Value{Text: t.Text, Pos: t.Pos}, nil
+		}
+		p.next()
+		v := Value{Func: strings.ToLower(t.Text), Pos: t.Pos}
+		if p.peek().Kind == tokenRParen {
+			p.next()
+			return v, nil
+		}
+		for {
+			arg, err := p.parseValue()
+			if err != nil {
+				return Value{}, err
+			}
+			v.Args = append(v.Args, arg)
+
+			sep := p.next()
+			if sep.Kind == tokenRParen {
+				return v, nil
+			}
+			if sep.Kind != tokenComma {
+				return Value{}, errorAt(sep.Pos, "expected , or ) after the arguments of %s, got %s", t.Text, describe(sep))
+			}
+		}
+	}
+	return Value{}, errorAt(t.Pos, "expected a value, got %s", describe(t))
+}
+
+func describe(t token) string {
+	switch t.Kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return "string " + `"` + t.Text + `"`
+	}
+	return `"` + t.Text + `"`
+}
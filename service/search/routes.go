@@ -0,0 +1,144 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+type Handler struct {
+	store types.SearchStore
+	index types.SearchIndex
+}
+
+func NewHandler(store types.SearchStore, index types.SearchIndex) *Handler {
+	return &Handler{store: store, index: index}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/search", h.handleSearch).Methods("GET")
+	router.HandleFunc("/search/text", h.handleSearchText).Methods("GET")
+}
+
+// handleSearch runs the query in q over the issues of the projects the user
+// can view, paged with limit and start_at.
+func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	limit, startAt, err := pageParams(params)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	query, err := compileQuery(params.Get("q"), Context{UserID: userID, Now: time.Now()})
+	if err != nil {
+		var syntaxErr *SyntaxError
+		if errors.As(err, &syntaxErr) {
+			utils.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error":    syntaxErr.Error(),
+				"position": syntaxErr.Pos,
+			})
+			return
+		}
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.store.SearchIssues(userID, query, limit, startAt)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to search issues: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"issues":   page.Issues,
+		"total":    page.Total,
+		"start_at": startAt,
+		"limit":    limit,
+	})
+}
+
+func compileQuery(q string, ctx Context) (types.IssueQuery, error) {
+	parsed, err := Parse(q)
+	if err != nil {
+		return types.IssueQuery{}, err
+	}
+	return Compile(parsed, ctx)
+}
+
+// handleSearchText ranks issues by the words in q, matching word prefixes in
+// summaries, descriptions and comments. project and scope narrow the search.
+func (h *Handler) handleSearchText(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	limit, startAt, err := pageParams(params)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := types.TextQuery{
+		Text:    strings.TrimSpace(params.Get("q")),
+		Project: params.Get("project"),
+		Limit:   limit,
+		Offset:  startAt,
+	}
+	if query.Text == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("q is required"))
+		return
+	}
+	if scope := params.Get("scope"); scope != "" {
+		id, err := strconv.Atoi(scope)
+		if err != nil || id < 1 {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid scope %q", scope))
+			return
+		}
+		query.ScopeID = id
+	}
+
+	page, err := h.index.SearchText(auth.GetUserIDFromContext(r.Context()), query)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to search issues: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"hits":     page.Hits,
+		"total":    page.Total,
+		"start_at": startAt,
+		"limit":    limit,
+	})
+}
+
+func pageParams(params url.Values) (limit, startAt int, err error) {
+	limit = defaultLimit
+	if value := params.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		limit = n
+	}
+	if value := params.Get("start_at"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("start_at must be a non-negative number")
+		}
+		startAt = n
+	}
+	return limit, startAt, nil
+}
@@ -0,0 +1,143 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestSearchServiceHandlers(t *testing.T) {
+	store := &mockSearchStore{page: &types.IssuePage{Issues: []types.Issue{{ID: 1, Key: "WEB-1"}}, Total: 3}}
+	index := &mockSearchIndex{page: &types.TextSearchPage{Hits: []types.TextSearchHit{{Issue: types.Issue{ID: 2}, Score: 1.5}}, Total: 1}}
+	handler := NewHandler(store, index)
+
+	t.Run("should search as the current user", func(t *testing.T) {
+		rr := testRequest(t, handler, 5, "/search?q="+url.QueryEscape("assignee = currentUser()")+"&limit=1&start_at=2", http.StatusOK)
+
+		var resp struct {
+			Issues  []types.Issue `json:"issues"`
+			Total   int           `json:"total"`
+			StartAt int           `json:"start_at"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Issues) != 1 || resp.Total != 3 || resp.StartAt != 2 {
+			t.Errorf("unexpected response %+v", resp)
+		}
+		if store.userID != 5 || store.limit != 1 || store.offset != 2 {
+			t.Errorf("unexpected search by user %d, limit %d, offset %d", store.userID, store.limit, store.offset)
+		}
+		if len(store.query.Args) != 1 || store.query.Args[0] != 5 {
+			t.Errorf("expected currentUser() to bind the user, got %v", store.query.Args)
+		}
+	})
+
+	t.Run("should return the position of a syntax error", func(t *testing.T) {
+		rr := testRequest(t, handler, 5, "/search?q="+url.QueryEscape("status = open AND"), http.StatusBadRequest)
+
+		var resp struct {
+			Error    string `json:"error"`
+			Position int    `json:"position"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Position != 17 || resp.Error == "" {
+			t.Errorf("unexpected response %+v", resp)
+		}
+	})
+
+	t.Run("should fail with an invalid limit", func(t *testing.T) {
+		testRequest(t, handler, 5, "/search?q=status+%3D+open&limit=500", http.StatusBadRequest)
+	})
+
+	t.Run("Text Search", func(t *testing.T) {
+		t.Run("should pass the filters to the index", func(t *testing.T) {
+			rr := testRequest(t, handler, 5, "/search/text?q=login+fail&project=WEB&scope=3", http.StatusOK)
+
+			var resp struct {
+				Hits  []types.TextSearchHit `json:"hits"`
+				Total int                   `json:"total"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Hits) != 1 || resp.Total != 1 {
+				t.Errorf("unexpected response %+v", resp)
+			}
+			want := types.TextQuery{Text: "login fail", Project: "WEB", ScopeID: 3, Limit: defaultLimit}
+			if index.userID != 5 || index.query != want {
+				t.Errorf("unexpected search by user %d with %+v", index.userID, index.query)
+			}
+		})
+
+		t.Run("should fail without text", func(t *testing.T) {
+			testRequest(t, handler, 5, "/search/text?q=+", http.StatusBadRequest)
+		})
+
+		t.Run("should fail with an invalid scope", func(t *testing.T) {
+			testRequest(t, handler, 5, "/search/text?q=login&scope=abc", http.StatusBadRequest)
+		})
+	})
+}
+
+// testRequest - Helper function to perform GET requests as a user and check the response
+func testRequest(t testing.TB, handler *Handler, userID int, path string, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, userID))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+		fmt.Printf("Response Body: %s\n", rr.Body.String())
+	}
+
+	return rr
+}
+
+// mockSearchStore - Mock implementation of the search store, remembers the last search
+type mockSearchStore struct {
+	page          *types.IssuePage
+	userID        int
+	query         types.IssueQuery
+	limit, offset int
+}
+
+func (m *mockSearchStore) SearchIssues(userID int, query types.IssueQuery, limit, offset int) (*types.IssuePage, error) {
+	m.userID, m.query, m.limit, m.offset = userID, query, limit, offset
+	return m.page, nil
+}
+
+// mockSearchIndex - Mock implementation of the search index, remembers the last search
+type mockSearchIndex struct {
+	page   *types.TextSearchPage
+	userID int
+	query  types.TextQuery
+}
+
+func (m *mockSearchIndex) IndexIssue(issueID int) error {
+	return nil
+}
+
+func (m *mockSearchIndex) SearchText(userID int, query types.TextQuery) (*types.TextSearchPage, error) {
+	m.userID, m.query = userID, query
+	return m.page, nil
+}
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testContext = Context{UserID: 5, Now: time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC)}
+
+func TestCompile(t *testing.T) {
+	t.Run("should compile the example query", func(t *testing.T) {
+		q, err := compileQuery("project in (WEB, API) AND status != resolved AND assignee = currentUser() ORDER BY updated DESC", testContext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		where := "((i.project_key IN (?, ?) AND NOT COALESCE(i.status IN (?), FALSE)) AND i.assignee_id = ?)"
+		if q.Where != where {
+			t.Errorf("expected where %q, got %q", where, q.Where)
+		}
+		if args := []any{"WEB", "API", "resolved", 5}; !reflect.DeepEqual(q.Args, args) {
+			t.Errorf("expected args %v, got %v", args, q.Args)
+		}
+		if q.OrderBy != "i.updatedAt DESC, i.id ASC" {
+			t.Errorf("unexpected order %q", q.OrderBy)
+		}
+	})
+
+	t.Run("should bind values instead of inlining them", func(t *testing.T) {
+		q, err := compileQuery(`summary ~ "'; DROP TABLE issues; --" OR key = "x' OR 1=1"`, testContext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Where != "((i.summary LIKE ?) OR i.`key` IN (?))" {
+			t.Errorf("unexpected where %q", q.Where)
+		}
+		if args := []any{"%'; DROP TABLE issues; --%", "x' OR 1=1"}; !reflect.DeepEqual(q.Args, args) {
+			t.Errorf("expected args %v, got %v", args, q.Args)
+		}
+	})
+
+	t.Run("should search and order by priority and due date", func(t *testing.T) {
+		q, err := compileQuery("priority in (P0, P1) AND due < now() ORDER BY priority, due", testContext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Where != "(i.priority IN (?, ?) AND i.due_date < ?)" {
+			t.Errorf("unexpected where %q", q.Where)
+		}
+		if q.OrderBy != "i.priority ASC, COALESCE(i.due_date, '9999-12-31') ASC, i.id ASC" {
+			t.Errorf("unexpected order %q", q.OrderBy)
+		}
+	})
+
+	t.Run("should resolve functions and relative times", func(t *testing.T) {
+		q, err := compileQuery("sprint in openSprints() AND project in scope(3) AND created >= -7d AND assignee is empty", testContext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		weekAgo := testContext.Now.Add(-7 * 24 * time.Hour)
+		if args := []any{testContext.Now, testContext.Now, 3, weekAgo}; !reflect.DeepEqual(q.Args, args) {
+			t.Errorf("expected args %v, got %v", args, q.Args)
+		}
+		if q.OrderBy != "i.createdAt ASC, i.id ASC" {
+			t.Errorf("unexpected order %q", q.OrderBy)
+		}
+	})
+
+	t.Run("should match everything without conditions", func(t *testing.T) {
+		q, err := compileQuery("order by key desc", testContext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Where != "TRUE" || q.OrderBy != "i.id DESC, i.id ASC" {
+			t.Errorf("unexpected query %+v", q)
+		}
+	})
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{"status = ", 9},
+		{"status = open AND", 17},
+		{"(status = open", 14},
+		{"status open", 7},
+		{`summary ~ "unterminated`, 10},
+		{"status = open # x", 14},
+		{"project in (WEB API)", 16},
+		{"resolution = fixed", 0},
+		{"status ~ open", 7},
+		{"created > yesterday", 10},
+		{"assignee = openSprints()", 11},
+		{"status = open ORDER BY description", 23},
+		{"status = open status = closed", 14},
+	}
+
+	for _, tt := range tests {
+		_, err := compileQuery(tt.query, testContext)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected a syntax error, got %v", tt.query, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos {
+			t.Errorf("%q: expected position %d, got %d (%v)", tt.query, tt.pos, syntaxErr.Pos, syntaxErr)
+		}
+	}
+}
+
+func TestTextSearch(t *testing.T) {
+	t.Run("should require every term as a prefix", func(t *testing.T) {
+		terms := searchTerms(`Login +fails -"now"*`)
+		if got := booleanQuery(terms); got != "+login* +fails* +now*" {
+			t.Errorf("unexpected boolean query %q", got)
+		}
+	})
+
+	t.Run("should mark prefix matches and escape the text", func(t *testing.T) {
+		snippet, ok := highlight("The <b>login</b> page logs nothing", []string{"log"})
+		if !ok {
+			t.Fatal("expected a highlight")
+		}
+		want := "The &lt;b&gt;<mark>login</mark>&lt;/b&gt; page <mark>logs</mark> nothing"
+		if snippet != want {
+			t.Errorf("expected %q, got %q", want, snippet)
+		}
+	})
+
+	t.Run("should cut a snippet around the first match", func(t *testing.T) {
+		text := strings.Repeat("filler ", 40) + "crash " + strings.Repeat("filler ", 40)
+		snippet, ok := highlight(text, []string{"crash"})
+		if !ok || !strings.HasPrefix(snippet, "…filler") || !strings.HasSuffix(snippet, "…") || !strings.Contains(snippet, "<mark>crash</mark>") {
+			t.Errorf("unexpected snippet %q", snippet)
+		}
+	})
+
+	t.Run("should skip fields without a match", func(t *testing.T) {
+		if _, ok := highlight("nothing here", []string{"crash"}); ok {
+			t.Error("expected no highlight")
+		}
+	})
+}
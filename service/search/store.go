@@ -0,0 +1,33 @@
+package search
+
+import (
+	"database/sql"
+
+	"github.com/maximis3d/issue-tracking-system/service/issue"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// visibleProjects limits a search to the projects the user leads or is
+// assigned to, leaving out those requiring two-factor when it is not enabled.
+const visibleProjects = `i.project_key IN (
+        SELECT p.project_key
+        FROM projects p
+        JOIN users u ON u.id = ?
+        LEFT JOIN project_assignments pa ON pa.project_id = p.id AND pa.user_id = u.id
+        WHERE (p.project_lead = u.id OR pa.user_id IS NOT NULL)
+        AND (NOT p.require_2fa OR u.totp_enabled)
+    )`
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) SearchIssues(userID int, query types.IssueQuery, limit, offset int) (*types.IssuePage, error) {
+	query.Where = visibleProjects + " AND (" + query.Where + ")"
+	query.Args = append([]any{userID}, query.Args...)
+	return issue.QueryIssues(s.db, query, limit, offset)
+}
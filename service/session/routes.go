@@ -0,0 +1,105 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type Handler struct {
+	store     types.SessionStore
+	userStore types.UserStore
+}
+
+func NewHandler(store types.SessionStore, userStore types.UserStore) *Handler {
+	return &Handler{store: store, userStore: userStore}
+}
+
+// RegisterRoutes registers the session routes, they all require an authenticated user.
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/logout", h.handleLogout).Methods("POST")
+	router.HandleFunc("/sessions", h.handleGetSessions).Methods("GET")
+	router.HandleFunc("/sessions/{sessionID}", h.handleRevokeSession).Methods("DELETE")
+	router.HandleFunc("/users/{userID}/sessions", h.handleRevokeAllUserSessions).Methods("DELETE")
+}
+
+// handleLogout revokes the session the request was authenticated with.
+func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	sessionID := auth.GetSessionIDFromContext(r.Context())
+
+	if err := h.store.RevokeSession(userID, sessionID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to log out: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
+// handleGetSessions lists the active sessions of the authenticated user.
+func (h *Handler) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	currentSessionID := auth.GetSessionIDFromContext(r.Context())
+
+	sessions, err := h.store.GetActiveSessionsForUser(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to fetch sessions: %v", err))
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"message":  "Sessions fetched successfully",
+		"sessions": sessions,
+	})
+}
+
+// handleRevokeSession revokes one of the authenticated user's own sessions.
+func (h *Handler) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := strconv.Atoi(mux.Vars(r)["sessionID"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid sessionID: %v", err))
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	if err := h.store.RevokeSession(userID, sessionID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
+
+// handleRevokeAllUserSessions lets an admin sign a compromised account out everywhere.
+func (h *Handler) handleRevokeAllUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["userID"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid userID: %v", err))
+		return
+	}
+
+	if !auth.RequireAdmin(w, r, h.userStore) {
+		return
+	}
+
+	if _, err := h.userStore.GetUserByID(userID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return
+	}
+
+	if err := h.store.RevokeAllSessionsForUser(userID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "All sessions revoked successfully"})
+}
@@ -0,0 +1,201 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestSessionHandlers(t *testing.T) {
+	store := newMockSessionStore()
+	userStore := &mockUserStore{users: map[int]types.User{
+		1: {ID: 1, Email: "admin@example.com", IsAdmin: true},
+		2: {ID: 2, Email: "user@example.com"},
+	}}
+	handler := NewHandler(store, userStore)
+
+	adminSession, _ := store.CreateSession(types.Session{UserID: 1})
+	userSession, _ := store.CreateSession(types.Session{UserID: 2})
+	otherSession, _ := store.CreateSession(types.Session{UserID: 2})
+
+	t.Run("List Sessions", func(t *testing.T) {
+		t.Run("should list the user's active sessions", func(t *testing.T) {
+			rr := testRequest(t, handler, http.MethodGet, "/sessions", 2, userSession, http.StatusOK)
+
+			var body struct {
+				Sessions []types.Session `json:"sessions"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Sessions) != 2 {
+				t.Errorf("expected 2 sessions, got %d", len(body.Sessions))
+			}
+		})
+	})
+
+	t.Run("Revoke Session", func(t *testing.T) {
+		t.Run("should fail with invalid sessionID", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/sessions/abc", 2, userSession, http.StatusBadRequest)
+		})
+
+		t.Run("should not revoke another user's session", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, fmt.Sprintf("/sessions/%d", adminSession), 2, userSession, http.StatusNotFound)
+		})
+
+		t.Run("should revoke an own session", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, fmt.Sprintf("/sessions/%d", otherSession), 2, userSession, http.StatusOK)
+		})
+	})
+
+	t.Run("Revoke All User Sessions", func(t *testing.T) {
+		t.Run("should be forbidden for non admins", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/users/1/sessions", 2, userSession, http.StatusForbidden)
+		})
+
+		t.Run("should return 404 for unknown users", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/users/99/sessions", 1, adminSession, http.StatusNotFound)
+		})
+
+		t.Run("should revoke every session for admins", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/users/2/sessions", 1, adminSession, http.StatusOK)
+			if active, _ := store.IsSessionActive(userSession); active {
+				t.Error("expected the user's sessions to be revoked")
+			}
+		})
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		t.Run("should revoke the current session", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/logout", 1, adminSession, http.StatusOK)
+			if active, _ := store.IsSessionActive(adminSession); active {
+				t.Error("expected the session to be revoked")
+			}
+		})
+	})
+}
+
+func testRequest(t testing.TB, handler *Handler, method, path string, userID, sessionID int, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, path, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(req.Context(), auth.UserKey, userID)
+	ctx = context.WithValue(ctx, auth.SessionKey, sessionID)
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+	}
+	return rr
+}
+
+// -------------------- MOCK STORES --------------------
+
+type mockSessionStore struct {
+	sessions map[int]*types.Session
+}
+
+func newMockSessionStore() *mockSessionStore {
+	return &mockSessionStore{sessions: make(map[int]*types.Session)}
+}
+
+func (m *mockSessionStore) CreateSession(session types.Session) (int, error) {
+	session.ID = len(m.sessions) + 1
+	m.sessions[session.ID] = &session
+	return session.ID, nil
+}
+
+func (m *mockSessionStore) GetSessionByRefreshToken(tokenHash string) (*types.Session, error) {
+	return nil, fmt.Errorf("session not found")
+}
+
+func (m *mockSessionStore) RotateRefreshToken(sessionID int, oldHash, newHash string, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *mockSessionStore) IsSessionActive(sessionID int) (bool, error) {
+	s, ok := m.sessions[sessionID]
+	return ok && s.RevokedAt == nil, nil
+}
+
+func (m *mockSessionStore) GetActiveSessionsForUser(userID int) ([]types.Session, error) {
+	sessions := []types.Session{}
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *mockSessionStore) RevokeSession(userID, sessionID int) error {
+	s, ok := m.sessions[sessionID]
+	if !ok || s.UserID != userID || s.RevokedAt != nil {
+		return fmt.Errorf("session not found")
+	}
+	now := time.Now()
+	s.RevokedAt = &now
+	return nil
+}
+
+func (m *mockSessionStore) RevokeAllSessionsForUser(userID int) error {
+	for _, s := range m.sessions {
+		if s.UserID == userID {
+			m.RevokeSession(userID, s.ID)
+		}
+	}
+	return nil
+}
+
+type mockUserStore struct {
+	users map[int]types.User
+}
+
+func (m *mockUserStore) GetUserByEmail(email string) (*types.User, error) {
+	return nil, fmt.Errorf("user not found")
+}
+
+func (m *mockUserStore) GetUserByID(id int) (*types.User, error) {
+	u, ok := m.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &u, nil
+}
+
+func (m *mockUserStore) CreateUser(user types.User) error {
+	return nil
+}
+
+func (m *mockUserStore) MarkEmailVerified(userID int) error {
+	return nil
+}
+
+func (m *mockUserStore) UpdatePassword(userID int, hashedPassword string) error {
+	return nil
+}
+
+func (m *mockUserStore) UpdateProfile(userID int, firstName, lastName, email string) error {
+	return nil
+}
+
+func (m *mockUserStore) SetUserActive(userID int, active bool) error {
+	return nil
+}
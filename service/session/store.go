@@ -0,0 +1,175 @@
+package session
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateSession(session types.Session) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		session.UserID, session.TokenHash, session.UserAgent, session.IPAddress, session.ExpiresAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert session: %v", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve session id: %v", err)
+	}
+
+	return int(id), nil
+}
+
+// GetSessionByRefreshToken finds the session that currently owns the token,
+// or the one it was rotated away from so reuse can be detected.
+func (s *Store) GetSessionByRefreshToken(tokenHash string) (*types.Session, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, refresh_token_hash, previous_token_hash, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE refresh_token_hash = ? OR previous_token_hash = ?
+		LIMIT 1`, tokenHash, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session: %v", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	return scanRowIntoSession(rows)
+}
+
+// RotateRefreshToken swaps the refresh token of an active session, failing if
+// another request already rotated it.
+func (s *Store) RotateRefreshToken(sessionID int, oldHash, newHash string, expiresAt time.Time) error {
+	res, err := s.db.Exec(`
+		UPDATE sessions
+		SET previous_token_hash = refresh_token_hash, refresh_token_hash = ?, expires_at = ?, last_used_at = NOW()
+		WHERE id = ? AND refresh_token_hash = ? AND revoked_at IS NULL`,
+		newHash, expiresAt, sessionID, oldHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("session %d is no longer active", sessionID)
+	}
+
+	return nil
+}
+
+func (s *Store) IsSessionActive(sessionID int) (bool, error) {
+	var active bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM sessions WHERE id = ? AND revoked_at IS NULL AND expires_at > NOW())`,
+		sessionID).Scan(&active)
+	if err != nil {
+		return false, err
+	}
+	return active, nil
+}
+
+func (s *Store) GetActiveSessionsForUser(userID int) ([]types.Session, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, refresh_token_hash, previous_token_hash, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []types.Session{}
+	for rows.Next() {
+		session, err := scanRowIntoSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return sessions, nil
+}
+
+func (s *Store) RevokeSession(userID, sessionID int) error {
+	res, err := s.db.Exec(`
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %v", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %v", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+func (s *Store) RevokeAllSessionsForUser(userID int) error {
+	_, err := s.db.Exec(`
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE user_id = ? AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+	return nil
+}
+
+func scanRowIntoSession(rows *sql.Rows) (*types.Session, error) {
+	session := new(types.Session)
+	var previousHash sql.NullString
+	var revokedAt sql.NullTime
+
+	err := rows.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.TokenHash,
+		&previousHash,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan session: %v", err)
+	}
+
+	session.PreviousTokenHash = previousHash.String
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, nil
+}
@@ -7,21 +7,25 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/service/issue"
 	"github.com/maximis3d/issue-tracking-system/types"
 	"github.com/maximis3d/issue-tracking-system/utils"
 )
 
 type Handler struct {
 	store types.SprintStore
+	roles types.RoleStore
 }
 
-func NewHandler(store types.SprintStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.SprintStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, roles: roles}
 }
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/sprints", h.handleCreateSprint).Methods("POST")
 	router.HandleFunc("/sprints/{sprintID}/issues/{issueID}", h.handleAddIssueToSprint).Methods("POST")
 	router.HandleFunc("/sprints/{sprintID}/issues", h.handleGetIssuesInSprint).Methods("GET")
+	router.HandleFunc("/sprints/{sprintID}/points", h.handleGetPointTotals).Methods("GET")
 
 }
 
@@ -40,6 +44,10 @@ func (h *Handler) handleCreateSprint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.RequireProjectPermission(w, r, h.roles, sprint.ProjectKey, auth.PermManageSprints) {
+		return
+	}
+
 	// Create the sprint using the store
 	err := h.store.CreateSprint(types.Sprint{
 		Name:        sprint.Name,
@@ -73,6 +81,16 @@ func (h *Handler) handleAddIssueToSprint(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	sprint, err := h.store.GetSprintByID(sprintID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, sprint.ProjectKey, auth.PermManageSprints) {
+		return
+	}
+
 	// Call store method to add the issue to the sprint
 	err = h.store.AddIssueToSprint(issueID, sprintID)
 	if err != nil {
@@ -94,14 +112,63 @@ func (h *Handler) handleGetIssuesInSprint(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	issues, err := h.store.GetIssuesInSprint(sprintID)
+	sprint, err := h.store.GetSprintByID(sprintID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, sprint.ProjectKey, auth.PermViewIssues) {
+		return
+	}
+
+	filter, err := issue.ParseFilter(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.store.GetIssuesInSprint(sprintID, filter)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to fetch issues for sprint: %v", err))
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]any{
-		"message": "Issues fetched successfully",
-		"issues":  issues,
+		"message":     "Issues fetched successfully",
+		"issues":      page.Issues,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// handleGetPointTotals adds up the story points and estimates of the issues
+// in the sprint, the done points are its velocity.
+func (h *Handler) handleGetPointTotals(w http.ResponseWriter, r *http.Request) {
+	sprintID, err := strconv.Atoi(mux.Vars(r)["sprintID"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid sprint ID"))
+		return
+	}
+
+	sprint, err := h.store.GetSprintByID(sprintID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, sprint.ProjectKey, auth.PermViewIssues) {
+		return
+	}
+
+	totals, err := h.store.GetPointTotals(sprintID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to fetch point totals for sprint: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"sprint_id": sprintID,
+		"points":    totals,
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/maximis3d/issue-tracking-system/service/issue"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
@@ -27,6 +28,22 @@ func (s *Store) CreateSprint(sprint types.Sprint) error {
 	return nil
 }
 
+func (s *Store) GetSprintByID(id int) (*types.Sprint, error) {
+	sprint := new(types.Sprint)
+	err := s.db.QueryRow(`
+        SELECT id, name, description, start_date, end_date, project_key
+        FROM sprints
+        WHERE id = ?`, id).
+		Scan(&sprint.ID, &sprint.Name, &sprint.Description, &sprint.StartDate, &sprint.EndDate, &sprint.ProjectKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("sprint with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to fetch sprint: %v", err)
+	}
+	return sprint, nil
+}
+
 func (s *Store) AddIssueToSprint(issueID, sprintID int) error {
 	var sprintProjectKey string
 	err := s.db.QueryRow("SELECT project_key FROM sprints WHERE id = ?", sprintID).Scan(&sprintProjectKey)
@@ -59,26 +76,12 @@ func (s *Store) AddIssueToSprint(issueID, sprintID int) error {
 	return nil
 }
 
-func (s *Store) GetIssuesInSprint(sprintID int) ([]types.Issue, error) {
-	rows, err := s.db.Query("SELECT id, `key`, summary, `description`, project_key, reporter, assignee, status, issueType FROM issues WHERE sprint_id = ?", sprintID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query issues: %v", err)
-	}
-	defer rows.Close()
-
-	var issues []types.Issue
-	for rows.Next() {
-		var issue types.Issue
-		err := rows.Scan(&issue.ID, &issue.Key, &issue.Summary, &issue.Description, &issue.ProjectKey, &issue.Reporter, &issue.Assignee, &issue.Status, &issue.IssueType)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan issue row: %v", err)
-		}
-		issues = append(issues, issue)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error after iterating rows: %v", err)
-	}
+func (s *Store) GetIssuesInSprint(sprintID int, filter types.IssueFilter) (*types.IssuePage, error) {
+	return issue.ListIssues(s.db, "i.sprint_id = ?", []any{sprintID}, filter)
+}
 
-	return issues, nil
+// GetPointTotals counts stories, tasks and bugs only, the points of sub-tasks
+// and epics are already counted with them.
+func (s *Store) GetPointTotals(sprintID int) (*types.PointTotals, error) {
+	return issue.SumPoints(s.db, "i.sprint_id = ? AND i.issueType NOT IN (?, ?)", []any{sprintID, types.IssueTypeSubTask, types.IssueTypeEpic})
 }
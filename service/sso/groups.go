@@ -0,0 +1,70 @@
+package sso
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+)
+
+// ProjectGrant is a role on a project granted by membership of a group.
+type ProjectGrant struct {
+	ProjectKey string
+	Role       string
+}
+
+// GroupRoles maps identity provider groups to project roles.
+type GroupRoles map[string][]ProjectGrant
+
+// ParseGroupRoles parses a mapping like "eng-leads=API:lead,eng=API:member".
+// A group may appear more than once to grant roles on several projects.
+func ParseGroupRoles(s string) (GroupRoles, error) {
+	roles := GroupRoles{}
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		group, grant, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid group role mapping %q, expected group=PROJECT:role", entry)
+		}
+		projectKey, role, ok := strings.Cut(grant, ":")
+		if !ok || strings.TrimSpace(group) == "" || strings.TrimSpace(projectKey) == "" {
+			return nil, fmt.Errorf("invalid group role mapping %q, expected group=PROJECT:role", entry)
+		}
+
+		role = strings.TrimSpace(role)
+		if !auth.IsValidRole(role) {
+			return nil, fmt.Errorf("invalid role %q in group role mapping %q", role, entry)
+		}
+
+		group = strings.TrimSpace(group)
+		roles[group] = append(roles[group], ProjectGrant{ProjectKey: strings.TrimSpace(projectKey), Role: role})
+	}
+
+	return roles, nil
+}
+
+// RolesFor returns the role to hold on every project the mapping covers,
+// the highest one granted by the groups. Projects none of the groups grant
+// a role on map to an empty role, so membership is removed when a user
+// leaves a group.
+func (g GroupRoles) RolesFor(groups []string) map[string]string {
+	roles := make(map[string]string)
+	for _, grants := range g {
+		for _, grant := range grants {
+			roles[grant.ProjectKey] = ""
+		}
+	}
+
+	for _, group := range groups {
+		for _, grant := range g[group] {
+			roles[grant.ProjectKey] = auth.HigherRole(roles[grant.ProjectKey], grant.Role)
+		}
+	}
+
+	return roles
+}
@@ -0,0 +1,206 @@
+package sso
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+// stateTTL is how long the user has to sign in at the identity provider.
+const stateTTL = 10 * time.Minute
+
+var errAccountExists = errors.New("an account with this email already exists, sign in with your password")
+
+type Handler struct {
+	provider   *Provider
+	groupRoles GroupRoles
+	store      types.SSOStore
+	users      types.UserStore
+	sessions   types.SessionStore
+	roles      types.ProjectRoleSyncStore
+}
+
+// NewHandler returns the single sign-on handler, provider may be nil when
+// single sign-on is not configured.
+func NewHandler(provider *Provider, groupRoles GroupRoles, store types.SSOStore, users types.UserStore, sessions types.SessionStore, roles types.ProjectRoleSyncStore) *Handler {
+	return &Handler{provider: provider, groupRoles: groupRoles, store: store, users: users, sessions: sessions, roles: roles}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/sso/login", h.handleLogin).Methods("GET")
+	router.HandleFunc("/sso/callback", h.handleCallback).Methods("POST")
+}
+
+// handleLogin redirects to the identity provider. The PKCE verifier and the
+// nonce stay on the server, only the state travels with the user.
+func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if h.provider == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("single sign-on is not configured"))
+		return
+	}
+
+	var state types.SSOState
+	for _, v := range []*string{&state.State, &state.CodeVerifier, &state.Nonce} {
+		token, err := auth.GenerateToken()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		*v = token
+	}
+	state.ExpiresAt = time.Now().Add(stateTTL)
+
+	authURL, err := h.provider.AuthCodeURL(r.Context(), state.State, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		log.Printf("failed to build sso authorization url: %v", err)
+		utils.WriteError(w, http.StatusBadGateway, fmt.Errorf("identity provider is unavailable"))
+		return
+	}
+
+	if err := h.store.CreateSSOState(state); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleCallback finishes the login once the identity provider redirected
+// back to the frontend, which posts the code and state here. Users are
+// created on their first login and their project roles follow their groups.
+// Local two-factor authentication is left to the identity provider.
+func (h *Handler) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if h.provider == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("single sign-on is not configured"))
+		return
+	}
+
+	var payload types.SSOCallbackPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	state, err := h.store.ConsumeSSOState(payload.State)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired sso state"))
+		return
+	}
+
+	rawIDToken, err := h.provider.Exchange(r.Context(), payload.Code, state.CodeVerifier)
+	if err != nil {
+		log.Printf("sso code exchange failed: %v", err)
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("failed to sign in with the identity provider"))
+		return
+	}
+
+	claims, err := h.provider.VerifyIDToken(r.Context(), rawIDToken, state.Nonce)
+	if err != nil {
+		log.Printf("sso id token rejected: %v", err)
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("failed to sign in with the identity provider"))
+		return
+	}
+
+	u, err := h.resolveUser(claims)
+	if err != nil {
+		if err == errAccountExists {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !u.IsActive {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("account is deactivated"))
+		return
+	}
+
+	for projectKey, role := range h.groupRoles.RolesFor(claims.Groups) {
+		if err := h.roles.SetProjectRole(projectKey, u.ID, role); err != nil {
+			log.Printf("failed to sync role on project %s for user %d: %v", projectKey, u.ID, err)
+		}
+	}
+
+	tokens, err := auth.CreateSession(h.sessions, u.ID, r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// resolveUser finds the user linked to the identity. Otherwise an existing
+// account is linked when the provider vouches for the email address, or a
+// new account without a password is created.
+func (h *Handler) resolveUser(claims *IDTokenClaims) (*types.User, error) {
+	userID, err := h.store.GetUserIDByIdentity(claims.Issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 {
+		return h.users.GetUserByID(userID)
+	}
+
+	if claims.Email == "" {
+		return nil, fmt.Errorf("identity provider did not return an email address")
+	}
+
+	u, err := h.users.GetUserByEmail(claims.Email)
+	if err == nil && !claims.EmailVerified {
+		return nil, errAccountExists
+	}
+
+	if err != nil {
+		firstName, lastName := claimNames(claims)
+		if err := h.users.CreateUser(types.User{FirstName: firstName, LastName: lastName, Email: claims.Email}); err != nil {
+			return nil, err
+		}
+		if u, err = h.users.GetUserByEmail(claims.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	if claims.EmailVerified && u.EmailVerifiedAt == nil {
+		if err := h.users.MarkEmailVerified(u.ID); err != nil {
+			log.Printf("failed to mark email of user %d as verified: %v", u.ID, err)
+		}
+	}
+
+	if err := h.store.LinkIdentity(u.ID, claims.Issuer, claims.Subject); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func claimNames(claims *IDTokenClaims) (string, string) {
+	if claims.GivenName != "" || claims.FamilyName != "" {
+		return claims.GivenName, claims.FamilyName
+	}
+
+	if first, last, ok := strings.Cut(strings.TrimSpace(claims.Name), " "); ok {
+		return first, strings.TrimSpace(last)
+	}
+	if claims.Name != "" {
+		return claims.Name, ""
+	}
+
+	local, _, _ := strings.Cut(claims.Email, "@")
+	return local, ""
+}
@@ -0,0 +1,439 @@
+package sso
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestSSOHandlers(t *testing.T) {
+	idp := newFakeIDP(t)
+	defer idp.server.Close()
+
+	groupRoles, err := ParseGroupRoles("eng-leads=API:lead, eng=API:member, eng=WEB:member")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMockSSOStore()
+	users := newMockUserStore()
+	roles := &mockRoleSyncStore{roles: make(map[string]string)}
+	provider := NewProvider(ProviderConfig{
+		IssuerURL:   idp.server.URL,
+		ClientID:    "its",
+		RedirectURL: "http://localhost:5173/sso/callback",
+		Scopes:      []string{"openid", "email", "profile", "groups"},
+	})
+	handler := NewHandler(provider, groupRoles, store, users, &mockSessionStore{}, roles)
+
+	t.Run("should return 404 when not configured", func(t *testing.T) {
+		disabled := NewHandler(nil, nil, store, users, &mockSessionStore{}, roles)
+		testRequest(t, disabled, http.MethodGet, "/sso/login", nil, http.StatusNotFound)
+	})
+
+	t.Run("should create the user on first login and map groups to roles", func(t *testing.T) {
+		idp.claims = jwt.MapClaims{
+			"sub": "alice-1", "email": "alice@example.com", "email_verified": true,
+			"given_name": "Alice", "family_name": "Smith", "groups": []string{"eng", "eng-leads"},
+		}
+		code, state := idp.authorize(t, handler)
+
+		rr := testRequest(t, handler, http.MethodPost, "/sso/callback", types.SSOCallbackPayload{Code: code, State: state}, http.StatusOK)
+		var tokens types.TokenPair
+		if err := json.NewDecoder(rr.Body).Decode(&tokens); err != nil || tokens.AccessToken == "" {
+			t.Fatalf("expected a token pair, got %s", rr.Body.String())
+		}
+
+		u, err := users.GetUserByEmail("alice@example.com")
+		if err != nil {
+			t.Fatal("expected the user to be created")
+		}
+		if u.FirstName != "Alice" || u.Password != "" || u.EmailVerifiedAt == nil {
+			t.Errorf("unexpected user %+v", u)
+		}
+		if roles.roles["API"] != "lead" || roles.roles["WEB"] != "member" {
+			t.Errorf("unexpected roles %v", roles.roles)
+		}
+
+		t.Run("should not accept the state twice", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/sso/callback", types.SSOCallbackPayload{Code: code, State: state}, http.StatusBadRequest)
+		})
+	})
+
+	t.Run("should reuse the linked user and follow group changes", func(t *testing.T) {
+		idp.claims = jwt.MapClaims{
+			"sub": "alice-1", "email": "alice@new.example.com", "email_verified": true, "groups": []string{"other"},
+		}
+		code, state := idp.authorize(t, handler)
+		testRequest(t, handler, http.MethodPost, "/sso/callback", types.SSOCallbackPayload{Code: code, State: state}, http.StatusOK)
+
+		if len(users.users) != 1 {
+			t.Errorf("expected no new user, got %d users", len(users.users))
+		}
+		if roles.roles["API"] != "" || roles.roles["WEB"] != "" {
+			t.Errorf("expected roles to be removed, got %v", roles.roles)
+		}
+	})
+
+	t.Run("should fail when the code verifier does not match", func(t *testing.T) {
+		idp.claims = jwt.MapClaims{"sub": "bob-1", "email": "bob@example.com", "email_verified": true}
+		code, _ := idp.authorize(t, handler)
+		_, otherState := idp.authorize(t, handler)
+
+		testRequest(t, handler, http.MethodPost, "/sso/callback", types.SSOCallbackPayload{Code: code, State: otherState}, http.StatusUnauthorized)
+	})
+
+	t.Run("should not take over an account with an unverified email", func(t *testing.T) {
+		users.CreateUser(types.User{FirstName: "Carol", Email: "carol@example.com", Password: "hash"})
+		idp.claims = jwt.MapClaims{"sub": "carol-1", "email": "carol@example.com", "email_verified": false}
+		code, state := idp.authorize(t, handler)
+
+		testRequest(t, handler, http.MethodPost, "/sso/callback", types.SSOCallbackPayload{Code: code, State: state}, http.StatusConflict)
+	})
+
+	t.Run("should reject tokens signed by another key", func(t *testing.T) {
+		idp.claims = jwt.MapClaims{"sub": "dave-1", "email": "dave@example.com"}
+		idp.signWithRogueKey = true
+		defer func() { idp.signWithRogueKey = false }()
+		code, state := idp.authorize(t, handler)
+
+		testRequest(t, handler, http.MethodPost, "/sso/callback", types.SSOCallbackPayload{Code: code, State: state}, http.StatusUnauthorized)
+	})
+}
+
+func TestGroupRoles(t *testing.T) {
+	if _, err := ParseGroupRoles("eng=API:owner"); err == nil {
+		t.Error("expected an unknown role to be rejected")
+	}
+	if _, err := ParseGroupRoles("eng"); err == nil {
+		t.Error("expected a malformed mapping to be rejected")
+	}
+
+	g, err := ParseGroupRoles("eng=API:member,leads=API:maintainer,ops=OPS:viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roles := g.RolesFor([]string{"leads", "eng"})
+	if roles["API"] != "maintainer" || roles["OPS"] != "" {
+		t.Errorf("unexpected roles %v", roles)
+	}
+}
+
+// fakeIDP is a small OpenID Connect provider standing in for the real one.
+// Its authorize endpoint signs the user in right away with claims.
+type fakeIDP struct {
+	server           *httptest.Server
+	key              *rsa.PrivateKey
+	rogueKey         *rsa.PrivateKey
+	signWithRogueKey bool
+	claims           jwt.MapClaims
+	grants           map[string]fakeGrant
+	issued           int
+}
+
+type fakeGrant struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+	claims      jwt.MapClaims
+}
+
+func newFakeIDP(t testing.TB) *fakeIDP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rogueKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &fakeIDP{key: key, rogueKey: rogueKey, grants: make(map[string]fakeGrant)}
+	router := mux.NewRouter()
+	router.HandleFunc("/.well-known/openid-configuration", idp.handleDiscovery)
+	router.HandleFunc("/jwks", idp.handleJWKS)
+	router.HandleFunc("/authorize", idp.handleAuthorize)
+	router.HandleFunc("/token", idp.handleToken).Methods("POST")
+	idp.server = httptest.NewServer(router)
+
+	return idp
+}
+
+func (idp *fakeIDP) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 idp.server.URL,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+func (idp *fakeIDP) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+		"kid": "test-key",
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+	}}})
+}
+
+func (idp *fakeIDP) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	idp.issued++
+	code := fmt.Sprintf("code-%d", idp.issued)
+	idp.grants[code] = fakeGrant{
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		redirectURI: q.Get("redirect_uri"),
+		claims:      idp.claims,
+	}
+
+	http.Redirect(w, r, q.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (idp *fakeIDP) handleToken(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	grant, ok := idp.grants[r.PostForm.Get("code")]
+	delete(idp.grants, r.PostForm.Get("code"))
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != grant.redirectURI ||
+		CodeChallenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   r.PostForm.Get("client_id"),
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": grant.nonce,
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	key := idp.key
+	if idp.signWithRogueKey {
+		key = idp.rogueKey
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+// authorize starts a login on the handler, follows it through the provider
+// and returns the code and state the frontend would post to the callback.
+func (idp *fakeIDP) authorize(t testing.TB, handler *Handler) (string, string) {
+	t.Helper()
+
+	rr := testRequest(t, handler, http.MethodGet, "/sso/login", nil, http.StatusFound)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected the provider to redirect back, got %d", resp.StatusCode)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback.Query().Get("code"), callback.Query().Get("state")
+}
+
+func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body *bytes.Buffer
+	if payload != nil {
+		marshalled, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewBuffer(marshalled)
+	} else {
+		body = &bytes.Buffer{}
+	}
+
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d: %s", expectedStatus, rr.Code, rr.Body.String())
+	}
+	return rr
+}
+
+// mockSSOStore - Mock implementation of the sso store
+type mockSSOStore struct {
+	states     map[string]types.SSOState
+	identities map[string]int
+}
+
+func newMockSSOStore() *mockSSOStore {
+	return &mockSSOStore{states: make(map[string]types.SSOState), identities: make(map[string]int)}
+}
+
+func (m *mockSSOStore) CreateSSOState(state types.SSOState) error {
+	m.states[state.State] = state
+	return nil
+}
+
+func (m *mockSSOStore) ConsumeSSOState(state string) (*types.SSOState, error) {
+	st, ok := m.states[state]
+	if !ok || time.Now().After(st.ExpiresAt) {
+		return nil, fmt.Errorf("sso state not found or expired")
+	}
+	delete(m.states, state)
+	return &st, nil
+}
+
+func (m *mockSSOStore) GetUserIDByIdentity(issuer, subject string) (int, error) {
+	return m.identities[issuer+"|"+subject], nil
+}
+
+func (m *mockSSOStore) LinkIdentity(userID int, issuer, subject string) error {
+	m.identities[issuer+"|"+subject] = userID
+	return nil
+}
+
+// mockUserStore - Mock implementation of the user store
+type mockUserStore struct {
+	users map[int]types.User
+}
+
+func newMockUserStore() *mockUserStore {
+	return &mockUserStore{users: make(map[int]types.User)}
+}
+
+func (m *mockUserStore) GetUserByEmail(email string) (*types.User, error) {
+	for _, u := range m.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (m *mockUserStore) GetUserByID(id int) (*types.User, error) {
+	u, ok := m.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &u, nil
+}
+
+func (m *mockUserStore) CreateUser(user types.User) error {
+	user.ID = len(m.users) + 1
+	user.IsActive = true
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *mockUserStore) MarkEmailVerified(userID int) error {
+	u := m.users[userID]
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	m.users[userID] = u
+	return nil
+}
+
+func (m *mockUserStore) UpdatePassword(userID int, hashedPassword string) error {
+	return nil
+}
+
+func (m *mockUserStore) UpdateProfile(userID int, firstName, lastName, email string) error {
+	return nil
+}
+
+func (m *mockUserStore) SetUserActive(userID int, active bool) error {
+	return nil
+}
+
+// mockSessionStore - Session store that only hands out session ids
+type mockSessionStore struct {
+	nextID int
+}
+
+func (m *mockSessionStore) CreateSession(types.Session) (int, error) {
+	m.nextID++
+	return m.nextID, nil
+}
+
+func (m *mockSessionStore) GetSessionByRefreshToken(string) (*types.Session, error) {
+	return nil, fmt.Errorf("session not found")
+}
+
+func (m *mockSessionStore) RotateRefreshToken(int, string, string, time.Time) error {
+	return nil
+}
+
+func (m *mockSessionStore) IsSessionActive(int) (bool, error) {
+	return true, nil
+}
+
+func (m *mockSessionStore) GetActiveSessionsForUser(int) ([]types.Session, error) {
+	return nil, nil
+}
+
+func (m *mockSessionStore) RevokeSession(int, int) error {
+	return nil
+}
+
+func (m *mockSessionStore) RevokeAllSessionsForUser(int) error {
+	return nil
+}
+
+// mockRoleSyncStore - Records the synced role per project
+type mockRoleSyncStore struct {
+	roles map[string]string
+}
+
+func (m *mockRoleSyncStore) SetProjectRole(projectKey string, userID int, role string) error {
+	m.roles[projectKey] = role
+	return nil
+}
@@ -0,0 +1,73 @@
+package sso
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateSSOState(state types.SSOState) error {
+	_, err := s.db.Exec("INSERT INTO sso_states (state, code_verifier, nonce, expires_at) VALUES (?, ?, ?, ?)",
+		state.State, state.CodeVerifier, state.Nonce, state.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert sso state: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) ConsumeSSOState(state string) (*types.SSOState, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	st := new(types.SSOState)
+	err = tx.QueryRow("SELECT state, code_verifier, nonce, expires_at FROM sso_states WHERE state = ? AND expires_at > NOW() FOR UPDATE", state).
+		Scan(&st.State, &st.CodeVerifier, &st.Nonce, &st.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("sso state not found or expired")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sso state: %v", err)
+	}
+
+	// Expired states are cleaned up along the way
+	if _, err := tx.Exec("DELETE FROM sso_states WHERE state = ? OR expires_at <= NOW()", state); err != nil {
+		return nil, fmt.Errorf("failed to delete sso state: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func (s *Store) GetUserIDByIdentity(issuer, subject string) (int, error) {
+	var userID int
+	err := s.db.QueryRow("SELECT user_id FROM user_identities WHERE issuer = ? AND subject = ?", issuer, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch identity: %v", err)
+	}
+	return userID, nil
+}
+
+func (s *Store) LinkIdentity(userID int, issuer, subject string) error {
+	_, err := s.db.Exec("INSERT INTO user_identities (user_id, issuer, subject) VALUES (?, ?, ?)", userID, issuer, subject)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %v", err)
+	}
+	return nil
+}
@@ -6,16 +6,18 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 	"github.com/maximis3d/issue-tracking-system/utils"
 )
 
 type Handler struct {
 	store types.StandupStore
+	roles types.RoleStore
 }
 
-func NewHandler(store types.StandupStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.StandupStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, roles: roles}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
@@ -31,6 +33,10 @@ func (h *Handler) handleCreateStandup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !auth.RequireProjectPermission(w, r, h.roles, standup.ProjectKey, auth.PermRunStandups) {
+		return
+	}
+
 	// Get the last finished standup's end_time
 	lastEndTime, err := h.store.GetLastStandupEndTime(standup.ProjectKey)
 	if err != nil {
@@ -45,6 +51,12 @@ func (h *Handler) handleCreateStandup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	changes, err := h.store.GetChangesSince(standup.ProjectKey, lastEndTime)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to fetch issue changes: %v", err))
+		return
+	}
+
 	// Create the new standup (this will only happen after filtering the issues)
 	if err := h.store.CreateStandup(standup); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -55,6 +67,7 @@ func (h *Handler) handleCreateStandup(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusCreated, map[string]any{
 		"message": "Standup Created Successfully",
 		"issues":  issues,
+		"changes": changes,
 	})
 }
 
@@ -69,6 +82,11 @@ func (h *Handler) handleEndStandUp(w http.ResponseWriter, r *http.Request) {
 	if err := utils.Validate.Struct(standup); err != nil {
 		errors := err.(validator.ValidationErrors)
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid palyad: %v", errors))
+		return
+	}
+
+	if !auth.RequireProjectPermission(w, r, h.roles, standup.ProjectKey, auth.PermRunStandups) {
+		return
 	}
 
 	if err := h.store.EndCurrentStandUp(standup); err != nil {
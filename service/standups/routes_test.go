@@ -2,6 +2,7 @@ package standups
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -10,19 +11,37 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
 func TestStandupHandlers(t *testing.T) {
 	store := newMockStandupStore()
-	handler := NewHandler(store)
+	handler := NewHandler(store, &mockRoleStore{roles: map[string]string{"project-1": "member", "project-2": "viewer"}})
 
 	t.Run("Create Standup", func(t *testing.T) {
 		t.Run("should create a new standup", func(t *testing.T) {
 			standup := types.Standup{
 				ProjectKey: "project-1",
 			}
-			testRequest(t, handler, http.MethodPost, "/standups/start", standup, http.StatusCreated)
+			rr := testRequest(t, handler, http.MethodPost, "/standups/start", standup, http.StatusCreated)
+
+			var resp struct {
+				Changes []types.IssueChange `json:"changes"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Changes) != 1 || resp.Changes[0].Field != "status" {
+				t.Errorf("expected the status change since the last standup, got %+v", resp.Changes)
+			}
+		})
+
+		t.Run("should be forbidden for viewers", func(t *testing.T) {
+			standup := types.Standup{
+				ProjectKey: "project-2",
+			}
+			testRequest(t, handler, http.MethodPost, "/standups/start", standup, http.StatusForbidden)
 		})
 	})
 
@@ -36,7 +55,7 @@ func TestStandupHandlers(t *testing.T) {
 	})
 }
 
-func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) {
+func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
 	t.Helper()
 
 	var body *bytes.Buffer
@@ -55,6 +74,7 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 		t.Fatal(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
 
 	rr := httptest.NewRecorder()
 	router := mux.NewRouter()
@@ -64,6 +84,8 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 	if rr.Code != expectedStatus {
 		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
 	}
+
+	return rr
 }
 
 // -------------------- MOCK STORE --------------------
@@ -109,3 +131,23 @@ func (m *mockStandupStore) GetLastStandupEndTime(projectKey string) (sql.NullTim
 	// Simulating fetching the last standup end time
 	return sql.NullTime{Valid: true, Time: time.Now()}, nil
 }
+
+func (m *mockStandupStore) GetChangesSince(projectKey string, since sql.NullTime) ([]types.IssueChange, error) {
+	// Simulating the history since the last standup
+	return []types.IssueChange{
+		{ID: 1, IssueID: 1, IssueKey: projectKey + "-1", Field: "status", OldValue: "open", NewValue: "in_progress"},
+	}, nil
+}
+
+// mockRoleStore - Mock implementation of the role store
+type mockRoleStore struct {
+	roles map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
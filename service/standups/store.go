@@ -159,3 +159,38 @@ func (s *Store) FilterTicketsByEndTime(projectKey string, lastEndTime sql.NullTi
 
 	return issues, nil
 }
+
+// GetChangesSince returns the field changes of the project's issues after
+// the given time, oldest first, so a standup shows what actually changed.
+func (s *Store) GetChangesSince(projectKey string, since sql.NullTime) ([]types.IssueChange, error) {
+	query := `
+		SELECT h.id, h.issue_id, i.key, COALESCE(h.actor_id, 0), h.field,
+			COALESCE(h.old_value, ''), COALESCE(h.new_value, ''), h.changed_at
+		FROM issue_history h
+		JOIN issues i ON i.id = h.issue_id
+		WHERE i.project_key = ?`
+	args := []any{projectKey}
+
+	if since.Valid {
+		query += " AND h.changed_at > ?"
+		args = append(args, since.Time)
+	}
+	query += " ORDER BY h.changed_at, h.id"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []types.IssueChange
+	for rows.Next() {
+		var c types.IssueChange
+		if err := rows.Scan(&c.ID, &c.IssueID, &c.IssueKey, &c.ActorID, &c.Field, &c.OldValue, &c.NewValue, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
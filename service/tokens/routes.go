@@ -0,0 +1,112 @@
+package tokens
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type Handler struct {
+	store types.AccessTokenStore
+}
+
+func NewHandler(store types.AccessTokenStore) *Handler {
+	return &Handler{store: store}
+}
+
+// RegisterRoutes registers the personal access token routes.
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tokens", h.handleCreateToken).Methods("POST")
+	router.HandleFunc("/tokens", h.handleGetTokens).Methods("GET")
+	router.HandleFunc("/tokens/{tokenID}", h.handleRevokeToken).Methods("DELETE")
+}
+
+// handleCreateToken creates a personal access token for the authenticated
+// user. The plain token is only ever returned in this response.
+func (h *Handler) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	var payload types.AccessTokenPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	for _, scope := range payload.Scopes {
+		if !auth.IsValidScope(scope) {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid scope %q", scope))
+			return
+		}
+	}
+
+	plainToken, err := auth.GenerateAccessToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	token := types.AccessToken{
+		UserID:    auth.GetUserIDFromContext(r.Context()),
+		Name:      payload.Name,
+		TokenHash: auth.HashToken(plainToken),
+		Scopes:    payload.Scopes,
+		CreatedAt: time.Now(),
+	}
+	if payload.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, payload.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	token.ID, err = h.store.CreateAccessToken(token)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to create access token: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]any{
+		"message":     "Access token created successfully, copy it now as it will not be shown again",
+		"token":       plainToken,
+		"accessToken": token,
+	})
+}
+
+// handleGetTokens lists the authenticated user's access tokens.
+func (h *Handler) handleGetTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.store.GetAccessTokensForUser(auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to fetch access tokens: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "Access tokens fetched successfully",
+		"tokens":  tokens,
+	})
+}
+
+// handleRevokeToken revokes one of the authenticated user's access tokens.
+func (h *Handler) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := strconv.Atoi(mux.Vars(r)["tokenID"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid tokenID: %v", err))
+		return
+	}
+
+	if err := h.store.RevokeAccessToken(auth.GetUserIDFromContext(r.Context()), tokenID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Access token revoked successfully"})
+}
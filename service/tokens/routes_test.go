@@ -0,0 +1,148 @@
+package tokens
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestAccessTokenHandlers(t *testing.T) {
+	store := newMockAccessTokenStore()
+	handler := NewHandler(store)
+
+	t.Run("Create Token", func(t *testing.T) {
+		t.Run("should fail if payload is invalid", func(t *testing.T) {
+			payload := types.AccessTokenPayload{Name: "ci"}
+			testRequest(t, handler, http.MethodPost, "/tokens", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should fail with an unknown scope", func(t *testing.T) {
+			payload := types.AccessTokenPayload{Name: "ci", Scopes: []string{"admin:everything"}}
+			testRequest(t, handler, http.MethodPost, "/tokens", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should create a token and only store its hash", func(t *testing.T) {
+			payload := types.AccessTokenPayload{Name: "ci", Scopes: []string{"read:issues"}, ExpiresInDays: 30}
+			rr := testRequest(t, handler, http.MethodPost, "/tokens", payload, http.StatusCreated)
+
+			var body struct {
+				Token string `json:"token"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if !auth.IsPersonalAccessToken(body.Token) {
+				t.Errorf("expected a personal access token, got %q", body.Token)
+			}
+
+			stored := store.tokens[1]
+			if stored.TokenHash != auth.HashToken(body.Token) {
+				t.Error("expected the stored hash to match the returned token")
+			}
+			if stored.ExpiresAt == nil {
+				t.Error("expected the token to expire")
+			}
+		})
+	})
+
+	t.Run("List Tokens", func(t *testing.T) {
+		testRequest(t, handler, http.MethodGet, "/tokens", nil, http.StatusOK)
+	})
+
+	t.Run("Revoke Token", func(t *testing.T) {
+		t.Run("should fail with invalid tokenID", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/tokens/abc", nil, http.StatusBadRequest)
+		})
+
+		t.Run("should return 404 for unknown tokens", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/tokens/99", nil, http.StatusNotFound)
+		})
+
+		t.Run("should revoke an own token", func(t *testing.T) {
+			testRequest(t, handler, http.MethodDelete, "/tokens/1", nil, http.StatusOK)
+		})
+	})
+}
+
+func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body *bytes.Buffer
+	if payload != nil {
+		marshalled, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewBuffer(marshalled)
+	} else {
+		body = &bytes.Buffer{}
+	}
+
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+	}
+	return rr
+}
+
+// -------------------- MOCK STORE --------------------
+
+type mockAccessTokenStore struct {
+	tokens map[int]types.AccessToken
+}
+
+func newMockAccessTokenStore() *mockAccessTokenStore {
+	return &mockAccessTokenStore{tokens: make(map[int]types.AccessToken)}
+}
+
+func (m *mockAccessTokenStore) CreateAccessToken(token types.AccessToken) (int, error) {
+	token.ID = len(m.tokens) + 1
+	m.tokens[token.ID] = token
+	return token.ID, nil
+}
+
+func (m *mockAccessTokenStore) GetAccessTokenByHash(tokenHash string) (*types.AccessToken, error) {
+	return nil, fmt.Errorf("access token not found")
+}
+
+func (m *mockAccessTokenStore) GetAccessTokensForUser(userID int) ([]types.AccessToken, error) {
+	tokens := []types.AccessToken{}
+	for _, t := range m.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *mockAccessTokenStore) UpdateAccessTokenLastUsed(tokenID int) error {
+	return nil
+}
+
+func (m *mockAccessTokenStore) RevokeAccessToken(userID, tokenID int) error {
+	t, ok := m.tokens[tokenID]
+	if !ok || t.UserID != userID {
+		return fmt.Errorf("access token not found")
+	}
+	delete(m.tokens, tokenID)
+	return nil
+}
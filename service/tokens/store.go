@@ -0,0 +1,134 @@
+package tokens
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateAccessToken(token types.AccessToken) (int, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		token.UserID, token.Name, token.TokenHash, strings.Join(token.Scopes, " "), token.ExpiresAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert access token: %v", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve access token id: %v", err)
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) GetAccessTokenByHash(tokenHash string) (*types.AccessToken, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query access token: %v", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, fmt.Errorf("access token not found")
+	}
+
+	return scanRowIntoAccessToken(rows)
+}
+
+func (s *Store) GetAccessTokensForUser(userID int) ([]types.AccessToken, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query access tokens: %v", err)
+	}
+	defer rows.Close()
+
+	tokens := []types.AccessToken{}
+	for rows.Next() {
+		token, err := scanRowIntoAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return tokens, nil
+}
+
+func (s *Store) UpdateAccessTokenLastUsed(tokenID int) error {
+	_, err := s.db.Exec("UPDATE personal_access_tokens SET last_used_at = NOW() WHERE id = ?", tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to update access token: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) RevokeAccessToken(userID, tokenID int) error {
+	res, err := s.db.Exec("DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?", tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %v", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %v", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("access token not found")
+	}
+
+	return nil
+}
+
+func scanRowIntoAccessToken(rows *sql.Rows) (*types.AccessToken, error) {
+	token := new(types.AccessToken)
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+
+	err := rows.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&scopes,
+		&expiresAt,
+		&lastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan access token: %v", err)
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return token, nil
+}
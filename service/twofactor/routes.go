@@ -0,0 +1,213 @@
+package twofactor
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type Handler struct {
+	users    types.UserStore
+	store    types.TwoFactorStore
+	attempts types.LoginAttemptStore
+}
+
+func NewHandler(users types.UserStore, store types.TwoFactorStore, attempts types.LoginAttemptStore) *Handler {
+	return &Handler{users: users, store: store, attempts: attempts}
+}
+
+// RegisterRoutes registers the two-factor enrollment routes of the
+// authenticated user.
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/2fa/enroll", h.handleEnroll).Methods("POST")
+	router.HandleFunc("/2fa/confirm", h.handleConfirm).Methods("POST")
+	router.HandleFunc("/2fa/disable", h.handleDisable).Methods("POST")
+	router.HandleFunc("/2fa/recovery-codes", h.handleRegenerateRecoveryCodes).Methods("POST")
+}
+
+// handleEnroll starts enrollment with a new secret. Two-factor stays off
+// until a code from the authenticator is confirmed.
+func (h *Handler) handleEnroll(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if u.TOTPEnabled {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("two-factor authentication is already enabled"))
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.store.SetTOTPSecret(u.ID, secret); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(config.Envs.TOTPIssuer, u.Email, secret),
+	})
+}
+
+// handleConfirm enables two-factor once the user proves the authenticator
+// works, and returns the recovery codes. They are only shown this once.
+func (h *Handler) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	payload, ok := parseCodePayload(w, r)
+	if !ok {
+		return
+	}
+
+	u, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if u.TOTPEnabled || u.TOTPSecret == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("no two-factor enrollment in progress"))
+		return
+	}
+
+	step, valid := auth.ValidateTOTP(u.TOTPSecret, payload.Code, time.Now())
+	if !valid {
+		utils.WriteError(w, http.StatusBadRequest, auth.ErrInvalidTwoFactorCode)
+		return
+	}
+
+	if fresh, err := h.store.UseTOTPStep(u.ID, step); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	} else if !fresh {
+		utils.WriteError(w, http.StatusBadRequest, auth.ErrInvalidTwoFactorCode)
+		return
+	}
+
+	if err := h.store.EnableTOTP(u.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	codes, err := auth.NewRecoveryCodes(h.store, u.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// handleDisable turns two-factor off, which takes the current password as
+// well as a code.
+func (h *Handler) handleDisable(w http.ResponseWriter, r *http.Request) {
+	var payload types.DisableTwoFactorPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	u, ok := h.enabledUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !auth.ReauthenticatePassword(w, r, h.attempts, h.store, u, payload.CurrentPassword, payload.Code) {
+		return
+	}
+
+	if err := h.store.DisableTOTP(u.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// handleRegenerateRecoveryCodes replaces every recovery code of the user,
+// used or not, with a new set.
+func (h *Handler) handleRegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	payload, ok := parseCodePayload(w, r)
+	if !ok {
+		return
+	}
+
+	u, ok := h.enabledUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !auth.ReauthenticateCode(w, r, h.attempts, h.store, u, payload.Code) {
+		return
+	}
+
+	codes, err := auth.NewRecoveryCodes(h.store, u.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// enabledUser returns the authenticated user, who must have two-factor
+// authentication on. Changes to it are checked with a code under the login
+// throttle, so that a stolen session alone can't weaken the account.
+func (h *Handler) enabledUser(w http.ResponseWriter, r *http.Request) (*types.User, bool) {
+	u, ok := h.currentUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if !u.TOTPEnabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("two-factor authentication is not enabled"))
+		return nil, false
+	}
+	return u, true
+}
+
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (*types.User, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == -1 {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("authentication required"))
+		return nil, false
+	}
+
+	u, err := h.users.GetUserByID(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return nil, false
+	}
+	return u, true
+}
+
+func parseCodePayload(w http.ResponseWriter, r *http.Request) (*types.TwoFactorCodePayload, bool) {
+	var payload types.TwoFactorCodePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return nil, false
+	}
+	return &payload, true
+}
@@ -0,0 +1,292 @@
+package twofactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestTwoFactorHandlers(t *testing.T) {
+	store := newMockTwoFactorStore()
+	password, _ := auth.HashPassword("test123")
+	store.users[1] = types.User{ID: 1, Email: "user@example.com", Password: password}
+	attempts := newMockLoginAttemptStore()
+	handler := NewHandler(store, store, attempts)
+
+	var secret string
+	var recoveryCodes []string
+
+	t.Run("Enroll", func(t *testing.T) {
+		rr := testRequest(t, handler, http.MethodPost, "/2fa/enroll", nil, http.StatusOK)
+
+		var enrollment types.TwoFactorEnrollment
+		if err := json.NewDecoder(rr.Body).Decode(&enrollment); err != nil {
+			t.Fatal(err)
+		}
+		if enrollment.Secret == "" || store.users[1].TOTPSecret != enrollment.Secret {
+			t.Fatal("expected the secret to be stored")
+		}
+		if store.users[1].TOTPEnabled {
+			t.Error("expected 2FA to stay disabled until confirmed")
+		}
+		secret = enrollment.Secret
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+		t.Run("should fail with a wrong code", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/2fa/confirm", types.TwoFactorCodePayload{Code: "000000"}, http.StatusBadRequest)
+		})
+
+		t.Run("should enable 2FA and return recovery codes", func(t *testing.T) {
+			code, _ := auth.TOTPCode(secret, time.Now())
+			rr := testRequest(t, handler, http.MethodPost, "/2fa/confirm", types.TwoFactorCodePayload{Code: code}, http.StatusOK)
+
+			var res types.RecoveryCodes
+			if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			if len(res.RecoveryCodes) == 0 || len(store.recoveryCodes[1]) != len(res.RecoveryCodes) {
+				t.Errorf("expected recovery codes to be stored, got %v", res.RecoveryCodes)
+			}
+			if !store.users[1].TOTPEnabled {
+				t.Error("expected 2FA to be enabled")
+			}
+			recoveryCodes = res.RecoveryCodes
+		})
+
+		t.Run("should not enroll again while enabled", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/2fa/enroll", nil, http.StatusConflict)
+		})
+	})
+
+	t.Run("Regenerate Recovery Codes", func(t *testing.T) {
+		t.Run("should fail with a wrong code and throttle the next guess", func(t *testing.T) {
+			defer attempts.reset()
+
+			testRequest(t, handler, http.MethodPost, "/2fa/recovery-codes", types.TwoFactorCodePayload{Code: "000000"}, http.StatusBadRequest)
+			rr := testRequest(t, handler, http.MethodPost, "/2fa/recovery-codes", types.TwoFactorCodePayload{Code: recoveryCodes[0]}, http.StatusTooManyRequests)
+			if rr.Header().Get("Retry-After") == "" {
+				t.Error("expected a Retry-After header")
+			}
+		})
+
+		t.Run("should replace the codes when given a recovery code", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/2fa/recovery-codes", types.TwoFactorCodePayload{Code: recoveryCodes[0]}, http.StatusOK)
+
+			if _, ok := store.recoveryCodes[1][auth.HashRecoveryCode(recoveryCodes[1])]; ok {
+				t.Error("expected the old codes to be replaced")
+			}
+		})
+	})
+
+	t.Run("Disable", func(t *testing.T) {
+		code, _ := auth.TOTPCode(secret, time.Now().Add(30*time.Second))
+
+		t.Run("should require the current password", func(t *testing.T) {
+			defer attempts.reset()
+
+			testRequest(t, handler, http.MethodPost, "/2fa/disable", types.TwoFactorCodePayload{Code: code}, http.StatusBadRequest)
+			payload := types.DisableTwoFactorPayload{CurrentPassword: "wrong", Code: code}
+			testRequest(t, handler, http.MethodPost, "/2fa/disable", payload, http.StatusBadRequest)
+
+			if attempts.attempts["email:user@example.com"].Failures != 1 {
+				t.Errorf("expected the wrong password to count as a failed login, got %+v", attempts.attempts)
+			}
+			if !store.users[1].TOTPEnabled {
+				t.Error("expected 2FA to stay enabled")
+			}
+		})
+
+		t.Run("should disable 2FA with the password and a code", func(t *testing.T) {
+			payload := types.DisableTwoFactorPayload{CurrentPassword: "test123", Code: code}
+			testRequest(t, handler, http.MethodPost, "/2fa/disable", payload, http.StatusOK)
+
+			if store.users[1].TOTPEnabled || store.users[1].TOTPSecret != "" {
+				t.Error("expected 2FA to be disabled")
+			}
+
+			testRequest(t, handler, http.MethodPost, "/2fa/disable", payload, http.StatusBadRequest)
+		})
+	})
+}
+
+func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body *bytes.Buffer
+	if payload != nil {
+		marshalled, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewBuffer(marshalled)
+	} else {
+		body = &bytes.Buffer{}
+	}
+
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, 1))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d: %s", expectedStatus, rr.Code, rr.Body.String())
+	}
+	return rr
+}
+
+// mockTwoFactorStore - Mock implementation of the user and two-factor stores
+type mockTwoFactorStore struct {
+	users         map[int]types.User
+	totpSteps     map[int]int64
+	recoveryCodes map[int]map[string]bool
+}
+
+func newMockTwoFactorStore() *mockTwoFactorStore {
+	return &mockTwoFactorStore{
+		users:         make(map[int]types.User),
+		totpSteps:     make(map[int]int64),
+		recoveryCodes: make(map[int]map[string]bool),
+	}
+}
+
+func (m *mockTwoFactorStore) GetUserByEmail(email string) (*types.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (m *mockTwoFactorStore) GetUserByID(id int) (*types.User, error) {
+	u, ok := m.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &u, nil
+}
+
+func (m *mockTwoFactorStore) CreateUser(types.User) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockTwoFactorStore) MarkEmailVerified(userID int) error {
+	return nil
+}
+
+func (m *mockTwoFactorStore) UpdatePassword(userID int, hashedPassword string) error {
+	return nil
+}
+
+func (m *mockTwoFactorStore) UpdateProfile(userID int, firstName, lastName, email string) error {
+	return nil
+}
+
+func (m *mockTwoFactorStore) SetUserActive(userID int, active bool) error {
+	return nil
+}
+
+func (m *mockTwoFactorStore) SetTOTPSecret(userID int, secret string) error {
+	u := m.users[userID]
+	u.TOTPSecret, u.TOTPEnabled = secret, false
+	m.users[userID] = u
+	return nil
+}
+
+func (m *mockTwoFactorStore) EnableTOTP(userID int) error {
+	u := m.users[userID]
+	u.TOTPEnabled = true
+	m.users[userID] = u
+	return nil
+}
+
+func (m *mockTwoFactorStore) DisableTOTP(userID int) error {
+	u := m.users[userID]
+	u.TOTPSecret, u.TOTPEnabled = "", false
+	m.users[userID] = u
+	delete(m.recoveryCodes, userID)
+	return nil
+}
+
+func (m *mockTwoFactorStore) UseTOTPStep(userID int, step int64) (bool, error) {
+	if step <= m.totpSteps[userID] {
+		return false, nil
+	}
+	m.totpSteps[userID] = step
+	return true, nil
+}
+
+func (m *mockTwoFactorStore) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	m.recoveryCodes[userID] = make(map[string]bool)
+	for _, hash := range codeHashes {
+		m.recoveryCodes[userID][hash] = false
+	}
+	return nil
+}
+
+func (m *mockTwoFactorStore) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	used, ok := m.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+// mockLoginAttemptStore - Mock implementation of the login attempt store
+type mockLoginAttemptStore struct {
+	attempts map[string]types.LoginAttempt
+}
+
+func newMockLoginAttemptStore() *mockLoginAttemptStore {
+	return &mockLoginAttemptStore{attempts: make(map[string]types.LoginAttempt)}
+}
+
+func (m *mockLoginAttemptStore) reset() {
+	m.attempts = make(map[string]types.LoginAttempt)
+}
+
+func (m *mockLoginAttemptStore) GetLoginAttempt(key string) (*types.LoginAttempt, error) {
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (m *mockLoginAttemptStore) IncrementLoginFailures(key string, now time.Time, resetAfter time.Duration) (int, error) {
+	attempt, ok := m.attempts[key]
+	if !ok || now.Sub(attempt.LastFailedAt) > resetAfter {
+		attempt = types.LoginAttempt{Key: key}
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = now
+	m.attempts[key] = attempt
+	return attempt.Failures, nil
+}
+
+func (m *mockLoginAttemptStore) LockLogin(key string, until time.Time) error {
+	attempt := m.attempts[key]
+	if attempt.LockedUntil == nil || until.After(*attempt.LockedUntil) {
+		attempt.LockedUntil = &until
+	}
+	m.attempts[key] = attempt
+	return nil
+}
+
+func (m *mockLoginAttemptStore) ClearLoginAttempts(key string) error {
+	delete(m.attempts, key)
+	return nil
+}
@@ -2,7 +2,13 @@ package user
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -13,19 +19,44 @@ import (
 )
 
 type Handler struct {
-	store types.UserStore
+	store      types.UserStore
+	sessions   types.SessionStore
+	userTokens types.UserTokenStore
+	attempts   types.LoginAttemptStore
+	twoFactor  types.TwoFactorStore
+	mailer     types.Mailer
 }
 
-func NewHandler(store types.UserStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.UserStore, sessions types.SessionStore, userTokens types.UserTokenStore, attempts types.LoginAttemptStore, twoFactor types.TwoFactorStore, mailer types.Mailer) *Handler {
+	return &Handler{store: store, sessions: sessions, userTokens: userTokens, attempts: attempts, twoFactor: twoFactor, mailer: mailer}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/login", h.handleLogin).Methods("POST")
+	router.HandleFunc("/login/2fa", h.handleLoginTwoFactor).Methods("POST")
 	router.HandleFunc("/register", h.handleRegister).Methods("POST")
+	router.HandleFunc("/refresh", h.handleRefresh).Methods("POST")
+	router.HandleFunc("/verify-email", h.handleVerifyEmail).Methods("POST")
+	router.HandleFunc("/resend-verification", h.handleResendVerification).Methods("POST")
+	router.HandleFunc("/forgot-password", h.handleForgotPassword).Methods("POST")
+	router.HandleFunc("/reset-password", h.handleResetPassword).Methods("POST")
+}
+
+// RegisterProtectedRoutes registers the user routes that need an authenticated user.
+func (h *Handler) RegisterProtectedRoutes(router *mux.Router) {
+	router.HandleFunc("/me", h.handleGetMe).Methods("GET")
+	router.HandleFunc("/me", h.handleUpdateMe).Methods("PATCH")
+	router.HandleFunc("/me/password", h.handleChangePassword).Methods("POST")
+	router.HandleFunc("/users/{userID}/unlock", h.handleUnlockUser).Methods("POST")
+	router.HandleFunc("/users/{userID}/deactivate", h.handleSetUserActive(false)).Methods("POST")
+	router.HandleFunc("/users/{userID}/reactivate", h.handleSetUserActive(true)).Methods("POST")
 }
 
 func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !passwordLoginEnabled(w) {
+		return
+	}
+
 	var user types.LoginUserPayload
 	if err := utils.ParseJSON(r, &user); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
@@ -38,30 +69,396 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	now := time.Now()
+	emailKey := auth.EmailThrottleKey(user.Email)
+	ipKey := auth.IPThrottleKey(auth.ClientIP(r))
+
+	wait, err := auth.LoginRetryAfter(h.attempts, now, emailKey, ipKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many failed login attempts, try again later"))
+		return
+	}
+
 	u, err := h.store.GetUserByEmail(user.Email)
 
 	if err != nil {
+		auth.RecordLoginFailures(h.attempts, emailKey, ipKey, now)
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("not found, invalid email or password"))
 		return
 	}
 
 	if !auth.ComparePassword(u.Password, []byte(user.Password)) {
+		auth.RecordLoginFailures(h.attempts, emailKey, ipKey, now)
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("not found, invalid email or password"))
 		return
 	}
-	secret := []byte(config.Envs.JWTSecret)
-	token, err := auth.CreateJWT(secret, u.ID)
 
+	if !u.IsActive {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("account is deactivated"))
+		return
+	}
+
+	if config.Envs.RequireEmailVerification && u.EmailVerifiedAt == nil {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("email address has not been verified"))
+		return
+	}
+
+	// The password alone is not enough, the session is only created once
+	// the challenge is answered on /login/2fa. Failed attempts are kept so
+	// a known password doesn't reset the limit on guessing codes.
+	if u.TOTPEnabled {
+		ttl := time.Second * time.Duration(config.Envs.TwoFactorChallengeExpirationInSeconds)
+		challenge, err := auth.CreateUserToken(h.userTokens, u.ID, auth.PurposeTwoFactorLogin, ttl)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, types.TwoFactorChallenge{TwoFactorRequired: true, ChallengeToken: challenge})
+		return
+	}
+
+	if err := h.attempts.ClearLoginAttempts(emailKey); err != nil {
+		log.Printf("failed to clear login attempts for user %d: %v", u.ID, err)
+	}
+
+	tokens, err := auth.CreateSession(h.sessions, u.ID, r)
+
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tokens)
+
+}
+
+// handleLoginTwoFactor completes a login started by handleLogin with either
+// a TOTP code or a recovery code. Wrong codes count as failed logins.
+func (h *Handler) handleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var payload types.TwoFactorLoginPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// Only check the challenge here, it is used up once the code is accepted
+	userID, err := auth.ParseUserToken(payload.ChallengeToken, auth.PurposeTwoFactorLogin)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	u, err := h.store.GetUserByID(userID)
+	if err != nil || !u.TOTPEnabled || !u.IsActive {
+		utils.WriteError(w, http.StatusUnauthorized, auth.ErrInvalidUserToken)
+		return
+	}
+
+	now := time.Now()
+	emailKey := auth.EmailThrottleKey(u.Email)
+	ipKey := auth.IPThrottleKey(auth.ClientIP(r))
+
+	wait, err := auth.LoginRetryAfter(h.attempts, now, emailKey, ipKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many failed login attempts, try again later"))
+		return
+	}
+
+	if err := auth.VerifyTwoFactorCode(h.twoFactor, u, payload.Code, now); err != nil {
+		if err == auth.ErrInvalidTwoFactorCode {
+			auth.RecordLoginFailures(h.attempts, emailKey, ipKey, now)
+			utils.WriteError(w, http.StatusUnauthorized, err)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, err := auth.ConsumeUserToken(h.userTokens, payload.ChallengeToken, auth.PurposeTwoFactorLogin); err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err := h.attempts.ClearLoginAttempts(emailKey); err != nil {
+		log.Printf("failed to clear login attempts for user %d: %v", u.ID, err)
+	}
+
+	tokens, err := auth.CreateSession(h.sessions, u.ID, r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// passwordLoginEnabled writes an error when accounts are managed by single
+// sign-on only.
+func passwordLoginEnabled(w http.ResponseWriter) bool {
+	if config.Envs.DisablePasswordLogin {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("password login is disabled, sign in with single sign-on"))
+		return false
+	}
+	return true
+}
+
+// handleUnlockUser lets an admin lift a login lockout early. Only the account
+// key is reset, the failures counted against the client IP stay until they
+// expire. That key is shared by every account behind the address and is often
+// the one of whoever guessed the password, so a user behind a throttled
+// address can still get 429 until it runs out.
+func (h *Handler) handleUnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["userID"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid userID: %v", err))
+		return
+	}
+
+	if !auth.RequireAdmin(w, r, h.store) {
+		return
+	}
+
+	u, err := h.store.GetUserByID(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return
+	}
+
+	if err := h.attempts.ClearLoginAttempts(auth.EmailThrottleKey(u.Email)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "User unlocked successfully"})
+}
+
+func (h *Handler) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, u)
+}
+
+// handleUpdateMe updates the fields present in the payload. Changing the
+// email address takes the current password, and the two-factor code when it
+// is turned on, the new address has to be verified again and the old one is
+// told about the change.
+func (h *Handler) handleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	var payload types.UpdateProfilePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	u, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	emailChanged := payload.Email != nil && !strings.EqualFold(*payload.Email, u.Email)
+	if emailChanged {
+		if !auth.ReauthenticatePassword(w, r, h.attempts, h.twoFactor, u, payload.CurrentPassword, payload.Code) {
+			return
+		}
+		if _, err := h.store.GetUserByEmail(*payload.Email); err == nil {
+			utils.WriteError(w, http.StatusConflict, fmt.Errorf("user with email %s already exists", *payload.Email))
+			return
+		}
+	}
+
+	if payload.FirstName != nil {
+		u.FirstName = *payload.FirstName
+	}
+	if payload.LastName != nil {
+		u.LastName = *payload.LastName
+	}
+	oldEmail := u.Email
+	if payload.Email != nil {
+		u.Email = *payload.Email
+	}
+
+	if err := h.store.UpdateProfile(u.ID, u.FirstName, u.LastName, u.Email); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if emailChanged {
+		u.EmailVerifiedAt = nil
+		h.sendVerificationEmail(u)
+		h.sendEmailChangedEmail(u, oldEmail)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, u)
+}
+
+// handleChangePassword sets a new password after checking the current one
+// and the two-factor code, every other session of the user is signed out.
+func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	if !passwordLoginEnabled(w) {
+		return
+	}
+
+	var payload types.ChangePasswordPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	u, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !auth.ReauthenticatePassword(w, r, h.attempts, h.twoFactor, u, payload.CurrentPassword, payload.Code) {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(payload.NewPassword)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"token": token})
+	if err := h.store.UpdatePassword(u.ID, hashedPassword); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	current := auth.GetSessionIDFromContext(r.Context())
+	sessions, err := h.sessions.GetActiveSessionsForUser(u.ID)
+	if err != nil {
+		log.Printf("failed to list sessions of user %d: %v", u.ID, err)
+	}
+	for _, session := range sessions {
+		if session.ID == current {
+			continue
+		}
+		if err := h.sessions.RevokeSession(u.ID, session.ID); err != nil {
+			log.Printf("failed to revoke session %d of user %d: %v", session.ID, u.ID, err)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Password changed successfully"})
+}
+
+// handleSetUserActive lets an admin deactivate or reactivate a user.
+// Deactivating signs the user out everywhere.
+func (h *Handler) handleSetUserActive(active bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["userID"])
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid userID: %v", err))
+			return
+		}
+
+		if !auth.RequireAdmin(w, r, h.store) {
+			return
+		}
+
+		if !active && userID == auth.GetUserIDFromContext(r.Context()) {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("you can't deactivate your own account"))
+			return
+		}
+
+		if _, err := h.store.GetUserByID(userID); err != nil {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+			return
+		}
+
+		if err := h.store.SetUserActive(userID, active); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		message := "User reactivated successfully"
+		if !active {
+			message = "User deactivated successfully"
+			if err := h.sessions.RevokeAllSessionsForUser(userID); err != nil {
+				log.Printf("failed to revoke sessions of user %d: %v", userID, err)
+			}
+		}
+
+		utils.WriteJSON(w, http.StatusOK, map[string]string{"message": message})
+	}
+}
+
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (*types.User, bool) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == -1 {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("authentication required"))
+		return nil, false
+	}
 
+	u, err := h.store.GetUserByID(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return nil, false
+	}
+	return u, true
+}
+
+func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var payload types.RefreshTokenPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	tokens, err := auth.RefreshSession(h.sessions, payload.RefreshToken)
+	if err != nil {
+		if err == auth.ErrInvalidRefreshToken {
+			utils.WriteError(w, http.StatusUnauthorized, err)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tokens)
 }
 
 func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if !passwordLoginEnabled(w) {
+		return
+	}
+
 	var user types.RegisterUserPayload
 	if err := utils.ParseJSON(r, &user); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
@@ -99,5 +496,177 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if u, err := h.store.GetUserByEmail(user.Email); err != nil {
+		log.Printf("failed to load new user %s: %v", user.Email, err)
+	} else {
+		h.sendVerificationEmail(u)
+	}
+
 	utils.WriteJSON(w, http.StatusCreated, map[string]string{"message": "User created successfully"})
 }
+
+func (h *Handler) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var payload types.VerifyEmailPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	userID, err := auth.ConsumeUserToken(h.userTokens, payload.Token, auth.PurposeVerifyEmail)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, auth.ErrInvalidUserToken)
+		return
+	}
+
+	if err := h.store.MarkEmailVerified(userID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Email verified successfully"})
+}
+
+// handleResendVerification always answers the same way so it can't be used
+// to find out which emails have an account.
+func (h *Handler) handleResendVerification(w http.ResponseWriter, r *http.Request) {
+	var payload types.EmailPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	if u, err := h.store.GetUserByEmail(payload.Email); err == nil && u.EmailVerifiedAt == nil {
+		h.sendVerificationEmail(u)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "If the account exists and is unverified, a verification email has been sent",
+	})
+}
+
+// handleForgotPassword always answers the same way so it can't be used to
+// find out which emails have an account.
+func (h *Handler) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if !passwordLoginEnabled(w) {
+		return
+	}
+
+	var payload types.EmailPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	if u, err := h.store.GetUserByEmail(payload.Email); err == nil {
+		h.sendPasswordResetEmail(u)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "If the account exists, a password reset email has been sent",
+	})
+}
+
+// handleResetPassword sets a new password and signs the user out everywhere.
+func (h *Handler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	if !passwordLoginEnabled(w) {
+		return
+	}
+
+	var payload types.ResetPasswordPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	userID, err := auth.ConsumeUserToken(h.userTokens, payload.Token, auth.PurposeResetPassword)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, auth.ErrInvalidUserToken)
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(payload.Password)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.store.UpdatePassword(userID, hashedPassword); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Receiving the reset email proves ownership of the address as well
+	if err := h.store.MarkEmailVerified(userID); err != nil {
+		log.Printf("failed to mark email of user %d as verified: %v", userID, err)
+	}
+
+	if err := h.sessions.RevokeAllSessionsForUser(userID); err != nil {
+		log.Printf("failed to revoke sessions of user %d: %v", userID, err)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Password reset successfully"})
+}
+
+func (h *Handler) sendVerificationEmail(u *types.User) {
+	ttl := time.Second * time.Duration(config.Envs.EmailVerificationExpirationInSeconds)
+	token, err := auth.CreateUserToken(h.userTokens, u.ID, auth.PurposeVerifyEmail, ttl)
+	if err != nil {
+		log.Printf("failed to create verification token for user %d: %v", u.ID, err)
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s/verify-email?token=%s\n\nThe link expires in %s.\n",
+		u.FirstName, config.Envs.FrontendURL, url.QueryEscape(token), ttl)
+	if err := h.mailer.Send(u.Email, "Verify your email address", body); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", u.ID, err)
+	}
+}
+
+// sendEmailChangedEmail tells the old address about the change, so a
+// hijacked account doesn't go unnoticed.
+func (h *Handler) sendEmailChangedEmail(u *types.User, oldEmail string) {
+	body := fmt.Sprintf("Hi %s,\n\nThe email address of your account was changed to %s. If you did not do this, contact your administrator right away.\n",
+		u.FirstName, u.Email)
+	if err := h.mailer.Send(oldEmail, "Your email address was changed", body); err != nil {
+		log.Printf("failed to send email change notice to user %d: %v", u.ID, err)
+	}
+}
+
+func (h *Handler) sendPasswordResetEmail(u *types.User) {
+	ttl := time.Second * time.Duration(config.Envs.PasswordResetExpirationInSeconds)
+	token, err := auth.CreateUserToken(h.userTokens, u.ID, auth.PurposeResetPassword, ttl)
+	if err != nil {
+		log.Printf("failed to create password reset token for user %d: %v", u.ID, err)
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset your password. If it was you, open the link below:\n\n%s/reset-password?token=%s\n\nThe link expires in %s. If you did not ask for this you can ignore this email.\n",
+		u.FirstName, config.Envs.FrontendURL, url.QueryEscape(token), ttl)
+	if err := h.mailer.Send(u.Email, "Reset your password", body); err != nil {
+		log.Printf("failed to send password reset email to user %d: %v", u.ID, err)
+	}
+}
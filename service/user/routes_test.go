@@ -2,19 +2,35 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/config"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
 	"github.com/maximis3d/issue-tracking-system/types"
 )
 
 func TestUserServiceHandlers(t *testing.T) {
 	userStore := newMockUserStore()
-	handler := NewHandler(userStore)
+	mailer := &mockMailer{}
+	attempts := newMockLoginAttemptStore()
+	handler := NewHandler(userStore, newMockSessionStore(), newMockUserTokenStore(), attempts, userStore, mailer)
+
+	// Throttling is covered by its own tests, don't let failed logins slow the others down
+	defer func(base, ipMax int64) {
+		config.Envs.LoginBackoffBaseSeconds, config.Envs.LoginIPMaxAttempts = base, ipMax
+	}(config.Envs.LoginBackoffBaseSeconds, config.Envs.LoginIPMaxAttempts)
+	config.Envs.LoginBackoffBaseSeconds = 0
+	config.Envs.LoginIPMaxAttempts = 1000
 
 	t.Run("User Registration", func(t *testing.T) {
 		t.Run("should fail if payload is invalid", func(t *testing.T) {
@@ -65,10 +81,342 @@ func TestUserServiceHandlers(t *testing.T) {
 			testRequest(t, handler, http.MethodPost, "/login", payload, http.StatusOK)
 		})
 	})
+
+	t.Run("Email Verification", func(t *testing.T) {
+		config.Envs.RequireEmailVerification = true
+		defer func() { config.Envs.RequireEmailVerification = false }()
+
+		payload := types.RegisterUserPayload{
+			FirstName: "Jane",
+			LastName:  "Doe",
+			Email:     "jane@example.com",
+			Password:  "test123",
+		}
+		testRequest(t, handler, http.MethodPost, "/register", payload, http.StatusCreated)
+
+		token := mailer.lastToken(t, "jane@example.com")
+		login := types.LoginUserPayload{Email: "jane@example.com", Password: "test123"}
+
+		t.Run("should block login until the email is verified", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/login", login, http.StatusForbidden)
+		})
+
+		t.Run("should fail with an invalid token", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/verify-email", types.VerifyEmailPayload{Token: "invalid"}, http.StatusBadRequest)
+		})
+
+		t.Run("should verify the email", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/verify-email", types.VerifyEmailPayload{Token: token}, http.StatusOK)
+			testRequest(t, handler, http.MethodPost, "/login", login, http.StatusOK)
+		})
+
+		t.Run("should only accept a token once", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/verify-email", types.VerifyEmailPayload{Token: token}, http.StatusBadRequest)
+		})
+	})
+
+	t.Run("Password Reset", func(t *testing.T) {
+		t.Run("should not reveal unknown emails", func(t *testing.T) {
+			sent := len(mailer.sent)
+			testRequest(t, handler, http.MethodPost, "/forgot-password", types.EmailPayload{Email: "nobody@example.com"}, http.StatusOK)
+			if len(mailer.sent) != sent {
+				t.Error("expected no email to be sent")
+			}
+		})
+
+		testRequest(t, handler, http.MethodPost, "/forgot-password", types.EmailPayload{Email: "jane@example.com"}, http.StatusOK)
+		token := mailer.lastToken(t, "jane@example.com")
+
+		t.Run("should not accept a reset token for email verification", func(t *testing.T) {
+			testRequest(t, handler, http.MethodPost, "/verify-email", types.VerifyEmailPayload{Token: token}, http.StatusBadRequest)
+		})
+
+		t.Run("should reset the password", func(t *testing.T) {
+			payload := types.ResetPasswordPayload{Token: token, Password: "newpassword"}
+			testRequest(t, handler, http.MethodPost, "/reset-password", payload, http.StatusOK)
+
+			login := types.LoginUserPayload{Email: "jane@example.com", Password: "newpassword"}
+			testRequest(t, handler, http.MethodPost, "/login", login, http.StatusOK)
+		})
+
+		t.Run("should only accept a token once", func(t *testing.T) {
+			payload := types.ResetPasswordPayload{Token: token, Password: "anotherpassword"}
+			testRequest(t, handler, http.MethodPost, "/reset-password", payload, http.StatusBadRequest)
+		})
+	})
+
+	t.Run("Login Throttling", func(t *testing.T) {
+		payload := types.RegisterUserPayload{
+			FirstName: "Locked",
+			LastName:  "Out",
+			Email:     "locked@example.com",
+			Password:  "test123",
+		}
+		testRequest(t, handler, http.MethodPost, "/register", payload, http.StatusCreated)
+
+		wrong := types.LoginUserPayload{Email: "locked@example.com", Password: "wrongpassword"}
+		right := types.LoginUserPayload{Email: "locked@example.com", Password: "test123"}
+
+		t.Run("should back off after a failed attempt", func(t *testing.T) {
+			config.Envs.LoginBackoffBaseSeconds = 30
+			defer func() { config.Envs.LoginBackoffBaseSeconds = 0 }()
+			attempts.reset()
+
+			testRequest(t, handler, http.MethodPost, "/login", wrong, http.StatusBadRequest)
+			rr := testRequest(t, handler, http.MethodPost, "/login", right, http.StatusTooManyRequests)
+			if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "30" {
+				t.Errorf("expected Retry-After 30, got %q", retryAfter)
+			}
+
+			attempts.reset()
+		})
+
+		t.Run("should lock the account after too many failures", func(t *testing.T) {
+			for i := int64(0); i < config.Envs.LoginMaxAttempts; i++ {
+				testRequest(t, handler, http.MethodPost, "/login", wrong, http.StatusBadRequest)
+			}
+			testRequest(t, handler, http.MethodPost, "/login", right, http.StatusTooManyRequests)
+		})
+
+		t.Run("should only let admins unlock accounts", func(t *testing.T) {
+			locked, _ := userStore.GetUserByEmail("locked@example.com")
+			path := fmt.Sprintf("/users/%d/unlock", locked.ID)
+
+			testRequestAs(t, handler, locked.ID, http.MethodPost, path, nil, http.StatusForbidden)
+
+			admin := types.User{FirstName: "Ad", LastName: "Min", Email: "admin@example.com", IsAdmin: true}
+			userStore.CreateUser(admin)
+			a, _ := userStore.GetUserByEmail(admin.Email)
+
+			testRequestAs(t, handler, a.ID, http.MethodPost, path, nil, http.StatusOK)
+			if attempt, _ := attempts.GetLoginAttempt(auth.IPThrottleKey("")); attempt == nil {
+				t.Error("expected the failures of the client IP to be kept")
+			}
+			testRequest(t, handler, http.MethodPost, "/login", right, http.StatusOK)
+		})
+	})
+
+	t.Run("Two-Factor Login", func(t *testing.T) {
+		payload := types.RegisterUserPayload{
+			FirstName: "Two",
+			LastName:  "Factor",
+			Email:     "2fa@example.com",
+			Password:  "test123",
+		}
+		testRequest(t, handler, http.MethodPost, "/register", payload, http.StatusCreated)
+
+		u, _ := userStore.GetUserByEmail(payload.Email)
+		secret, _ := auth.GenerateTOTPSecret()
+		userStore.update(u.ID, func(u *types.User) {
+			u.TOTPSecret = secret
+			u.TOTPEnabled = true
+		})
+		userStore.ReplaceRecoveryCodes(u.ID, []string{auth.HashRecoveryCode("abcde-fghij")})
+
+		login := types.LoginUserPayload{Email: payload.Email, Password: payload.Password}
+		challenge := func(t *testing.T) string {
+			rr := testRequest(t, handler, http.MethodPost, "/login", login, http.StatusOK)
+
+			var res types.TwoFactorChallenge
+			if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			if !res.TwoFactorRequired || res.ChallengeToken == "" {
+				t.Fatalf("expected a two-factor challenge, got %+v", res)
+			}
+			return res.ChallengeToken
+		}
+
+		t.Run("should reject a wrong code", func(t *testing.T) {
+			payload := types.TwoFactorLoginPayload{ChallengeToken: challenge(t), Code: "000000"}
+			testRequest(t, handler, http.MethodPost, "/login/2fa", payload, http.StatusUnauthorized)
+		})
+
+		t.Run("should reject an invalid challenge", func(t *testing.T) {
+			payload := types.TwoFactorLoginPayload{ChallengeToken: "invalid", Code: "000000"}
+			testRequest(t, handler, http.MethodPost, "/login/2fa", payload, http.StatusUnauthorized)
+		})
+
+		t.Run("should log in with a valid code only once", func(t *testing.T) {
+			code, _ := auth.TOTPCode(secret, time.Now())
+			payload := types.TwoFactorLoginPayload{ChallengeToken: challenge(t), Code: code}
+
+			rr := testRequest(t, handler, http.MethodPost, "/login/2fa", payload, http.StatusOK)
+			var tokens types.TokenPair
+			if err := json.NewDecoder(rr.Body).Decode(&tokens); err != nil || tokens.AccessToken == "" {
+				t.Errorf("expected a token pair, got %q (err %v)", rr.Body.String(), err)
+			}
+
+			testRequest(t, handler, http.MethodPost, "/login/2fa", payload, http.StatusUnauthorized)
+			payload.ChallengeToken = challenge(t)
+			testRequest(t, handler, http.MethodPost, "/login/2fa", payload, http.StatusUnauthorized)
+		})
+
+		t.Run("should accept a recovery code once", func(t *testing.T) {
+			payload := types.TwoFactorLoginPayload{ChallengeToken: challenge(t), Code: "ABCDE FGHIJ"}
+			testRequest(t, handler, http.MethodPost, "/login/2fa", payload, http.StatusOK)
+
+			payload.ChallengeToken = challenge(t)
+			testRequest(t, handler, http.MethodPost, "/login/2fa", payload, http.StatusUnauthorized)
+		})
+	})
+
+	t.Run("Profile", func(t *testing.T) {
+		payload := types.RegisterUserPayload{
+			FirstName: "Pro",
+			LastName:  "File",
+			Email:     "profile@example.com",
+			Password:  "test123",
+		}
+		testRequest(t, handler, http.MethodPost, "/register", payload, http.StatusCreated)
+		u, _ := userStore.GetUserByEmail(payload.Email)
+		userStore.MarkEmailVerified(u.ID)
+
+		t.Run("should return the current user without the password", func(t *testing.T) {
+			rr := testRequestAs(t, handler, u.ID, http.MethodGet, "/me", nil, http.StatusOK)
+			if bytes.Contains(rr.Body.Bytes(), []byte("password")) {
+				t.Errorf("expected no password in %s", rr.Body.String())
+			}
+			testRequest(t, handler, http.MethodGet, "/me", nil, http.StatusUnauthorized)
+		})
+
+		t.Run("should update the name only", func(t *testing.T) {
+			name := "Profi"
+			testRequestAs(t, handler, u.ID, http.MethodPatch, "/me", types.UpdateProfilePayload{FirstName: &name}, http.StatusOK)
+
+			updated, _ := userStore.GetUserByID(u.ID)
+			if updated.FirstName != "Profi" || updated.LastName != "File" || updated.EmailVerifiedAt == nil {
+				t.Errorf("unexpected user after update %+v", updated)
+			}
+		})
+
+		t.Run("should not take another user's email", func(t *testing.T) {
+			email := "2fa@example.com"
+			payload := types.UpdateProfilePayload{Email: &email, CurrentPassword: "test123"}
+			testRequestAs(t, handler, u.ID, http.MethodPatch, "/me", payload, http.StatusConflict)
+		})
+
+		t.Run("should require the current password to change the email", func(t *testing.T) {
+			email := "stolen@example.com"
+			testRequestAs(t, handler, u.ID, http.MethodPatch, "/me", types.UpdateProfilePayload{Email: &email}, http.StatusBadRequest)
+
+			payload := types.UpdateProfilePayload{Email: &email, CurrentPassword: "wrong"}
+			testRequestAs(t, handler, u.ID, http.MethodPatch, "/me", payload, http.StatusBadRequest)
+
+			updated, _ := userStore.GetUserByID(u.ID)
+			if updated.Email != "profile@example.com" {
+				t.Errorf("expected the email to stay, got %s", updated.Email)
+			}
+		})
+
+		t.Run("should require a two-factor code to change the email", func(t *testing.T) {
+			other, _ := userStore.GetUserByEmail("2fa@example.com")
+			email := "stolen-2fa@example.com"
+			payload := types.UpdateProfilePayload{Email: &email, CurrentPassword: "test123"}
+			testRequestAs(t, handler, other.ID, http.MethodPatch, "/me", payload, http.StatusBadRequest)
+
+			payload.Code = "000000"
+			testRequestAs(t, handler, other.ID, http.MethodPatch, "/me", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should verify a changed email again", func(t *testing.T) {
+			email := "new-profile@example.com"
+			payload := types.UpdateProfilePayload{Email: &email, CurrentPassword: "test123"}
+			testRequestAs(t, handler, u.ID, http.MethodPatch, "/me", payload, http.StatusOK)
+
+			updated, _ := userStore.GetUserByID(u.ID)
+			if updated.Email != email || updated.EmailVerifiedAt != nil {
+				t.Errorf("expected an unverified new email, got %+v", updated)
+			}
+			mailer.lastToken(t, email)
+
+			last := mailer.sent[len(mailer.sent)-1]
+			if last.to != "profile@example.com" || !strings.Contains(last.body, email) {
+				t.Errorf("expected a notice to the old address, got %+v", last)
+			}
+		})
+
+		t.Run("should require the current password to change it", func(t *testing.T) {
+			wrong := types.ChangePasswordPayload{CurrentPassword: "wrong", NewPassword: "newpass"}
+			testRequestAs(t, handler, u.ID, http.MethodPost, "/me/password", wrong, http.StatusBadRequest)
+
+			right := types.ChangePasswordPayload{CurrentPassword: "test123", NewPassword: "newpass"}
+			testRequestAs(t, handler, u.ID, http.MethodPost, "/me/password", right, http.StatusOK)
+
+			login := types.LoginUserPayload{Email: "new-profile@example.com", Password: "newpass"}
+			testRequest(t, handler, http.MethodPost, "/login", login, http.StatusOK)
+		})
+
+		t.Run("should count a wrong current password as a failed login", func(t *testing.T) {
+			defer attempts.reset()
+
+			wrong := types.ChangePasswordPayload{CurrentPassword: "wrong", NewPassword: "other"}
+			testRequestAs(t, handler, u.ID, http.MethodPost, "/me/password", wrong, http.StatusBadRequest)
+			if attempts.attempts[auth.EmailThrottleKey("new-profile@example.com")].Failures != 1 {
+				t.Errorf("expected a failed login to be recorded, got %+v", attempts.attempts)
+			}
+		})
+
+		t.Run("should require a two-factor code to change the password", func(t *testing.T) {
+			other, _ := userStore.GetUserByEmail("2fa@example.com")
+			payload := types.ChangePasswordPayload{CurrentPassword: "test123", NewPassword: "newpass"}
+			testRequestAs(t, handler, other.ID, http.MethodPost, "/me/password", payload, http.StatusBadRequest)
+
+			payload.Code = "000000"
+			testRequestAs(t, handler, other.ID, http.MethodPost, "/me/password", payload, http.StatusBadRequest)
+
+			login := types.LoginUserPayload{Email: "2fa@example.com", Password: "test123"}
+			testRequest(t, handler, http.MethodPost, "/login", login, http.StatusOK)
+		})
+
+		t.Run("should let admins deactivate and reactivate users", func(t *testing.T) {
+			admin := types.User{FirstName: "Ad", LastName: "Min", Email: "profile-admin@example.com", IsAdmin: true}
+			userStore.CreateUser(admin)
+			a, _ := userStore.GetUserByEmail(admin.Email)
+			deactivate := fmt.Sprintf("/users/%d/deactivate", u.ID)
+			login := types.LoginUserPayload{Email: "new-profile@example.com", Password: "newpass"}
+
+			testRequestAs(t, handler, u.ID, http.MethodPost, deactivate, nil, http.StatusForbidden)
+			testRequestAs(t, handler, a.ID, http.MethodPost, fmt.Sprintf("/users/%d/deactivate", a.ID), nil, http.StatusBadRequest)
+
+			testRequestAs(t, handler, a.ID, http.MethodPost, deactivate, nil, http.StatusOK)
+			testRequest(t, handler, http.MethodPost, "/login", login, http.StatusForbidden)
+
+			testRequestAs(t, handler, a.ID, http.MethodPost, fmt.Sprintf("/users/%d/reactivate", u.ID), nil, http.StatusOK)
+			testRequest(t, handler, http.MethodPost, "/login", login, http.StatusOK)
+		})
+	})
+
+	t.Run("should refuse password logins when disabled", func(t *testing.T) {
+		config.Envs.DisablePasswordLogin = true
+		defer func() { config.Envs.DisablePasswordLogin = false }()
+
+		login := types.LoginUserPayload{Email: "2fa@example.com", Password: "test123"}
+		testRequest(t, handler, http.MethodPost, "/login", login, http.StatusForbidden)
+	})
+
+	t.Run("Token Refresh", func(t *testing.T) {
+		t.Run("should fail if payload is invalid", func(t *testing.T) {
+			payload := types.RefreshTokenPayload{}
+			testRequest(t, handler, http.MethodPost, "/refresh", payload, http.StatusBadRequest)
+		})
+
+		t.Run("should fail with an unknown refresh token", func(t *testing.T) {
+			payload := types.RefreshTokenPayload{RefreshToken: "unknown"}
+			testRequest(t, handler, http.MethodPost, "/refresh", payload, http.StatusUnauthorized)
+		})
+	})
 }
 
 // testRequest - Helper function to perform HTTP requests and check response
-func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) {
+func testRequest(t testing.TB, handler *Handler, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return testRequestAs(t, handler, -1, method, path, payload, expectedStatus)
+}
+
+// testRequestAs - Like testRequest but authenticated as the given user, -1 for anonymous requests
+func testRequestAs(t testing.TB, handler *Handler, userID int, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
 	t.Helper()
 
 	marshalled, err := json.Marshal(payload)
@@ -81,26 +429,34 @@ func testRequest(t testing.TB, handler *Handler, method, path string, payload an
 		t.Fatal(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if userID != -1 {
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, userID))
+	}
 
 	rr := httptest.NewRecorder()
 	router := mux.NewRouter()
-	router.HandleFunc("/register", handler.handleRegister).Methods("POST")
-	router.HandleFunc("/login", handler.handleLogin).Methods("POST")
+	handler.RegisterRoutes(router)
+	handler.RegisterProtectedRoutes(router)
 	router.ServeHTTP(rr, req)
 
 	if rr.Code != expectedStatus {
 		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
 	}
+	return rr
 }
 
 // mockUserStore - Mock implementation of the user store
 type mockUserStore struct {
-	users map[string]types.User
+	users         map[string]types.User
+	totpSteps     map[int]int64
+	recoveryCodes map[int]map[string]bool
 }
 
 func newMockUserStore() *mockUserStore {
 	return &mockUserStore{
-		users: make(map[string]types.User),
+		users:         make(map[string]types.User),
+		totpSteps:     make(map[int]int64),
+		recoveryCodes: make(map[int]map[string]bool),
 	}
 }
 
@@ -116,10 +472,256 @@ func (m *mockUserStore) CreateUser(user types.User) error {
 	if _, exists := m.users[user.Email]; exists {
 		return fmt.Errorf("user already exists")
 	}
+	user.ID = len(m.users) + 1
+	user.IsActive = true
 	m.users[user.Email] = user
 	return nil
 }
 
 func (m *mockUserStore) GetUserByID(id int) (*types.User, error) {
+	for _, user := range m.users {
+		if user.ID == id {
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (m *mockUserStore) MarkEmailVerified(userID int) error {
+	return m.update(userID, func(u *types.User) {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	})
+}
+
+func (m *mockUserStore) UpdatePassword(userID int, hashedPassword string) error {
+	return m.update(userID, func(u *types.User) { u.Password = hashedPassword })
+}
+
+func (m *mockUserStore) UpdateProfile(userID int, firstName, lastName, email string) error {
+	for key, user := range m.users {
+		if user.ID == userID {
+			if user.Email != email {
+				user.EmailVerifiedAt = nil
+			}
+			user.FirstName, user.LastName, user.Email = firstName, lastName, email
+			delete(m.users, key)
+			m.users[email] = user
+			return nil
+		}
+	}
+	return fmt.Errorf("user not found")
+}
+
+func (m *mockUserStore) SetUserActive(userID int, active bool) error {
+	return m.update(userID, func(u *types.User) { u.IsActive = active })
+}
+
+func (m *mockUserStore) update(userID int, fn func(*types.User)) error {
+	for email, user := range m.users {
+		if user.ID == userID {
+			fn(&user)
+			m.users[email] = user
+			return nil
+		}
+	}
+	return fmt.Errorf("user not found")
+}
+
+func (m *mockUserStore) SetTOTPSecret(userID int, secret string) error {
+	return m.update(userID, func(u *types.User) {
+		u.TOTPSecret = secret
+		u.TOTPEnabled = false
+	})
+}
+
+func (m *mockUserStore) EnableTOTP(userID int) error {
+	return m.update(userID, func(u *types.User) { u.TOTPEnabled = true })
+}
+
+func (m *mockUserStore) DisableTOTP(userID int) error {
+	delete(m.recoveryCodes, userID)
+	return m.update(userID, func(u *types.User) {
+		u.TOTPSecret = ""
+		u.TOTPEnabled = false
+	})
+}
+
+func (m *mockUserStore) UseTOTPStep(userID int, step int64) (bool, error) {
+	if step <= m.totpSteps[userID] {
+		return false, nil
+	}
+	m.totpSteps[userID] = step
+	return true, nil
+}
+
+func (m *mockUserStore) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	m.recoveryCodes[userID] = make(map[string]bool)
+	for _, hash := range codeHashes {
+		m.recoveryCodes[userID][hash] = false
+	}
+	return nil
+}
+
+func (m *mockUserStore) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	used, ok := m.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+// mockUserTokenStore - Mock implementation of the user token store
+type mockUserTokenStore struct {
+	tokens map[string]types.UserToken
+	used   map[string]bool
+}
+
+func newMockUserTokenStore() *mockUserTokenStore {
+	return &mockUserTokenStore{tokens: make(map[string]types.UserToken), used: make(map[string]bool)}
+}
+
+func (m *mockUserTokenStore) CreateUserToken(token types.UserToken) error {
+	m.tokens[token.JTI] = token
+	return nil
+}
+
+func (m *mockUserTokenStore) ConsumeUserToken(jti string, purpose string) error {
+	token, ok := m.tokens[jti]
+	if !ok || token.Purpose != purpose || m.used[jti] {
+		return fmt.Errorf("token already used or expired")
+	}
+	m.used[jti] = true
+	return nil
+}
+
+// mockMailer - Mailer that keeps every email in memory
+type mockMailer struct {
+	sent []sentMail
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+func (m *mockMailer) Send(to, subject, body string) error {
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// lastToken returns the token from the link in the last email sent to the address
+func (m *mockMailer) lastToken(t testing.TB, to string) string {
+	t.Helper()
+
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].to != to {
+			continue
+		}
+		match := tokenPattern.FindStringSubmatch(m.sent[i].body)
+		if match == nil {
+			t.Fatalf("no token found in email: %s", m.sent[i].body)
+		}
+		token, err := url.QueryUnescape(match[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	t.Fatalf("no email sent to %s", to)
+	return ""
+}
+
+// mockSessionStore - Mock implementation of the session store
+type mockSessionStore struct {
+	sessions map[int]types.Session
+}
+
+func newMockSessionStore() *mockSessionStore {
+	return &mockSessionStore{sessions: make(map[int]types.Session)}
+}
+
+func (m *mockSessionStore) CreateSession(session types.Session) (int, error) {
+	session.ID = len(m.sessions) + 1
+	m.sessions[session.ID] = session
+	return session.ID, nil
+}
+
+func (m *mockSessionStore) GetSessionByRefreshToken(tokenHash string) (*types.Session, error) {
+	for _, s := range m.sessions {
+		if s.TokenHash == tokenHash {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found")
+}
+
+func (m *mockSessionStore) RotateRefreshToken(sessionID int, oldHash, newHash string, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *mockSessionStore) IsSessionActive(sessionID int) (bool, error) {
+	_, ok := m.sessions[sessionID]
+	return ok, nil
+}
+
+func (m *mockSessionStore) GetActiveSessionsForUser(userID int) ([]types.Session, error) {
 	return nil, nil
 }
+
+func (m *mockSessionStore) RevokeSession(userID, sessionID int) error {
+	return nil
+}
+
+func (m *mockSessionStore) RevokeAllSessionsForUser(userID int) error {
+	return nil
+}
+
+// mockLoginAttemptStore - Mock implementation of the login attempt store
+type mockLoginAttemptStore struct {
+	attempts map[string]types.LoginAttempt
+}
+
+func newMockLoginAttemptStore() *mockLoginAttemptStore {
+	return &mockLoginAttemptStore{attempts: make(map[string]types.LoginAttempt)}
+}
+
+func (m *mockLoginAttemptStore) reset() {
+	m.attempts = make(map[string]types.LoginAttempt)
+}
+
+func (m *mockLoginAttemptStore) GetLoginAttempt(key string) (*types.LoginAttempt, error) {
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (m *mockLoginAttemptStore) IncrementLoginFailures(key string, now time.Time, resetAfter time.Duration) (int, error) {
+	attempt, ok := m.attempts[key]
+	if !ok || now.Sub(attempt.LastFailedAt) > resetAfter {
+		attempt = types.LoginAttempt{Key: key}
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = now
+	m.attempts[key] = attempt
+	return attempt.Failures, nil
+}
+
+func (m *mockLoginAttemptStore) LockLogin(key string, until time.Time) error {
+	attempt := m.attempts[key]
+	if attempt.LockedUntil == nil || until.After(*attempt.LockedUntil) {
+		attempt.LockedUntil = &until
+	}
+	m.attempts[key] = attempt
+	return nil
+}
+
+func (m *mockLoginAttemptStore) ClearLoginAttempts(key string) error {
+	delete(m.attempts, key)
+	return nil
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/maximis3d/issue-tracking-system/types"
 )
@@ -15,8 +16,11 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
+// userColumns must stay in the same order as the scan in scanRowsIntoUser
+const userColumns = "id, firstName, lastName, email, password, is_admin, is_active, email_verified_at, totp_secret, totp_enabled, createdAt"
+
 func (s *Store) GetUserByEmail(email string) (*types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE email = ?", email)
+	rows, err := s.db.Query("SELECT "+userColumns+" FROM users WHERE email = ?", email)
 
 	if err != nil {
 		return nil, err
@@ -40,6 +44,8 @@ func (s *Store) GetUserByEmail(email string) (*types.User, error) {
 
 func scanRowsIntoUser(rows *sql.Rows) (*types.User, error) {
 	user := new(types.User)
+	var emailVerifiedAt sql.NullTime
+	var totpSecret sql.NullString
 
 	err := rows.Scan(
 		&user.ID,
@@ -47,6 +53,11 @@ func scanRowsIntoUser(rows *sql.Rows) (*types.User, error) {
 		&user.LastName,
 		&user.Email,
 		&user.Password,
+		&user.IsAdmin,
+		&user.IsActive,
+		&emailVerifiedAt,
+		&totpSecret,
+		&user.TOTPEnabled,
 		&user.CreatedAt,
 	)
 
@@ -54,11 +65,16 @@ func scanRowsIntoUser(rows *sql.Rows) (*types.User, error) {
 		return nil, err
 	}
 
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	user.TOTPSecret = totpSecret.String
+
 	return user, nil
 }
 
 func (s *Store) GetUserByID(id int) (*types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE id = ?", id)
+	rows, err := s.db.Query("SELECT "+userColumns+" FROM users WHERE id = ?", id)
 	if err != nil {
 		return nil, err
 	}
@@ -87,3 +103,214 @@ func (s *Store) CreateUser(user types.User) error {
 
 	return nil
 }
+
+func (s *Store) MarkEmailVerified(userID int) error {
+	_, err := s.db.Exec("UPDATE users SET email_verified_at = NOW() WHERE id = ? AND email_verified_at IS NULL", userID)
+	if err != nil {
+		return fmt.Errorf("failed to verify email: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) UpdatePassword(userID int, hashedPassword string) error {
+	_, err := s.db.Exec("UPDATE users SET password = ? WHERE id = ?", hashedPassword, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) UpdateProfile(userID int, firstName, lastName, email string) error {
+	_, err := s.db.Exec(`
+		UPDATE users
+		SET email_verified_at = IF(email = ?, email_verified_at, NULL), firstName = ?, lastName = ?, email = ?
+		WHERE id = ?`, email, firstName, lastName, email, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update profile: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) SetUserActive(userID int, active bool) error {
+	_, err := s.db.Exec("UPDATE users SET is_active = ? WHERE id = ?", active, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) CreateUserToken(token types.UserToken) error {
+	_, err := s.db.Exec("INSERT INTO user_tokens (jti, user_id, purpose, expires_at) VALUES (?, ?, ?, ?)",
+		token.JTI, token.UserID, token.Purpose, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert user token: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) ConsumeUserToken(jti string, purpose string) error {
+	res, err := s.db.Exec(`
+		UPDATE user_tokens SET used_at = NOW()
+		WHERE jti = ? AND purpose = ? AND used_at IS NULL AND expires_at > NOW()`, jti, purpose)
+	if err != nil {
+		return fmt.Errorf("failed to consume user token: %v", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to consume user token: %v", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("token already used or expired")
+	}
+
+	return nil
+}
+
+func (s *Store) GetLoginAttempt(key string) (*types.LoginAttempt, error) {
+	attempt := new(types.LoginAttempt)
+	var lockedUntil sql.NullTime
+
+	err := s.db.QueryRow("SELECT throttle_key, failures, last_failed_at, locked_until FROM login_attempts WHERE throttle_key = ?", key).
+		Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailedAt, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch login attempts: %v", err)
+	}
+
+	if lockedUntil.Valid {
+		attempt.LockedUntil = &lockedUntil.Time
+	}
+
+	return attempt, nil
+}
+
+// IncrementLoginFailures counts the failure in the upsert itself, which keeps
+// the row locked until the new count is read back.
+func (s *Store) IncrementLoginFailures(key string, now time.Time, resetAfter time.Duration) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO login_attempts (throttle_key, failures, last_failed_at)
+		VALUES (?, 1, ?)
+		ON DUPLICATE KEY UPDATE
+			failures = IF(last_failed_at < ?, 1, failures + 1),
+			locked_until = IF(last_failed_at < ?, NULL, locked_until),
+			last_failed_at = VALUES(last_failed_at)`,
+		key, now, now.Add(-resetAfter), now.Add(-resetAfter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count login failure: %v", err)
+	}
+
+	var failures int
+	if err := tx.QueryRow("SELECT failures FROM login_attempts WHERE throttle_key = ?", key).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("failed to fetch login failures: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return failures, nil
+}
+
+func (s *Store) LockLogin(key string, until time.Time) error {
+	_, err := s.db.Exec("UPDATE login_attempts SET locked_until = GREATEST(COALESCE(locked_until, ?), ?) WHERE throttle_key = ?", until, until, key)
+	if err != nil {
+		return fmt.Errorf("failed to lock login: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) ClearLoginAttempts(key string) error {
+	_, err := s.db.Exec("DELETE FROM login_attempts WHERE throttle_key = ?", key)
+	if err != nil {
+		return fmt.Errorf("failed to clear login attempts: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) SetTOTPSecret(userID int, secret string) error {
+	_, err := s.db.Exec("UPDATE users SET totp_secret = ?, totp_enabled = FALSE, totp_last_step = 0 WHERE id = ?", secret, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) EnableTOTP(userID int) error {
+	_, err := s.db.Exec("UPDATE users SET totp_enabled = TRUE WHERE id = ? AND totp_secret IS NOT NULL", userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) DisableTOTP(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0 WHERE id = ?", userID); err != nil {
+		return fmt.Errorf("failed to disable totp: %v", err)
+	}
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep only moves totp_last_step forward, so a code can't be replayed
+// even by concurrent requests.
+func (s *Store) UseTOTPStep(userID int, step int64) (bool, error) {
+	res, err := s.db.Exec("UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record totp step: %v", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record totp step: %v", err)
+	}
+	return affected > 0, nil
+}
+
+func (s *Store) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return fmt.Errorf("failed to insert recovery code: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	res, err := s.db.Exec("UPDATE recovery_codes SET used_at = NOW() WHERE user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %v", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %v", err)
+	}
+	return affected > 0, nil
+}
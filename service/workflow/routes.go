@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+	"github.com/maximis3d/issue-tracking-system/utils"
+)
+
+type Handler struct {
+	store types.WorkflowStore
+	roles types.RoleStore
+}
+
+func NewHandler(store types.WorkflowStore, roles types.RoleStore) *Handler {
+	return &Handler{store: store, roles: roles}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/projects/{key}/workflow", h.handleGetWorkflow).Methods("GET")
+	router.HandleFunc("/projects/{key}/workflow", h.handleSaveWorkflow).Methods("PUT")
+}
+
+func (h *Handler) handleGetWorkflow(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermViewIssues) {
+		return
+	}
+
+	wf, err := h.store.GetWorkflow(projectKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, wf)
+}
+
+// handleSaveWorkflow replaces the statuses and transitions of the project.
+// Statuses are listed in board order.
+func (h *Handler) handleSaveWorkflow(w http.ResponseWriter, r *http.Request) {
+	projectKey := mux.Vars(r)["key"]
+
+	if !auth.RequireProjectPermission(w, r, h.roles, projectKey, auth.PermManageWorkflow) {
+		return
+	}
+
+	var wf types.Workflow
+	if err := utils.ParseJSON(r, &wf); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(wf); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	wf.ProjectKey = projectKey
+	if err := Validate(wf); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.SaveWorkflow(wf); err != nil {
+		if errors.Is(err, ErrStatusInUse) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	saved, err := h.store.GetWorkflow(projectKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, saved)
+}
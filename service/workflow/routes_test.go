@@ -0,0 +1,178 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/maximis3d/issue-tracking-system/service/auth"
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+func TestWorkflowServiceHandlers(t *testing.T) {
+	store := &mockWorkflowStore{
+		workflows: map[string]types.Workflow{"PRJ": Default("PRJ")},
+		used:      map[string][]string{"PRJ": {"open", "resolved"}},
+	}
+	roles := &mockRoleStore{roles: map[int]map[string]string{
+		1: {"PRJ": "maintainer"},
+		2: {"PRJ": "member"},
+	}}
+	handler := NewHandler(store, roles)
+
+	review := types.Workflow{
+		Statuses: []types.WorkflowStatus{
+			{Name: "open", Category: types.StatusCategoryTodo},
+			{Name: "in_progress", Category: types.StatusCategoryInProgress},
+			{Name: "review", Category: types.StatusCategoryInProgress},
+			{Name: "resolved", Category: types.StatusCategoryDone},
+		},
+		Transitions: []types.WorkflowTransition{
+			{From: "open", To: "in_progress"},
+			{From: "in_progress", To: "review"},
+			{From: "review", To: "resolved"},
+			{From: "resolved", To: "open"},
+		},
+	}
+
+	t.Run("Get Workflow", func(t *testing.T) {
+		t.Run("should return the statuses and transitions", func(t *testing.T) {
+			rr := testRequest(t, handler, 2, http.MethodGet, "/projects/PRJ/workflow", nil, http.StatusOK)
+
+			var wf types.Workflow
+			if err := json.NewDecoder(rr.Body).Decode(&wf); err != nil {
+				t.Fatal(err)
+			}
+			if len(wf.Statuses) != 3 || len(wf.Transitions) != 6 {
+				t.Errorf("expected the default workflow, got %+v", wf)
+			}
+		})
+
+		t.Run("should be forbidden for non members", func(t *testing.T) {
+			testRequest(t, handler, 2, http.MethodGet, "/projects/OTHER/workflow", nil, http.StatusForbidden)
+		})
+	})
+
+	t.Run("Save Workflow", func(t *testing.T) {
+		t.Run("should be forbidden for members", func(t *testing.T) {
+			testRequest(t, handler, 2, http.MethodPut, "/projects/PRJ/workflow", review, http.StatusForbidden)
+		})
+
+		t.Run("should fail if a category is unknown", func(t *testing.T) {
+			invalid := types.Workflow{Statuses: []types.WorkflowStatus{{Name: "open", Category: "waiting"}}}
+			testRequest(t, handler, 1, http.MethodPut, "/projects/PRJ/workflow", invalid, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if status names differ only in case", func(t *testing.T) {
+			invalid := types.Workflow{Statuses: []types.WorkflowStatus{
+				{Name: "open", Category: types.StatusCategoryTodo},
+				{Name: "Open", Category: types.StatusCategoryTodo},
+			}}
+			testRequest(t, handler, 1, http.MethodPut, "/projects/PRJ/workflow", invalid, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if a transition uses an unknown status", func(t *testing.T) {
+			invalid := review
+			invalid.Transitions = append([]types.WorkflowTransition{}, review.Transitions...)
+			invalid.Transitions = append(invalid.Transitions, types.WorkflowTransition{From: "open", To: "blocked"})
+			testRequest(t, handler, 1, http.MethodPut, "/projects/PRJ/workflow", invalid, http.StatusBadRequest)
+		})
+
+		t.Run("should fail if a status in use is removed", func(t *testing.T) {
+			invalid := types.Workflow{Statuses: review.Statuses[:3]}
+			testRequest(t, handler, 1, http.MethodPut, "/projects/PRJ/workflow", invalid, http.StatusConflict)
+		})
+
+		t.Run("should replace the workflow", func(t *testing.T) {
+			rr := testRequest(t, handler, 1, http.MethodPut, "/projects/PRJ/workflow", review, http.StatusOK)
+
+			var wf types.Workflow
+			if err := json.NewDecoder(rr.Body).Decode(&wf); err != nil {
+				t.Fatal(err)
+			}
+			if wf.ProjectKey != "PRJ" || len(wf.Statuses) != 4 || wf.Statuses[2].Name != "review" || len(wf.Transitions) != 4 {
+				t.Errorf("unexpected workflow %+v", wf)
+			}
+		})
+	})
+}
+
+// testRequest - Helper function to perform HTTP requests as a user and check the response
+func testRequest(t testing.TB, handler *Handler, userID int, method, path string, payload any, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body []byte
+	if payload != nil {
+		marshalled, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = marshalled
+	}
+
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserKey, userID))
+
+	rr := httptest.NewRecorder()
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, rr.Code)
+		fmt.Printf("Response Body: %s\n", rr.Body.String())
+	}
+
+	return rr
+}
+
+// mockWorkflowStore - Mock implementation of the workflow store, used lists the statuses issues are in
+type mockWorkflowStore struct {
+	workflows map[string]types.Workflow
+	used      map[string][]string
+}
+
+func (m *mockWorkflowStore) GetWorkflow(projectKey string) (*types.Workflow, error) {
+	wf, ok := m.workflows[projectKey]
+	if !ok {
+		return nil, fmt.Errorf("workflow for project %s not found", projectKey)
+	}
+	return &wf, nil
+}
+
+func (m *mockWorkflowStore) SaveWorkflow(wf types.Workflow) error {
+	for _, status := range m.used[wf.ProjectKey] {
+		kept := false
+		for _, s := range wf.Statuses {
+			kept = kept || strings.EqualFold(s.Name, status)
+		}
+		if !kept {
+			return fmt.Errorf("%w: %s", ErrStatusInUse, status)
+		}
+	}
+	m.workflows[wf.ProjectKey] = wf
+	return nil
+}
+
+// mockRoleStore - Mock implementation of the role store, roles per user and project
+type mockRoleStore struct {
+	roles map[int]map[string]string
+}
+
+func (m *mockRoleStore) GetUserRole(projectKey string, userID int) (string, error) {
+	return m.roles[userID][projectKey], nil
+}
+
+func (m *mockRoleStore) MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,126 @@
+package workflow
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// ErrStatusInUse is returned when a saved workflow drops a status that
+// issues are still in.
+var ErrStatusInUse = errors.New("status is still used by issues")
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetWorkflow(projectKey string) (*types.Workflow, error) {
+	rows, err := s.db.Query("SELECT name, category FROM workflow_statuses WHERE project_key = ? ORDER BY position, name", projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statuses: %v", err)
+	}
+	defer rows.Close()
+
+	wf := &types.Workflow{ProjectKey: projectKey, Transitions: []types.WorkflowTransition{}}
+	for rows.Next() {
+		var status types.WorkflowStatus
+		if err := rows.Scan(&status.Name, &status.Category); err != nil {
+			return nil, fmt.Errorf("failed to scan status row: %v", err)
+		}
+		wf.Statuses = append(wf.Statuses, status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+	if len(wf.Statuses) == 0 {
+		return nil, fmt.Errorf("workflow for project %s not found", projectKey)
+	}
+
+	rows, err = s.db.Query("SELECT from_status, to_status FROM workflow_transitions WHERE project_key = ? ORDER BY from_status, to_status", projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transitions: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t types.WorkflowTransition
+		if err := rows.Scan(&t.From, &t.To); err != nil {
+			return nil, fmt.Errorf("failed to scan transition row: %v", err)
+		}
+		wf.Transitions = append(wf.Transitions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	return wf, nil
+}
+
+// SaveWorkflow replaces the workflow while holding the project row, which
+// issue creation locks as well, so no issue lands in a status being removed.
+func (s *Store) SaveWorkflow(wf types.Workflow) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var projectID int
+	err = tx.QueryRow("SELECT id FROM projects WHERE project_key = ? FOR UPDATE", wf.ProjectKey).Scan(&projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("project %s not found", wf.ProjectKey)
+		}
+		return fmt.Errorf("failed to lock project: %v", err)
+	}
+
+	kept := make(map[string]bool)
+	for _, status := range wf.Statuses {
+		kept[strings.ToLower(status.Name)] = true
+	}
+
+	rows, err := tx.Query("SELECT DISTINCT status FROM issues WHERE project_key = ?", wf.ProjectKey)
+	if err != nil {
+		return fmt.Errorf("failed to query used statuses: %v", err)
+	}
+	var removed []string
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan status row: %v", err)
+		}
+		if !kept[strings.ToLower(status)] {
+			removed = append(removed, status)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error after iterating rows: %v", err)
+	}
+	if len(removed) > 0 {
+		return fmt.Errorf("%w: %s", ErrStatusInUse, strings.Join(removed, ", "))
+	}
+
+	// Transitions go with their statuses through the foreign keys
+	if _, err := tx.Exec("DELETE FROM workflow_statuses WHERE project_key = ?", wf.ProjectKey); err != nil {
+		return fmt.Errorf("failed to clear workflow: %v", err)
+	}
+
+	if err := InsertWorkflow(tx, wf); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/maximis3d/issue-tracking-system/types"
+)
+
+// Default is the workflow given to new projects, the statuses the tracker
+// used before workflows were configurable with every transition allowed.
+func Default(projectKey string) types.Workflow {
+	statuses := []types.WorkflowStatus{
+		{Name: "open", Category: types.StatusCategoryTodo},
+		{Name: "in_progress", Category: types.StatusCategoryInProgress},
+		{Name: "resolved", Category: types.StatusCategoryDone},
+	}
+
+	var transitions []types.WorkflowTransition
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if from.Name != to.Name {
+				transitions = append(transitions, types.WorkflowTransition{From: from.Name, To: to.Name})
+			}
+		}
+	}
+
+	return types.Workflow{ProjectKey: projectKey, Statuses: statuses, Transitions: transitions}
+}
+
+// Validate checks that status names are unique, ignoring case as the
+// database does, and that transitions connect two different known statuses.
+func Validate(wf types.Workflow) error {
+	names := make(map[string]bool)
+	for _, s := range wf.Statuses {
+		name := strings.ToLower(s.Name)
+		if strings.TrimSpace(s.Name) != s.Name || s.Name == "" {
+			return fmt.Errorf("invalid status name %q", s.Name)
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate status %s", s.Name)
+		}
+		names[name] = true
+	}
+
+	seen := make(map[types.WorkflowTransition]bool)
+	for _, t := range wf.Transitions {
+		if !names[strings.ToLower(t.From)] {
+			return fmt.Errorf("transition from unknown status %s", t.From)
+		}
+		if !names[strings.ToLower(t.To)] {
+			return fmt.Errorf("transition to unknown status %s", t.To)
+		}
+		if strings.EqualFold(t.From, t.To) {
+			return fmt.Errorf("transition from %s to itself", t.From)
+		}
+		key := types.WorkflowTransition{From: strings.ToLower(t.From), To: strings.ToLower(t.To)}
+		if seen[key] {
+			return fmt.Errorf("duplicate transition from %s to %s", t.From, t.To)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// InsertWorkflow writes the statuses and transitions of a project that has
+// none, callers own the transaction.
+func InsertWorkflow(tx *sql.Tx, wf types.Workflow) error {
+	for i, s := range wf.Statuses {
+		_, err := tx.Exec("INSERT INTO workflow_statuses (project_key, name, category, position) VALUES (?, ?, ?, ?)",
+			wf.ProjectKey, s.Name, s.Category, i)
+		if err != nil {
+			return fmt.Errorf("failed to insert status %s: %v", s.Name, err)
+		}
+	}
+
+	for _, t := range wf.Transitions {
+		_, err := tx.Exec("INSERT INTO workflow_transitions (project_key, from_status, to_status) VALUES (?, ?, ?)",
+			wf.ProjectKey, t.From, t.To)
+		if err != nil {
+			return fmt.Errorf("failed to insert transition from %s to %s: %v", t.From, t.To, err)
+		}
+	}
+
+	return nil
+}
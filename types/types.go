@@ -1,7 +1,9 @@
 package types
 
 import (
+	"context"
 	"database/sql"
+	"io"
 	"time"
 )
 
@@ -9,14 +11,24 @@ type UserStore interface {
 	GetUserByEmail(email string) (*User, error)
 	GetUserByID(id int) (*User, error)
 	CreateUser(User) error
+	MarkEmailVerified(userID int) error
+	UpdatePassword(userID int, hashedPassword string) error
+	// UpdateProfile also clears the email verification when the email changes.
+	UpdateProfile(userID int, firstName, lastName, email string) error
+	SetUserActive(userID int, active bool) error
 }
 type User struct {
-	ID        int       `json:"id"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
-	Email     string    `json:"email"`
-	Password  string    `json:"="`
-	CreatedAt time.Time `json:"createdAt"`
+	ID              int        `json:"id"`
+	FirstName       string     `json:"firstName"`
+	LastName        string     `json:"lastName"`
+	Email           string     `json:"email"`
+	Password        string     `json:"-"`
+	IsAdmin         bool       `json:"isAdmin"`
+	IsActive        bool       `json:"isActive"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	TOTPSecret      string     `json:"-"`
+	TOTPEnabled     bool       `json:"twoFactorEnabled"`
+	CreatedAt       time.Time  `json:"createdAt"`
 }
 
 type RegisterUserPayload struct {
@@ -31,6 +43,219 @@ type LoginUserPayload struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type UpdateProfilePayload struct {
+	FirstName *string `json:"firstName" validate:"omitempty,min=1,max=255"`
+	LastName  *string `json:"lastName" validate:"omitempty,min=1,max=255"`
+	Email     *string `json:"email" validate:"omitempty,email"`
+	// CurrentPassword and Code are only needed to change the email.
+	CurrentPassword string `json:"currentPassword"`
+	Code            string `json:"code"`
+}
+
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=3,max=100"`
+	// Code is needed when two-factor authentication is on
+	Code string `json:"code"`
+}
+
+type EmailPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type VerifyEmailPayload struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// TwoFactorChallenge is returned by login instead of a token pair when the
+// user has two-factor authentication enabled.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+}
+
+type TwoFactorLoginPayload struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorCodePayload struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorPayload struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	Code            string `json:"code" validate:"required"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorStore interface {
+	// SetTOTPSecret stores a pending secret, two-factor stays disabled until EnableTOTP.
+	SetTOTPSecret(userID int, secret string) error
+	EnableTOTP(userID int) error
+	// DisableTOTP removes the secret and every recovery code of the user.
+	DisableTOTP(userID int) error
+	// UseTOTPStep records the time step of an accepted code, returning false
+	// if that step or a later one was already used.
+	UseTOTPStep(userID int, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID int, codeHashes []string) error
+	// UseRecoveryCode marks the code as used, returning false if it is unknown or already used.
+	UseRecoveryCode(userID int, codeHash string) (bool, error)
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=3,max=100"`
+}
+
+// UserToken records a single use token sent by email, identified by the jti
+// claim of the signed token.
+type UserToken struct {
+	JTI       string
+	UserID    int
+	Purpose   string
+	ExpiresAt time.Time
+}
+
+type UserTokenStore interface {
+	CreateUserToken(UserToken) error
+	// ConsumeUserToken marks the token as used, failing if it was already used.
+	ConsumeUserToken(jti string, purpose string) error
+}
+
+// BlobStore keeps file contents such as attachments, addressed by key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns blob.ErrNotFound when there is no blob for key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Mailer sends plain text emails.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// LoginAttempt tracks consecutive failed logins for a throttling key, either
+// an email address or a client IP.
+type LoginAttempt struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
+
+type LoginAttemptStore interface {
+	// GetLoginAttempt returns nil when the key has no recorded failures.
+	GetLoginAttempt(key string) (*LoginAttempt, error)
+	// IncrementLoginFailures atomically counts a failure for the key and
+	// returns the new count, failures older than resetAfter are forgotten.
+	IncrementLoginFailures(key string, now time.Time, resetAfter time.Duration) (int, error)
+	// LockLogin locks the key until the given time, a later lock is kept.
+	LockLogin(key string, until time.Time) error
+	ClearLoginAttempts(key string) error
+}
+
+// SSOState is kept between the redirect to the identity provider and the
+// callback, so the PKCE verifier and nonce never leave the server.
+type SSOState struct {
+	State        string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
+
+type SSOCallbackPayload struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type SSOStore interface {
+	CreateSSOState(SSOState) error
+	// ConsumeSSOState returns and deletes the state, failing if it is unknown or expired.
+	ConsumeSSOState(state string) (*SSOState, error)
+	// GetUserIDByIdentity returns 0 when no user is linked to the identity.
+	GetUserIDByIdentity(issuer, subject string) (int, error)
+	LinkIdentity(userID int, issuer, subject string) error
+}
+
+// ProjectRoleSyncStore applies project roles granted outside of the
+// application, an empty role removes the user from the project.
+type ProjectRoleSyncStore interface {
+	SetProjectRole(projectKey string, userID int, role string) error
+}
+
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// TokenPair is handed out on login and on every refresh. The refresh token is
+// single use, refreshing returns a new pair and invalidates the old one.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+type Session struct {
+	ID                int        `json:"id"`
+	UserID            int        `json:"userId"`
+	TokenHash         string     `json:"-"`
+	PreviousTokenHash string     `json:"-"`
+	UserAgent         string     `json:"userAgent"`
+	IPAddress         string     `json:"ipAddress"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	LastUsedAt        time.Time  `json:"lastUsedAt"`
+	ExpiresAt         time.Time  `json:"expiresAt"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty"`
+	Current           bool       `json:"current"`
+}
+
+type SessionStore interface {
+	CreateSession(Session) (int, error)
+	GetSessionByRefreshToken(tokenHash string) (*Session, error)
+	RotateRefreshToken(sessionID int, oldHash, newHash string, expiresAt time.Time) error
+	IsSessionActive(sessionID int) (bool, error)
+	GetActiveSessionsForUser(userID int) ([]Session, error)
+	RevokeSession(userID, sessionID int) error
+	RevokeAllSessionsForUser(userID int) error
+}
+
+// AccessToken is a named personal access token for scripts and CI. Only the
+// hash of the token is stored, the token itself is shown once on creation.
+type AccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"userId"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type AccessTokenPayload struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays" validate:"min=0,max=365"`
+}
+
+type AccessTokenStore interface {
+	CreateAccessToken(AccessToken) (int, error)
+	GetAccessTokenByHash(tokenHash string) (*AccessToken, error)
+	GetAccessTokensForUser(userID int) ([]AccessToken, error)
+	UpdateAccessTokenLastUsed(tokenID int) error
+	RevokeAccessToken(userID, tokenID int) error
+}
+
 type Project struct {
 	ID          int       `json:"id"`
 	ProjectKey  string    `json:"project_key"`
@@ -39,13 +264,21 @@ type Project struct {
 	ProjectLead int       `json:"projectLead"`
 	IssueCount  int       `json:"issueCount"`
 	WIPLimit    int       `json:"wip_limit"`
+	Require2FA  bool      `json:"require_2fa"`
 	CreatedAt   time.Time `json:"createdAt"`
+
+	// PointScale lists the story points issues can be given, in ascending
+	// order. An empty scale allows any number of points.
+	PointScale []float64 `json:"point_scale"`
 }
 
 type ProjectStore interface {
 	GetProjectByKey(name string) (*Project, error)
 	GetProjects() ([]Project, error)
 	CreateProject(Project) error
+	UpdateWIPLimit(projectKey string, wipLimit int) error
+	UpdateRequire2FA(projectKey string, required bool) error
+	UpdatePointScale(projectKey string, scale []float64) error
 }
 type ProjectPayload struct {
 	ProjectKey  string `json:"project_key" validate:"required"`
@@ -55,6 +288,18 @@ type ProjectPayload struct {
 	WIPLimit    int    `json:"wip_limit" validate:"required"`
 }
 
+type WIPLimitPayload struct {
+	WIPLimit int `json:"wip_limit" validate:"required,min=1"`
+}
+
+type Require2FAPayload struct {
+	Required *bool `json:"required" validate:"required"`
+}
+
+type PointScalePayload struct {
+	Scale []float64 `json:"scale" validate:"max=20,dive,gte=0,lte=1000"`
+}
+
 type Issue struct {
 	ID          int    `json:"id"`
 	Summary     string `json:"summary" validate:"required"`
@@ -64,9 +309,47 @@ type Issue struct {
 	Reporter    string `json:"reporter" validate:"required"`
 	Assignee    string `json:"assignee" validate:"required"`
 	Status      string `json:"status" validate:"required"`
-	IssueType   string `json:"issueType" validate:"required"`
+	IssueType   string `json:"issueType" validate:"required,oneof=bug task story epic sub-task"`
 	SprintID    int    `json:"sprint_id"`
 
+	// ParentID places the issue in the hierarchy, sub-tasks sit under
+	// stories and tasks, which sit under epics. DeriveStatus makes an epic
+	// follow the statuses of its children.
+	ParentID     int  `json:"parent_id"`
+	DeriveStatus bool `json:"derive_status"`
+
+	// Labels are free-form and created on first use, Components name
+	// components of the project. Both are kept sorted.
+	Labels     []string `json:"labels" validate:"max=10"`
+	Components []string `json:"components" validate:"max=10"`
+
+	// Priority runs from P0, the most urgent, to P4. Severity is only set
+	// on bugs, DueDate is a date like 2025-06-30 or empty.
+	Priority string `json:"priority" validate:"omitempty,oneof=P0 P1 P2 P3 P4"`
+	Severity string `json:"severity" validate:"omitempty,oneof=critical major minor trivial"`
+	DueDate  string `json:"due_date" validate:"omitempty,datetime=2006-01-02"`
+
+	// StoryPoints come from the point scale of the project. The estimates
+	// are in minutes, the remaining estimate starts out as the original one.
+	// All three are nil when not estimated.
+	StoryPoints       *float64 `json:"story_points" validate:"omitnil,gte=0"`
+	OriginalEstimate  *int     `json:"original_estimate" validate:"omitnil,gte=0"`
+	RemainingEstimate *int     `json:"remaining_estimate" validate:"omitnil,gte=0"`
+
+	// Version goes up with every update, updates have to name the version
+	// they were made against.
+	Version int `json:"version"`
+
+	// ReporterID and AssigneeID reference users, the resolved users are
+	// filled in by the store and are nil when the user no longer exists.
+	ReporterID   int          `json:"reporter_id"`
+	AssigneeID   int          `json:"assignee_id"`
+	ReporterUser *UserSummary `json:"reporter_user"`
+	AssigneeUser *UserSummary `json:"assignee_user"`
+
+	// Links are only filled in for a single issue, updates ignore them.
+	Links []IssueLink `json:"links,omitempty"`
+
 	StartedAt  sql.NullTime
 	FinishedAt sql.NullTime
 	CycleTime  string    `json:"cycle_time"`
@@ -74,35 +357,460 @@ type Issue struct {
 	UpdatedAt  time.Time `json:"updatedAt" validate:"required"`
 }
 
+// UserSummary is the public part of a user shown alongside issues.
+type UserSummary struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email"`
+}
+
 type IssuePayload struct {
 	Summary     string `json:"summary" validate:"required"`
 	Description string `json:"description" validate:"required"`
 	ProjectKey  string `json:"project_key" validate:"required"`
 	Reporter    string `json:"reporter" validate:"required"`
-	Assignee    string `json:"assignee" validate:"required"`
-	Status      string `json:"status" validate:"required"`
-	IssueType   string `json:"issueType" validate:"required"`
-	SprintID    int    `json:"sprint_id"`
+	// Assignee can be left out when one of the components has a default
+	// assignee.
+	Assignee  string `json:"assignee"`
+	Status    string `json:"status" validate:"required"`
+	IssueType string `json:"issueType" validate:"required,oneof=bug task story epic sub-task"`
+	SprintID  int    `json:"sprint_id"`
+	// ParentID is required for sub-tasks
+	ParentID     int      `json:"parent_id" validate:"gte=0"`
+	DeriveStatus bool     `json:"derive_status"`
+	Labels       []string `json:"labels" validate:"max=10"`
+	Components   []string `json:"components" validate:"max=10"`
+	// Priority defaults to P2
+	Priority string `json:"priority" validate:"omitempty,oneof=P0 P1 P2 P3 P4"`
+	Severity string `json:"severity" validate:"omitempty,oneof=critical major minor trivial"`
+	DueDate  string `json:"due_date" validate:"omitempty,datetime=2006-01-02"`
+	// Estimates are in minutes
+	StoryPoints       *float64 `json:"story_points" validate:"omitnil,gte=0"`
+	OriginalEstimate  *int     `json:"original_estimate" validate:"omitnil,gte=0"`
+	RemainingEstimate *int     `json:"remaining_estimate" validate:"omitnil,gte=0"`
 }
 
+// IssueUpdatePayload is a partial update, only the fields present are
+// applied. A sprint_id or parent_id of 0 takes the issue out of its sprint
+// or parent, an empty severity or due_date clears it and so does a negative
+// story_points or estimate.
 type IssueUpdatePayload struct {
-	Summary     *string `json:"summary,omitempty"`
-	Description *string `json:"description,omitempty"`
-	ProjectKey  *string `json:"project_key,omitempty"`
-	Reporter    *string `json:"reporter,omitempty"`
-	Assignee    *string `json:"assignee,omitempty"`
-	Status      *string `json:"status,omitempty"`
-	IssueType   *string `json:"issueType,omitempty"`
-	SprintID    *int    `json:"sprint_id"`
+	Summary      *string   `json:"summary,omitempty"`
+	Description  *string   `json:"description,omitempty"`
+	ProjectKey   *string   `json:"project_key,omitempty"`
+	Reporter     *string   `json:"reporter,omitempty"`
+	Assignee     *string   `json:"assignee,omitempty"`
+	Status       *string   `json:"status,omitempty"`
+	IssueType    *string   `json:"issueType,omitempty"`
+	SprintID     *int      `json:"sprint_id" validate:"omitnil,gte=0"`
+	ParentID     *int      `json:"parent_id" validate:"omitnil,gte=0"`
+	DeriveStatus *bool     `json:"derive_status,omitempty"`
+	Labels       *[]string `json:"labels,omitempty" validate:"omitnil,max=10"`
+	Components   *[]string `json:"components,omitempty" validate:"omitnil,max=10"`
+	Priority     *string   `json:"priority,omitempty" validate:"omitnil,oneof=P0 P1 P2 P3 P4"`
+	Severity     *string   `json:"severity,omitempty"`
+	DueDate      *string   `json:"due_date,omitempty"`
+	Version      *int      `json:"version,omitempty"`
+
+	// Estimates are in minutes
+	StoryPoints       *float64 `json:"story_points,omitempty"`
+	OriginalEstimate  *int     `json:"original_estimate,omitempty"`
+	RemainingEstimate *int     `json:"remaining_estimate,omitempty"`
+}
+
+// IssueFilter narrows and orders an issue listing, zero values don't filter.
+type IssueFilter struct {
+	Statuses   []string
+	IssueTypes []string
+	Assignee   string
+	Reporter   string
+	// SprintID 0 matches issues outside any sprint
+	SprintID *int
+	// ParentID 0 matches issues without a parent
+	ParentID *int
+	// ExcludeTypes leaves out issues of these types, like sub-tasks that
+	// would count twice in metrics
+	ExcludeTypes  []string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	Text          string
+	// Labels and Components match issues with any of them
+	Labels     []string
+	Components []string
+	Priorities []string
+	Severities []string
+	// Overdue matches issues past their due date that are not done
+	Overdue bool
+	// Sort names the field to order by, prefixed with - for descending order
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+// IssuePage is one page of an issue listing, NextCursor is empty on the last
+// page.
+type IssuePage struct {
+	Issues     []Issue `json:"issues"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor"`
+}
+
+// IssueQuery is a compiled search. Where and OrderBy are SQL over the issues
+// table aliased i, joined with its reporter r and assignee a, and Args are
+// bound to the placeholders in Where.
+type IssueQuery struct {
+	Where   string
+	Args    []any
+	OrderBy string
+}
+
+type SearchStore interface {
+	// SearchIssues runs a search over the issues of the projects the user
+	// can view.
+	SearchIssues(userID int, query IssueQuery, limit, offset int) (*IssuePage, error)
+}
+
+// TextQuery is a full-text search over issue summaries, descriptions and
+// comments. Project and ScopeID narrow it down when set.
+type TextQuery struct {
+	Text    string
+	Project string
+	ScopeID int
+	Limit   int
+	Offset  int
+}
+
+// TextSearchHit is an issue matching a full-text search. Highlights holds a
+// snippet of each matching field, HTML escaped with the matches in <mark>.
+type TextSearchHit struct {
+	Issue      Issue             `json:"issue"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+type TextSearchPage struct {
+	Hits  []TextSearchHit `json:"hits"`
+	Total int             `json:"total"`
+}
+
+type SearchIndex interface {
+	// IndexIssue brings the indexed text of an issue and its comments up to
+	// date, it is called whenever either changes.
+	IndexIssue(issueID int) error
+	// SearchText ranks the issues of the projects the user can view.
+	SearchText(userID int, query TextQuery) (*TextSearchPage, error)
+}
+
+// IssueChange is one field change in the history of an issue. Fields are
+// named as in the Issue JSON.
+type IssueChange struct {
+	ID        int          `json:"id"`
+	IssueID   int          `json:"issue_id"`
+	IssueKey  string       `json:"issue_key"`
+	ActorID   int          `json:"actor_id"`
+	Actor     *UserSummary `json:"actor"`
+	Field     string       `json:"field"`
+	OldValue  string       `json:"old_value"`
+	NewValue  string       `json:"new_value"`
+	ChangedAt time.Time    `json:"changed_at"`
 }
 
 type IssueStore interface {
-	CreateIssue(issue Issue) error
-	UpdateIssue(issue Issue) error
+	// CreateIssue returns the id of the new issue.
+	CreateIssue(issue Issue, actorID int) (int, error)
+	// UpdateIssue records every changed field in the issue history.
+	UpdateIssue(issue Issue, actorID int) error
 	GetIssueByID(id int) (*Issue, error)
-	GetIssuesByProject(projectKey string) ([]Issue, error)
-	GetAverageCycleTime(projectKey string) (time.Duration, error)
-	GetWeeklyThroughput(projectKey string) (map[string]int, error)
+	GetIssueHistory(issueID int) ([]IssueChange, error)
+	GetIssuesByProject(projectKey string, filter IssueFilter) (*IssuePage, error)
+	// The metrics only count the issues matching the filter, its sort and
+	// page are ignored.
+	GetAverageCycleTime(projectKey string, filter IssueFilter) (time.Duration, error)
+	GetWeeklyThroughput(projectKey string, filter IssueFilter) (map[string]int, error)
+	// GetBoard flags P0 and P1 issues that are not done as aging once they
+	// have been in their status longer than agingAfter.
+	GetBoard(projectKey string, agingAfter time.Duration) (*Board, error)
+	GetChildren(issueID int) ([]Issue, error)
+	GetProgress(issueID int) (*IssueProgress, error)
+}
+
+// IssueProgress rolls up the children of an issue by status category.
+type IssueProgress struct {
+	IssueID     int         `json:"issue_id"`
+	Total       int         `json:"total"`
+	Todo        int         `json:"todo"`
+	InProgress  int         `json:"in_progress"`
+	Done        int         `json:"done"`
+	PercentDone int         `json:"percent_done"`
+	Points      PointTotals `json:"points"`
+}
+
+// PointTotals add up the estimates of a set of issues. Unestimated counts
+// the issues without story points, estimates are in minutes.
+type PointTotals struct {
+	Issues            int     `json:"issues"`
+	Unestimated       int     `json:"unestimated"`
+	Points            float64 `json:"points"`
+	DonePoints        float64 `json:"done_points"`
+	RemainingPoints   float64 `json:"remaining_points"`
+	OriginalEstimate  int     `json:"original_estimate"`
+	RemainingEstimate int     `json:"remaining_estimate"`
+}
+
+// Board shows the issues of a project by status, in workflow order. Done
+// columns only hold issues finished recently.
+type Board struct {
+	ProjectKey string        `json:"project_key"`
+	AgingDays  int           `json:"aging_days"`
+	Columns    []BoardColumn `json:"columns"`
+	// Aging lists the aging cards of all columns, oldest first
+	Aging []BoardCard `json:"aging"`
+}
+
+type BoardColumn struct {
+	Status   string      `json:"status"`
+	Category string      `json:"category"`
+	Cards    []BoardCard `json:"cards"`
+}
+
+type BoardCard struct {
+	Issue         Issue     `json:"issue"`
+	InStatusSince time.Time `json:"in_status_since"`
+	DaysInStatus  int       `json:"days_in_status"`
+	Aging         bool      `json:"aging"`
+}
+
+// Component is a part of a project issues can be filed against. Issues
+// created with a component and no assignee go to its default assignee.
+type Component struct {
+	ID                int          `json:"id"`
+	ProjectKey        string       `json:"project_key"`
+	Name              string       `json:"name"`
+	Description       string       `json:"description"`
+	DefaultAssigneeID int          `json:"default_assignee_id"`
+	DefaultAssignee   *UserSummary `json:"default_assignee"`
+	IssueCount        int          `json:"issue_count"`
+}
+
+type ComponentPayload struct {
+	Name              string `json:"name" validate:"required,max=64"`
+	Description       string `json:"description" validate:"max=255"`
+	DefaultAssigneeID int    `json:"default_assignee_id" validate:"gte=0"`
+}
+
+type ComponentStore interface {
+	GetComponents(projectKey string) ([]Component, error)
+	CreateComponent(component Component) (int, error)
+	UpdateComponent(component Component) error
+	// DeleteComponent also removes the component from its issues.
+	DeleteComponent(projectKey string, id int) error
+}
+
+// Label is a free-form label of a project with the number of issues
+// carrying it.
+type Label struct {
+	ID         int    `json:"id"`
+	ProjectKey string `json:"project_key"`
+	Name       string `json:"name"`
+	IssueCount int    `json:"issue_count"`
+}
+
+type LabelRenamePayload struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type LabelMergePayload struct {
+	Labels []string `json:"labels" validate:"required,min=1"`
+	Into   string   `json:"into" validate:"required"`
+}
+
+type LabelStore interface {
+	GetLabels(projectKey string) ([]Label, error)
+	// RenameLabel, MergeLabels and DeleteLabel record the label change in the
+	// history of every issue they touch, under actorID.
+	RenameLabel(projectKey, name, newName string, actorID int) error
+	// MergeLabels moves the issues of every label in names to into, creating
+	// it when needed, and deletes the merged labels.
+	MergeLabels(projectKey string, names []string, into string, actorID int) error
+	DeleteLabel(projectKey, name string, actorID int) error
+}
+
+// Link types between issues. A link reads differently from its two sides,
+// an issue that blocks another is blocked by it.
+const (
+	LinkBlocks     = "blocks"
+	LinkDuplicates = "duplicates"
+	LinkRelates    = "relates"
+	LinkClones     = "clones"
+)
+
+// IssueLink is a link as seen from one of its issues. Direction is outward
+// on the issue the link was created from and inward on the other one, the
+// description reads the link from this side, like "is blocked by".
+type IssueLink struct {
+	ID          int         `json:"id"`
+	Type        string      `json:"type"`
+	Direction   string      `json:"direction"`
+	Description string      `json:"description"`
+	Issue       LinkedIssue `json:"issue"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// LinkedIssue is the part of an issue shown on links and dependency graphs.
+type LinkedIssue struct {
+	ID             int    `json:"id"`
+	Key            string `json:"key"`
+	Summary        string `json:"summary"`
+	ProjectKey     string `json:"project"`
+	Status         string `json:"status"`
+	StatusCategory string `json:"status_category"`
+}
+
+type LinkPayload struct {
+	Type string `json:"type" validate:"required,oneof=blocks duplicates relates clones"`
+	// Issue is the key of the issue to link to
+	Issue string `json:"issue" validate:"required"`
+}
+
+// DependencyGraph holds the linked issues of a project, including those of
+// other projects they link to. Edges point from the outward side of a link,
+// Cycles lists the issue keys of every group of issues blocking each other.
+type DependencyGraph struct {
+	ProjectKey string        `json:"project_key"`
+	Nodes      []LinkedIssue `json:"nodes"`
+	Edges      []GraphEdge   `json:"edges"`
+	Cycles     [][]string    `json:"cycles"`
+}
+
+type GraphEdge struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Type string `json:"type"`
+}
+
+type LinkStore interface {
+	GetLinks(issueID int) ([]IssueLink, error)
+	GetLinkedIssue(key string) (*LinkedIssue, error)
+	// CreateLink stores the link from both sides and returns the id of the
+	// outward one. Issues of different projects can only be linked when
+	// the projects share a scope.
+	CreateLink(issueID, linkedIssueID int, linkType string, actorID int) (int, error)
+	// DeleteLink removes a link of the issue from both sides.
+	DeleteLink(issueID, linkID int) error
+	GetDependencyGraph(projectKey string) (*DependencyGraph, error)
+}
+
+// Comment is a comment on an issue. Replies are one level deep, the list
+// endpoint nests them under their parent.
+type Comment struct {
+	ID        int          `json:"id"`
+	IssueID   int          `json:"issue_id"`
+	ParentID  *int         `json:"parent_id"`
+	AuthorID  int          `json:"author_id"`
+	Author    *UserSummary `json:"author"`
+	Body      string       `json:"body"`
+	CreatedAt time.Time    `json:"created_at"`
+	EditedAt  *time.Time   `json:"edited_at"`
+	DeletedAt *time.Time   `json:"deleted_at"`
+	Replies   []Comment    `json:"replies,omitempty"`
+}
+
+// CommentRevision is the body a comment had before one of its edits.
+type CommentRevision struct {
+	ID        int       `json:"id"`
+	CommentID int       `json:"comment_id"`
+	Body      string    `json:"body"`
+	EditedBy  int       `json:"edited_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CommentPayload struct {
+	Body     string `json:"body" validate:"required,max=10000"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type CommentUpdatePayload struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+// CommentStore bumps the updatedAt of the issue on every change, so
+// commented issues show up in standups.
+type CommentStore interface {
+	CreateComment(comment Comment) (int, error)
+	GetCommentByID(id int) (*Comment, error)
+	// GetCommentsByIssue returns the comments of the issue oldest first,
+	// deleted ones included.
+	GetCommentsByIssue(issueID int) ([]Comment, error)
+	// UpdateComment keeps the previous body as a revision.
+	UpdateComment(id int, body string, editorID int) error
+	DeleteComment(id int) error
+	GetCommentRevisions(commentID int) ([]CommentRevision, error)
+}
+
+// Attachment is a file attached to an issue. The content lives in the blob
+// store under its SHA-256, so identical uploads share one blob.
+type Attachment struct {
+	ID          int       `json:"id"`
+	IssueID     int       `json:"issue_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	UploadedBy  int       `json:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AttachmentStore interface {
+	CreateAttachment(attachment Attachment) (int, error)
+	GetAttachmentByID(id int) (*Attachment, error)
+	GetAttachmentsByIssue(issueID int) ([]Attachment, error)
+	DeleteAttachment(id int) error
+	// CountBlobReferences returns the number of attachments sharing the content.
+	CountBlobReferences(sha256 string) (int, error)
+}
+
+// Status categories drive the WIP limit, cycle time and throughput, so
+// projects can name their statuses freely.
+const (
+	StatusCategoryTodo       = "todo"
+	StatusCategoryInProgress = "in_progress"
+	StatusCategoryDone       = "done"
+)
+
+const (
+	IssueTypeBug     = "bug"
+	IssueTypeTask    = "task"
+	IssueTypeStory   = "story"
+	IssueTypeEpic    = "epic"
+	IssueTypeSubTask = "sub-task"
+)
+
+type WorkflowStatus struct {
+	Name     string `json:"name" validate:"required,max=64"`
+	Category string `json:"category" validate:"required,oneof=todo in_progress done"`
+}
+
+type WorkflowTransition struct {
+	From string `json:"from" validate:"required"`
+	To   string `json:"to" validate:"required"`
+}
+
+// Workflow lists the statuses of a project in board order and the
+// transitions allowed between them.
+type Workflow struct {
+	ProjectKey  string               `json:"project_key"`
+	Statuses    []WorkflowStatus     `json:"statuses" validate:"required,min=1,dive"`
+	Transitions []WorkflowTransition `json:"transitions" validate:"dive"`
+}
+
+type WorkflowStore interface {
+	GetWorkflow(projectKey string) (*Workflow, error)
+	// SaveWorkflow replaces the workflow of the project, statuses still used
+	// by issues can't be removed.
+	SaveWorkflow(workflow Workflow) error
 }
 
 type Standup struct {
@@ -120,6 +828,9 @@ type StandupStore interface {
 	FilterTickets(Project) ([]Issue, error)
 	FilterTicketsByEndTime(projectKey string, lastEndTime sql.NullTime) ([]Issue, error)
 	GetLastStandupEndTime(projectKey string) (sql.NullTime, error)
+	// GetChangesSince returns the history of the project's issues after
+	// since, or all of it when since is not valid.
+	GetChangesSince(projectKey string, since sql.NullTime) ([]IssueChange, error)
 }
 
 type Scope struct {
@@ -133,8 +844,9 @@ type Scope struct {
 type ScopeStore interface {
 	CreateScope(Scope) error
 	AddProjectToScope(scopeID int, projectKey string) error
-	GetIssuesByScope(scopeID int) ([]Issue, error)
+	GetIssuesByScope(scopeID int, filter IssueFilter) (*IssuePage, error)
 	GetScopeDetails(scopeId int) (*Scope, error)
+	GetPointTotals(scopeID int) (*PointTotals, error)
 	GetAllScopeDetails() ([]Scope, error)
 	RemoveProjectFromScope(scopeID int, projectKey string) error
 }
@@ -146,17 +858,30 @@ type ProjectAssignment struct {
 	AssignedAt time.Time `json:"assigned_at"`
 }
 
+// RoleStore resolves the role a user holds on a project. An empty role means
+// the user is not a member of the project.
+type RoleStore interface {
+	GetUserRole(projectKey string, userID int) (string, error)
+	// MeetsTwoFactorRequirement is false when the project requires two-factor
+	// authentication and the user has not enabled it.
+	MeetsTwoFactorRequirement(projectKey string, userID int) (bool, error)
+}
+
 type ProjectAssignmentStore interface {
+	RoleStore
 	AssignUserToProject(projectID int, userID int, role string) error
 	RemoveUserFromProject(projectID int, userID int) error
 	GetUsersForProject(projectID int) ([]User, error)
 	GetAllUsers() ([]User, error)
 	IsUserAssignedToProject(projectID int, userID int) (bool, error)
+	GetProjectKey(projectID int) (string, error)
 }
 type SprintStore interface {
 	CreateSprint(sprint Sprint) error
+	GetSprintByID(id int) (*Sprint, error)
 	AddIssueToSprint(issueID, sprintID int) error
-	GetIssuesInSprint(sprintID int) ([]Issue, error)
+	GetIssuesInSprint(sprintID int, filter IssueFilter) (*IssuePage, error)
+	GetPointTotals(sprintID int) (*PointTotals, error)
 }
 type Sprint struct {
 	ID          int       `json:"id"`